@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/server"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// runExport implements the export subcommand: it streams the highlight
+// export of the configured API key as NDJSON, writing each page as it
+// arrives instead of holding the whole library in memory.
+func runExport(cfg types.Config, args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: readwise-mcp-server export [flags]\n\nStream all highlights as newline-delimited JSON. The API key is read from READWISE_API_KEY or READWISE_API_KEY_FILE.\n\n")
+		fs.PrintDefaults()
+	}
+	unit := fs.String("unit", export.UnitSources, "one line per: sources or highlights")
+	updatedAfter := fs.String("updated-after", "", "only export highlights updated after this ISO 8601 timestamp")
+	output := fs.String("o", "", "write to this file instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	logger := newLogger(cfg, os.Stderr)
+	fail := func(msg string, err error) int {
+		logger.Error(msg, "error", err)
+		return 1
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	nw, err := export.NewNDJSONWriter(w, *unit)
+	if err != nil {
+		return fail("invalid -unit", err)
+	}
+
+	apiKey, err := cfg.ResolveAPIKey()
+	if err != nil {
+		return fail("invalid API key configuration", err)
+	}
+
+	var f *os.File
+	if *output != "" {
+		if f, err = os.Create(*output); err != nil {
+			return fail("failed to create output file", err)
+		}
+		defer f.Close()
+		w.Reset(f)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	sources := 0
+	err = server.NewAPIClient(cfg, logger).StreamExport(ctx, apiKey, *updatedAfter, func(page *types.CursorResponse[types.ExportSource]) error {
+		if err := nw.Write(page.Results); err != nil {
+			return err
+		}
+		sources += len(page.Results)
+		return w.Flush()
+	})
+	if err != nil {
+		return fail("failed to export highlights", err)
+	}
+	if f != nil {
+		if err := f.Close(); err != nil {
+			return fail("failed to write export", err)
+		}
+	}
+	logger.Info("exported highlights", "sources", sources)
+	return 0
+}
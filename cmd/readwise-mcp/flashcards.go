@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/server"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// runFlashcards implements the flashcards subcommand: it exports the
+// highlights of the configured API key as an Anki import file.
+func runFlashcards(cfg types.Config, args []string) int {
+	fs := flag.NewFlagSet("flashcards", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: readwise-mcp-server flashcards [flags]\n\nExport highlights as an Anki import file. The API key is read from READWISE_API_KEY or READWISE_API_KEY_FILE.\n\n")
+		fs.PrintDefaults()
+	}
+	sourceID := fs.Int64("source", 0, "only export highlights of this source ID")
+	tag := fs.String("tag", "", "only export highlights with this tag or from a source with this tag")
+	after := fs.String("after", "", "only export highlights made on or after this date (YYYY-MM-DD)")
+	before := fs.String("before", "", "only export highlights made before this date (YYYY-MM-DD)")
+	format := fs.String("format", export.FormatTSV, "file format: tsv or csv")
+	output := fs.String("o", "", "write to this file instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	logger := newLogger(cfg, os.Stderr)
+	fail := func(msg string, err error) int {
+		logger.Error(msg, "error", err)
+		return 1
+	}
+
+	filter := export.FlashcardFilter{SourceID: *sourceID, Tag: *tag}
+	var err error
+	if filter.After, err = export.ParseDate(*after); err != nil {
+		return fail("invalid -after", err)
+	}
+	if filter.Before, err = export.ParseDate(*before); err != nil {
+		return fail("invalid -before", err)
+	}
+	if *format != export.FormatTSV && *format != export.FormatCSV {
+		return fail("invalid -format", fmt.Errorf("format must be tsv or csv, got %q", *format))
+	}
+
+	apiKey, err := cfg.ResolveAPIKey()
+	if err != nil {
+		return fail("invalid API key configuration", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	result, err := server.NewAPIClient(cfg, logger).ExportHighlights(ctx, apiKey, "")
+	if err != nil {
+		return fail("failed to export highlights", err)
+	}
+	cards := export.Flashcards(result.Results, filter)
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if *output != "" {
+		if f, err = os.Create(*output); err != nil {
+			return fail("failed to create output file", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := export.WriteFlashcards(w, cards, *format); err != nil {
+		return fail("failed to write flashcards", err)
+	}
+	if f != nil {
+		if err := f.Close(); err != nil {
+			return fail("failed to write flashcards", err)
+		}
+	}
+	logger.Info("exported flashcards", "cards", len(cards))
+	return 0
+}
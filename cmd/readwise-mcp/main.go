@@ -2,35 +2,80 @@ package main
 
 import (
 	"context"
+	"flag"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/rhuss/readwise-mcp-server/internal/server"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
 	"github.com/rhuss/readwise-mcp-server/internal/types"
 )
 
+// commands are subcommands that run once instead of starting the server,
+// keyed by the first argument. Each returns the process exit code.
+var commands = map[string]func(cfg types.Config, args []string) int{
+	"export":     runExport,
+	"flashcards": runFlashcards,
+	"sync":       runSync,
+}
+
 func main() {
 	cfg := types.LoadConfig()
 
-	level := slog.LevelInfo
-	switch cfg.LogLevel {
-	case "debug":
-		level = slog.LevelDebug
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
+	if len(os.Args) > 1 {
+		if run, ok := commands[os.Args[1]]; ok {
+			os.Exit(run(cfg, os.Args[2:]))
+		}
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	flag.StringVar(&cfg.Transport, "transport", cfg.Transport, "transport mode: http or stdio (overrides TRANSPORT)")
+	flag.Parse()
+
+	// In stdio mode stdout carries the MCP protocol, so logs go to stderr.
+	logOutput := os.Stdout
+	if cfg.Transport == types.TransportStdio {
+		logOutput = os.Stderr
+	}
+	logger := newLogger(cfg, logOutput)
+
+	if err := cfg.ValidateTransport(); err != nil {
+		logger.Error("invalid transport configuration", "error", err)
+		os.Exit(1)
+	}
 
-	if err := cfg.ValidateTLS(); err != nil {
+	var apiKey string
+	if cfg.Transport == types.TransportStdio {
+		key, err := cfg.ResolveAPIKey()
+		if err != nil {
+			logger.Error("invalid API key configuration", "error", err)
+			os.Exit(1)
+		}
+		apiKey = key
+	} else if err := cfg.ValidateTLS(); err != nil {
 		logger.Error("invalid TLS configuration", "error", err)
 		os.Exit(1)
 	}
 
+	if cfg.TracingEnabled() {
+		shutdown, err := telemetry.Setup(context.Background(), cfg.OTLPEndpoint, server.Version, logger)
+		if err != nil {
+			logger.Error("failed to set up tracing", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdown(ctx); err != nil {
+				logger.Warn("failed to flush traces", "error", err)
+			}
+		}()
+		logger.Info("tracing enabled", "endpoint", cfg.OTLPEndpoint)
+	}
+
 	srv, err := server.New(cfg, logger)
 	if err != nil {
 		logger.Error("failed to create server", "error", err)
@@ -40,8 +85,27 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	if err := srv.ListenAndServe(ctx); err != nil {
+	if cfg.Transport == types.TransportStdio {
+		err = srv.ServeStdio(ctx, apiKey)
+	} else {
+		err = srv.ListenAndServe(ctx)
+	}
+	if err != nil {
 		logger.Error("server failed", "error", err)
 		os.Exit(1)
 	}
 }
+
+// newLogger creates a JSON logger writing to w at the configured level.
+func newLogger(cfg types.Config, w io.Writer) *slog.Logger {
+	level := slog.LevelInfo
+	switch cfg.LogLevel {
+	case "debug":
+		level = slog.LevelDebug
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	}
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
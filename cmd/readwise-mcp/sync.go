@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/server"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"github.com/rhuss/readwise-mcp-server/internal/vault"
+)
+
+// runSync implements the sync subcommand: it mirrors the highlights of the
+// configured API key into a directory of Markdown files.
+func runSync(cfg types.Config, args []string) int {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: readwise-mcp-server sync -dir <path> [flags]\n\nWrite one Markdown file per source into a directory such as an Obsidian vault. Later runs only fetch and rewrite what changed; text outside the generated block of each file is kept. The API key is read from READWISE_API_KEY or READWISE_API_KEY_FILE, the template from EXPORT_TEMPLATE_FILE.\n\n")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", "", "directory to write the Markdown files to (required)")
+	full := fs.Bool("full", false, "fetch the whole export instead of the changes since the last sync, e.g. to pick up deleted highlights")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *dir == "" {
+		fmt.Fprintln(fs.Output(), "missing required flag: -dir")
+		fs.Usage()
+		return 2
+	}
+
+	logger := newLogger(cfg, os.Stderr)
+	fail := func(msg string, err error) int {
+		logger.Error(msg, "error", err)
+		return 1
+	}
+
+	markdown, err := export.LoadMarkdown(cfg.ExportTemplateFile)
+	if err != nil {
+		return fail("invalid export template", err)
+	}
+	apiKey, err := cfg.ResolveAPIKey()
+	if err != nil {
+		return fail("invalid API key configuration", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	result, err := vault.Sync(ctx, server.NewAPIClient(cfg, logger), apiKey, *dir, markdown, vault.Options{Full: *full})
+	if err != nil {
+		return fail("failed to sync highlights", err)
+	}
+	for _, name := range result.Skipped {
+		logger.Warn("skipped file without sync markers", "file", name)
+	}
+	logger.Info("synced highlights",
+		"full", result.Full,
+		"created", len(result.Created),
+		"updated", len(result.Updated),
+		"unchanged", result.Unchanged,
+		"skipped", len(result.Skipped),
+	)
+	return 0
+}
@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"strconv"
 	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -23,15 +27,16 @@ type Client struct {
 	httpClient *http.Client
 	v2BaseURL  string
 	v3BaseURL  string
+	retry      RetryPolicy
+	limiter    *rateLimiter
+	observer   Observer
+	logger     *slog.Logger
+	sleep      func(ctx context.Context, d time.Duration) error
 }
 
 // NewClient creates a new API client with default configuration.
 func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{Timeout: defaultTimeout},
-		v2BaseURL:  ReadwiseV2BaseURL,
-		v3BaseURL:  ReaderV3BaseURL,
-	}
+	return NewClientWithBaseURLs(ReadwiseV2BaseURL, ReaderV3BaseURL)
 }
 
 // NewClientWithBaseURLs creates a client with custom base URLs (for testing).
@@ -40,105 +45,213 @@ func NewClientWithBaseURLs(v2, v3 string) *Client {
 		httpClient: &http.Client{Timeout: defaultTimeout},
 		v2BaseURL:  v2,
 		v3BaseURL:  v3,
+		retry:      DefaultRetryPolicy(),
+		limiter:    newRateLimiter(DefaultRateLimits()),
+		observer:   nopObserver{},
+		logger:     slog.New(slog.DiscardHandler),
+		sleep:      sleepContext,
 	}
 }
 
+// SetRetryPolicy replaces the client's retry policy.
+func (c *Client) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
+// SetRateLimits replaces the client's per-key request budgets. Existing
+// buckets are discarded.
+func (c *Client) SetRateLimits(limits RateLimits) {
+	c.limiter = newRateLimiter(limits)
+}
+
+// SetObserver sets the observer notified of upstream requests and rate
+// limiting, e.g. for metrics.
+func (c *Client) SetObserver(o Observer) {
+	c.observer = o
+}
+
+// SetLogger sets the logger used for retry and rate limiting output.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// Logger returns the client's logger.
+func (c *Client) Logger() *slog.Logger {
+	return c.logger
+}
+
 // doRequest executes an HTTP request with the given API key and returns the response body.
-func (c *Client) doRequest(ctx context.Context, method, url, apiKey string, body interface{}) ([]byte, error) {
-	var reqBody io.Reader
+// Every attempt first waits for the key's budget in the endpoint family.
+// Failed requests that are safe to re-send are retried according to the client's retry policy.
+func (c *Client) doRequest(ctx context.Context, family endpointFamily, method, path, apiKey string, body interface{}) ([]byte, error) {
+	var data []byte
 	if body != nil {
-		data, err := json.Marshal(body)
+		var err error
+		data, err = json.Marshal(body)
 		if err != nil {
 			return nil, NewInternalError(fmt.Sprintf("failed to marshal request body: %v", err))
 		}
+	}
+
+	url := c.v2BaseURL + path
+	if family == familyV3 {
+		url = c.v3BaseURL + path
+	}
+	endpoint := endpointLabel(path)
+
+	ctx, span := telemetry.Start(ctx, method+" "+endpoint,
+		attribute.String("http.request.method", method),
+		attribute.String("url.template", endpoint),
+		attribute.String("readwise.api", string(family)),
+	)
+	if page := pageFromContext(ctx); page > 0 {
+		span.SetAttributes(attribute.Int("readwise.page", page))
+	}
+	respBody, err := c.doAttempts(ctx, family, method, url, endpoint, apiKey, data)
+	telemetry.End(span, err)
+	return respBody, err
+}
+
+// doAttempts sends a request until it succeeds, fails permanently or runs
+// out of attempts, and records every attempt as an event on the current span.
+func (c *Client) doAttempts(ctx context.Context, family endpointFamily, method, url, endpoint, apiKey string, data []byte) ([]byte, error) {
+	span := trace.SpanFromContext(ctx)
+
+	attempts := 1
+	if retrySafe(ctx, method) && c.retry.MaxAttempts > 1 {
+		attempts = c.retry.MaxAttempts
+	}
+
+	budget := budgetFamily(family, method, endpoint)
+	for attempt := 1; ; attempt++ {
+		if err := c.waitForBudget(ctx, apiKey, budget); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		respBody, status, err := c.send(ctx, method, url, apiKey, data)
+		c.observer.ObserveRequest(string(family), method, endpoint, status, time.Since(start))
+		span.AddEvent("attempt", trace.WithAttributes(
+			attribute.Int("http.request.resend_count", attempt-1),
+			attribute.Int("http.response.status_code", status),
+		))
+		if status > 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+		}
+		if err == nil {
+			return respBody, nil
+		}
+		if attempt >= attempts || !retryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		wait := c.retry.backoff(attempt)
+		if apiErr := err.(*ErrorResponse); apiErr.Code == "rate_limited" && apiErr.RetryAfter > 0 {
+			wait = time.Duration(apiErr.RetryAfter) * time.Second
+			if wait > c.retry.MaxRetryAfter {
+				return nil, err
+			}
+		}
+
+		c.logger.Warn("retrying upstream request",
+			"method", method,
+			"attempt", attempt+1,
+			"max_attempts", attempts,
+			"wait_ms", wait.Milliseconds(),
+			"error", err.Error(),
+		)
+		if c.sleep(ctx, wait) != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single HTTP round trip and maps the response to a body or an error.
+// It also returns the response status code, or 0 when no response was received.
+func (c *Client) send(ctx context.Context, method, url, apiKey string, data []byte) ([]byte, int, error) {
+	var reqBody io.Reader
+	if data != nil {
 		reqBody = bytes.NewReader(data)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return nil, NewInternalError(fmt.Sprintf("failed to create request: %v", err))
+		return nil, 0, NewInternalError(fmt.Sprintf("failed to create request: %v", err))
 	}
 
 	req.Header.Set("Authorization", "Token "+apiKey)
-	if body != nil {
+	if data != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, NewAPIError("connection_error", fmt.Sprintf("failed to connect to API: %v", err))
+		return nil, 0, NewAPIError("connection_error", fmt.Sprintf("failed to connect to API: %v", err))
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, NewInternalError(fmt.Sprintf("failed to read response body: %v", err))
+		return nil, resp.StatusCode, NewInternalError(fmt.Sprintf("failed to read response body: %v", err))
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		retryAfter := 60
-		if v := resp.Header.Get("Retry-After"); v != "" {
-			if n, err := strconv.Atoi(v); err == nil {
-				retryAfter = n
-			}
-		}
-		return nil, NewRateLimitError(retryAfter)
+		return nil, resp.StatusCode, NewRateLimitError(parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-		return nil, NewAuthError("Invalid or expired API key")
+		return nil, resp.StatusCode, NewAuthError("Invalid or expired API key")
 	}
 
 	if resp.StatusCode == http.StatusNoContent {
-		return nil, nil
+		return nil, resp.StatusCode, nil
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, NewAPIError(
+		return nil, resp.StatusCode, NewAPIError(
 			fmt.Sprintf("http_%d", resp.StatusCode),
 			fmt.Sprintf("API returned status %d: %s", resp.StatusCode, string(respBody)),
 		)
 	}
 
-	return respBody, nil
+	return respBody, resp.StatusCode, nil
 }
 
 // GetV2 performs a GET request against the Readwise v2 API.
 func (c *Client) GetV2(ctx context.Context, path, apiKey string) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodGet, c.v2BaseURL+path, apiKey, nil)
+	return c.doRequest(ctx, familyV2, http.MethodGet, path, apiKey, nil)
 }
 
 // PostV2 performs a POST request against the Readwise v2 API.
 func (c *Client) PostV2(ctx context.Context, path, apiKey string, body interface{}) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodPost, c.v2BaseURL+path, apiKey, body)
+	return c.doRequest(ctx, familyV2, http.MethodPost, path, apiKey, body)
 }
 
 // PatchV2 performs a PATCH request against the Readwise v2 API.
 func (c *Client) PatchV2(ctx context.Context, path, apiKey string, body interface{}) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodPatch, c.v2BaseURL+path, apiKey, body)
+	return c.doRequest(ctx, familyV2, http.MethodPatch, path, apiKey, body)
 }
 
 // DeleteV2 performs a DELETE request against the Readwise v2 API.
 func (c *Client) DeleteV2(ctx context.Context, path, apiKey string) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodDelete, c.v2BaseURL+path, apiKey, nil)
+	return c.doRequest(ctx, familyV2, http.MethodDelete, path, apiKey, nil)
 }
 
 // GetV3 performs a GET request against the Reader v3 API.
 func (c *Client) GetV3(ctx context.Context, path, apiKey string) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodGet, c.v3BaseURL+path, apiKey, nil)
+	return c.doRequest(ctx, familyV3, http.MethodGet, path, apiKey, nil)
 }
 
 // PostV3 performs a POST request against the Reader v3 API.
 func (c *Client) PostV3(ctx context.Context, path, apiKey string, body interface{}) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodPost, c.v3BaseURL+path, apiKey, body)
+	return c.doRequest(ctx, familyV3, http.MethodPost, path, apiKey, body)
 }
 
 // PatchV3 performs a PATCH request against the Reader v3 API.
 func (c *Client) PatchV3(ctx context.Context, path, apiKey string, body interface{}) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodPatch, c.v3BaseURL+path, apiKey, body)
+	return c.doRequest(ctx, familyV3, http.MethodPatch, path, apiKey, body)
 }
 
 // DeleteV3 performs a DELETE request against the Reader v3 API.
 func (c *Client) DeleteV3(ctx context.Context, path, apiKey string) ([]byte, error) {
-	return c.doRequest(ctx, http.MethodDelete, c.v3BaseURL+path, apiKey, nil)
+	return c.doRequest(ctx, familyV3, http.MethodDelete, path, apiKey, nil)
 }
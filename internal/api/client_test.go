@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestClientAuthHeaderInjection(t *testing.T) {
@@ -35,6 +36,7 @@ func TestClientRateLimitHandling(t *testing.T) {
 	defer ts.Close()
 
 	client := NewClientWithBaseURLs(ts.URL, ts.URL)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
 	_, err := client.GetV2(context.Background(), "/test", "key")
 
 	if err == nil {
@@ -176,3 +178,64 @@ func TestClientContentTypeOnPost(t *testing.T) {
 		t.Errorf("Content-Type = %q, want %q", gotContentType, "application/json")
 	}
 }
+
+type recordingObserver struct {
+	endpoints []string
+	statuses  []int
+}
+
+func (o *recordingObserver) ObserveRequest(family, method, endpoint string, status int, duration time.Duration) {
+	o.endpoints = append(o.endpoints, family+" "+method+" "+endpoint)
+	o.statuses = append(o.statuses, status)
+}
+
+func (o *recordingObserver) ObserveRateLimitWait(family string, wait time.Duration) {}
+
+func TestClientObserverSeesEveryAttempt(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client := NewClientWithBaseURLs(ts.URL, ts.URL)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+	obs := &recordingObserver{}
+	client.SetObserver(obs)
+
+	if _, err := client.GetV3(context.Background(), "/list/?id=01gwfvp9pyaabcdgmx14f6ha0a", "key"); err != nil {
+		t.Fatalf("GetV3 error: %v", err)
+	}
+
+	if len(obs.statuses) != 2 || obs.statuses[0] != 503 || obs.statuses[1] != 200 {
+		t.Errorf("statuses = %v, want [503 200]", obs.statuses)
+	}
+	if obs.endpoints[0] != "v3 GET /list/" {
+		t.Errorf("endpoint = %q, want %q", obs.endpoints[0], "v3 GET /list/")
+	}
+}
+
+func TestEndpointLabel(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/books/?page=2&page_size=100", "/books/"},
+		{"/books/123/", "/books/:id/"},
+		{"/highlights/42/tags/7", "/highlights/:id/tags/:id"},
+		{"/export/?pageCursor=abc", "/export/"},
+		{"/update/01gwfvp9pyaabcdgmx14f6ha0a/", "/update/:id/"},
+		{"/review/", "/review/"},
+	}
+
+	for _, tt := range tests {
+		if got := endpointLabel(tt.path); got != tt.want {
+			t.Errorf("endpointLabel(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -50,11 +50,16 @@ func NewAPIError(code, message string) *ErrorResponse {
 }
 
 // NewRateLimitError creates a rate limit error with retry information.
+// A retryAfter of 0 means the upstream API did not say when to retry.
 func NewRateLimitError(retryAfter int) *ErrorResponse {
+	message := "Rate limited by upstream API."
+	if retryAfter > 0 {
+		message = fmt.Sprintf("Rate limited by upstream API. Retry after %d seconds.", retryAfter)
+	}
 	return &ErrorResponse{
 		Type:       "api_error",
 		Code:       "rate_limited",
-		Message:    fmt.Sprintf("Rate limited by upstream API. Retry after %d seconds.", retryAfter),
+		Message:    message,
 		RetryAfter: retryAfter,
 	}
 }
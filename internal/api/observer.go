@@ -0,0 +1,39 @@
+package api
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Observer receives instrumentation events from the client. Implementations
+// must be safe for concurrent use. Events never carry API keys.
+type Observer interface {
+	// ObserveRequest is called after every upstream round trip, including
+	// retries. Endpoint is the request path with IDs and query stripped, and
+	// status is the HTTP status code, or 0 when no response was received.
+	ObserveRequest(family, method, endpoint string, status int, duration time.Duration)
+	// ObserveRateLimitWait is called when a request waited for local budget.
+	ObserveRateLimitWait(family string, wait time.Duration)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) ObserveRequest(string, string, string, int, time.Duration) {}
+func (nopObserver) ObserveRateLimitWait(string, time.Duration)                {}
+
+// endpointLabel reduces a request path to a low-cardinality label: the query
+// string is dropped and path segments containing digits, such as highlight
+// and document IDs, are replaced with ":id".
+func endpointLabel(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.IndexFunc(seg, unicode.IsDigit) >= 0 {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
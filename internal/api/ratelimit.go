@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// endpointFamily identifies an upstream API with its own request budget.
+type endpointFamily string
+
+const (
+	familyV2 endpointFamily = "v2"
+	familyV3 endpointFamily = "v3"
+	// familyV2List covers the v2 book and highlight list endpoints, which
+	// Readwise limits more tightly than the rest of v2. It only selects a
+	// request budget; the request itself goes to the v2 API.
+	familyV2List endpointFamily = "v2_list"
+)
+
+// v2ListEndpoints lists the v2 endpoints whose GET requests use the
+// familyV2List budget.
+var v2ListEndpoints = map[string]bool{
+	"/books/":      true,
+	"/highlights/": true,
+}
+
+// budgetFamily returns the family whose budget a request draws from.
+func budgetFamily(family endpointFamily, method, endpoint string) endpointFamily {
+	if family == familyV2 && method == http.MethodGet && v2ListEndpoints[endpoint] {
+		return familyV2List
+	}
+	return family
+}
+
+// limiterIdleTTL is how long an unused per-key limiter is kept before it is
+// dropped. A dropped limiter is recreated with a full bucket.
+const limiterIdleTTL = 10 * time.Minute
+
+// RateLimits sets the outbound request budgets per API key. A zero value
+// disables limiting for that endpoint family.
+type RateLimits struct {
+	V2PerMinute int
+	// V2ListPerMinute applies to listing books and highlights instead of
+	// V2PerMinute.
+	V2ListPerMinute int
+	V3PerMinute     int
+}
+
+// DefaultRateLimits returns the budgets used by new clients, matching the
+// documented Readwise and Reader API limits.
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		V2PerMinute:     240,
+		V2ListPerMinute: 20,
+		V3PerMinute:     20,
+	}
+}
+
+// rateLimiter holds a token bucket per API key hash and endpoint family, so
+// requests queue locally instead of exhausting the upstream budget.
+type rateLimiter struct {
+	limits RateLimits
+
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	lastScan time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	return &rateLimiter{
+		limits:  limits,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// reserve takes a token from the bucket for the given key and family and
+// returns the reservation, or nil when the family is not limited.
+func (l *rateLimiter) reserve(apiKey string, family endpointFamily) *rate.Reservation {
+	var perMinute int
+	switch family {
+	case familyV2:
+		perMinute = l.limits.V2PerMinute
+	case familyV2List:
+		perMinute = l.limits.V2ListPerMinute
+	case familyV3:
+		perMinute = l.limits.V3PerMinute
+	}
+	if perMinute <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	key := auth.HashAPIKey(apiKey) + "|" + string(family)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastScan) > limiterIdleTTL {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastUsed) > limiterIdleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastScan = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		// Set a quarter of the budget aside for bursts, so short fan-outs run
+		// unthrottled, and refill the rest over the minute. A full bucket plus
+		// a minute of refill then stays within the budget.
+		burst := max(1, perMinute/4)
+		refill := max(1, perMinute-burst)
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(float64(refill)/60), burst)}
+		l.buckets[key] = b
+	}
+	b.lastUsed = now
+	return b.limiter.ReserveN(now, 1)
+}
+
+// waitForBudget blocks until the rate limiter admits a request for the key
+// and family, logging the time spent waiting.
+func (c *Client) waitForBudget(ctx context.Context, apiKey string, family endpointFamily) error {
+	r := c.limiter.reserve(apiKey, family)
+	if r == nil {
+		return nil
+	}
+
+	wait := r.Delay()
+	if wait <= 0 {
+		return nil
+	}
+
+	c.observer.ObserveRateLimitWait(string(family), wait)
+	trace.SpanFromContext(ctx).AddEvent("rate limit wait", trace.WithAttributes(
+		attribute.Int64("readwise.wait_ms", wait.Milliseconds()),
+	))
+	c.logger.Info("waiting for upstream rate limit budget",
+		"family", string(family),
+		"wait_ms", wait.Milliseconds(),
+	)
+	if err := c.sleep(ctx, wait); err != nil {
+		r.Cancel()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRateLimitTestClient(limits RateLimits) (*Client, *httptest.Server, *[]time.Duration) {
+	client, ts, waits := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	client.SetRateLimits(limits)
+	return client, ts, waits
+}
+
+func TestRateLimitQueuesBeyondBurst(t *testing.T) {
+	client, ts, waits := newRateLimitTestClient(RateLimits{V2PerMinute: 60, V3PerMinute: 60})
+	defer ts.Close()
+
+	// A budget of 60/min allows a burst of 15 requests and refills 45/min.
+	for i := 0; i < 16; i++ {
+		if _, err := client.GetV2(context.Background(), "/test", "key"); err != nil {
+			t.Fatalf("GetV2 error: %v", err)
+		}
+	}
+
+	if len(*waits) != 1 {
+		t.Fatalf("waits = %v, want one wait after the burst", *waits)
+	}
+	if w := (*waits)[0]; w < time.Second || w > 1400*time.Millisecond {
+		t.Errorf("wait = %v, want about 1.33s", w)
+	}
+}
+
+func TestRateLimitFirstMinuteWithinBudget(t *testing.T) {
+	client, ts, waits := newRateLimitTestClient(RateLimits{V3PerMinute: 20})
+	defer ts.Close()
+
+	// The 21st request must not be admitted within the first minute.
+	for i := 0; i < 21; i++ {
+		client.GetV3(context.Background(), "/test", "key")
+	}
+	if len(*waits) == 0 || (*waits)[len(*waits)-1] < time.Minute {
+		t.Errorf("waits = %v, want the 21st request to wait at least a minute", *waits)
+	}
+}
+
+func TestRateLimitV2ListBudget(t *testing.T) {
+	client, ts, waits := newRateLimitTestClient(RateLimits{V2PerMinute: 240, V2ListPerMinute: 4})
+	defer ts.Close()
+
+	ctx := context.Background()
+	client.GetV2(ctx, "/highlights/?page=1", "key")
+	client.GetV2(ctx, "/books/?page=1", "key")
+	if len(*waits) != 1 {
+		t.Fatalf("waits = %v, want a wait once the list budget is spent", *waits)
+	}
+
+	client.GetV2(ctx, "/highlights/123/", "key")
+	client.GetV2(ctx, "/export/", "key")
+	client.PostV2(ctx, "/highlights/", "key", nil)
+	if len(*waits) != 1 {
+		t.Errorf("waits = %v, want other v2 requests to use the v2 budget", *waits)
+	}
+}
+
+func TestRateLimitPerKeyAndFamily(t *testing.T) {
+	client, ts, waits := newRateLimitTestClient(RateLimits{V2PerMinute: 4, V3PerMinute: 4})
+	defer ts.Close()
+
+	ctx := context.Background()
+	client.GetV2(ctx, "/test", "key-a")
+	client.GetV3(ctx, "/test", "key-a")
+	client.GetV2(ctx, "/test", "key-b")
+	if len(*waits) != 0 {
+		t.Fatalf("waits = %v, want no waits across keys and families", *waits)
+	}
+
+	client.GetV2(ctx, "/test", "key-a")
+	if len(*waits) != 1 {
+		t.Errorf("waits = %v, want a wait once key-a's v2 budget is spent", *waits)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	client, ts, waits := newRateLimitTestClient(RateLimits{})
+	defer ts.Close()
+
+	for i := 0; i < 50; i++ {
+		client.GetV3(context.Background(), "/test", "key")
+	}
+	if len(*waits) != 0 {
+		t.Errorf("waits = %v, want none with limiting disabled", *waits)
+	}
+}
+
+func TestRateLimitAppliesToRetries(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, waits := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	defer ts.Close()
+	client.SetRateLimits(RateLimits{V2PerMinute: 1})
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+
+	if _, err := client.GetV2(context.Background(), "/test", "key"); err != nil {
+		t.Fatalf("GetV2 error: %v", err)
+	}
+	// One zero backoff, then a wait for the next token before the retry.
+	if len(*waits) != 2 || (*waits)[1] < 50*time.Second {
+		t.Errorf("waits = %v, want the retry to wait for budget", *waits)
+	}
+}
+
+func TestRateLimitCanceledWait(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	client := NewClientWithBaseURLs(ts.URL, ts.URL)
+	client.SetRateLimits(RateLimits{V3PerMinute: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client.GetV3(ctx, "/test", "key")
+	if _, err := client.GetV3(ctx, "/test", "key"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
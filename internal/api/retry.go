@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how the client retries failed upstream requests.
+// GET requests are always eligible for retries; writes only when they are
+// marked idempotent.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles with every
+	// further attempt and is randomized by up to half its value.
+	BaseDelay time.Duration
+	// MaxDelay caps a single backoff.
+	MaxDelay time.Duration
+	// MaxRetryAfter is the longest Retry-After a 429 is waited out for; a
+	// longer one is returned to the caller immediately. A 429 without the
+	// header is retried with the regular backoff.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by new clients.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:   3,
+		BaseDelay:     500 * time.Millisecond,
+		MaxDelay:      10 * time.Second,
+		MaxRetryAfter: 30 * time.Second,
+	}
+}
+
+// backoff returns the jittered delay before the given retry (1-based).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter returns the seconds to wait according to a Retry-After
+// header in either delay-seconds or HTTP-date form, or 0 when the header is
+// missing or invalid.
+func parseRetryAfter(v string, now time.Time) int {
+	if v == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return max(n, 0)
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0
+	}
+	return max(int(math.Ceil(t.Sub(now).Seconds())), 0)
+}
+
+// retryable reports whether a failed request may succeed when sent again.
+func retryable(err error) bool {
+	apiErr, ok := err.(*ErrorResponse)
+	if !ok {
+		return false
+	}
+	switch apiErr.Code {
+	case "connection_error", "rate_limited", "http_500", "http_502", "http_503", "http_504":
+		return true
+	}
+	return false
+}
+
+type idempotentKey struct{}
+
+// idempotent marks a write request as safe to retry. Only use it for requests
+// whose repeated application has the same effect as a single one, such as a
+// PATCH that sets absolute field values.
+func idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// retrySafe reports whether a request with the given method may be re-sent.
+func retrySafe(ctx context.Context, method string) bool {
+	if method == http.MethodGet {
+		return true
+	}
+	v, _ := ctx.Value(idempotentKey{}).(bool)
+	return v
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
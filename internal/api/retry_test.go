@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// newRetryTestClient returns a client whose backoff sleeps are recorded instead of waited out.
+func newRetryTestClient(handler http.HandlerFunc) (*Client, *httptest.Server, *[]time.Duration) {
+	ts := httptest.NewServer(handler)
+	client := NewClientWithBaseURLs(ts.URL, ts.URL)
+	var waits []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	return client, ts, &waits
+}
+
+func TestRetryOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, waits := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	defer ts.Close()
+
+	if _, err := client.GetV2(context.Background(), "/test", "key"); err != nil {
+		t.Fatalf("GetV2 error: %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+	if len(*waits) != 2 {
+		t.Fatalf("backoff waits = %v, want 2", *waits)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, _ := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer ts.Close()
+
+	_, err := client.GetV2(context.Background(), "/test", "key")
+	if err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if apiErr := err.(*ErrorResponse); apiErr.Code != "http_502" {
+		t.Errorf("Code = %q, want %q", apiErr.Code, "http_502")
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, waits := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	defer ts.Close()
+
+	if _, err := client.GetV2(context.Background(), "/test", "key"); err != nil {
+		t.Fatalf("GetV2 error: %v", err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 7*time.Second {
+		t.Errorf("waits = %v, want [7s]", *waits)
+	}
+}
+
+func TestRetryAfterAboveCeilingFailsFast(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, waits := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	defer ts.Close()
+
+	_, err := client.GetV2(context.Background(), "/test", "key")
+	apiErr, ok := err.(*ErrorResponse)
+	if !ok || apiErr.Code != "rate_limited" {
+		t.Fatalf("error = %v, want rate_limited", err)
+	}
+	if apiErr.RetryAfter != 120 {
+		t.Errorf("RetryAfter = %d, want 120", apiErr.RetryAfter)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+	if len(*waits) != 0 {
+		t.Errorf("waits = %v, want none", *waits)
+	}
+}
+
+func TestRetryRateLimitWithoutRetryAfterUsesBackoff(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, waits := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	defer ts.Close()
+
+	if _, err := client.GetV2(context.Background(), "/test", "key"); err != nil {
+		t.Fatalf("GetV2 error: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want 2", n)
+	}
+	policy := DefaultRetryPolicy()
+	if len(*waits) != 1 || (*waits)[0] > policy.BaseDelay {
+		t.Errorf("waits = %v, want a single backoff of at most %v", *waits, policy.BaseDelay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", 0},
+		{"7", 7},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRetryNotAppliedToClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, _ := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	defer ts.Close()
+
+	client.GetV2(context.Background(), "/test", "key")
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
+
+func TestRetryNotAppliedToUnsafeWrites(t *testing.T) {
+	var calls atomic.Int32
+	client, ts, _ := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer ts.Close()
+
+	client.CreateHighlight(context.Background(), "key", types.CreateHighlightsRequest{
+		Highlights: []types.CreateHighlightRequest{{Text: "text"}},
+	})
+	client.DeleteHighlight(context.Background(), "key", "1")
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("calls = %d, want 2 (one per write, no retries)", n)
+	}
+}
+
+func TestRetryAppliedToIdempotentWrites(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	client, ts, _ := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		var req types.UpdateHighlightRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		bodies = append(bodies, req.Note)
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(types.Highlight{ID: 1, Note: req.Note})
+	})
+	defer ts.Close()
+
+	result, err := client.UpdateHighlight(context.Background(), "key", "1", types.UpdateHighlightRequest{Note: "retried"})
+	if err != nil {
+		t.Fatalf("UpdateHighlight error: %v", err)
+	}
+	if result.Note != "retried" {
+		t.Errorf("Note = %q, want %q", result.Note, "retried")
+	}
+	if len(bodies) != 2 || bodies[1] != "retried" {
+		t.Errorf("request bodies = %q, want body re-sent on retry", bodies)
+	}
+}
+
+func TestRetryKeepsFetchedExportPages(t *testing.T) {
+	var page2Calls atomic.Int32
+	client, ts, _ := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pageCursor") == "" {
+			json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+				Count:          1,
+				NextPageCursor: "page2",
+				Results:        []types.ExportSource{{UserBookID: 1}},
+			})
+			return
+		}
+		if page2Calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+			Count:   1,
+			Results: []types.ExportSource{{UserBookID: 2}},
+		})
+	})
+	defer ts.Close()
+
+	result, err := client.ExportHighlights(context.Background(), "key", "")
+	if err != nil {
+		t.Fatalf("ExportHighlights error: %v", err)
+	}
+	if len(result.Results) != 2 {
+		t.Errorf("len(Results) = %d, want 2", len(result.Results))
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	var calls atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := NewClientWithBaseURLs(ts.URL, ts.URL)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.GetV2(ctx, "/test", "key"); err == nil {
+		t.Fatal("expected error")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("retry backoff did not stop on context cancellation")
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1", n)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tests := []struct {
+		retry    int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 150 * time.Millisecond, 300 * time.Millisecond},
+		{10, 150 * time.Millisecond, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			d := p.backoff(tt.retry)
+			if d < tt.min || d > tt.max {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", tt.retry, d, tt.min, tt.max)
+			}
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package api
+
+import "context"
+
+type pageKey struct{}
+
+// withPage records the 1-based page number of a paginated request, so its
+// span can be told apart from the other pages of the same listing.
+func withPage(ctx context.Context, page int) context.Context {
+	return context.WithValue(ctx, pageKey{}, page)
+}
+
+// pageFromContext returns the page number set by withPage, or 0.
+func pageFromContext(ctx context.Context) int {
+	page, _ := ctx.Value(pageKey{}).(int)
+	return page
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTracingSpanPerExportPage(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := types.CursorResponse[types.ExportSource]{Results: []types.ExportSource{{UserBookID: 1}}}
+		if r.URL.Query().Get("pageCursor") == "" {
+			resp.NextPageCursor = "next"
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer ts.Close()
+
+	client := NewClientWithBaseURLs(ts.URL, ts.URL)
+	if _, err := client.ExportHighlights(context.Background(), "key", ""); err != nil {
+		t.Fatalf("ExportHighlights error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	for i, span := range spans {
+		if span.Name() != "GET /export/" {
+			t.Errorf("span name = %q, want %q", span.Name(), "GET /export/")
+		}
+		page, ok := spanAttr(span, "readwise.page")
+		if !ok || page.AsInt64() != int64(i+1) {
+			t.Errorf("span %d readwise.page = %v, want %d", i, page.AsInt64(), i+1)
+		}
+		if status, _ := spanAttr(span, "http.response.status_code"); status.AsInt64() != 200 {
+			t.Errorf("span %d status = %d, want 200", i, status.AsInt64())
+		}
+	}
+}
+
+func TestTracingRecordsRetriesAndErrors(t *testing.T) {
+	recorder := recordSpans(t)
+
+	client, ts, _ := newRetryTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	defer ts.Close()
+
+	client.GetV3(context.Background(), "/list/", "key")
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	attempts := 0
+	for _, ev := range spans[0].Events() {
+		if ev.Name == "attempt" {
+			attempts++
+		}
+	}
+	if attempts != 3 {
+		t.Errorf("attempt events = %d, want 3", attempts)
+	}
+	if spans[0].Status().Description == "" {
+		t.Error("expected an error status on the span")
+	}
+}
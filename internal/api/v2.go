@@ -14,6 +14,7 @@ func (c *Client) ListBooks(ctx context.Context, apiKey string, page, pageSize in
 	params := url.Values{}
 	if page > 0 {
 		params.Set("page", fmt.Sprintf("%d", page))
+		ctx = withPage(ctx, page)
 	}
 	if pageSize > 0 {
 		params.Set("page_size", fmt.Sprintf("%d", pageSize))
@@ -61,6 +62,7 @@ func (c *Client) ListHighlights(ctx context.Context, apiKey string, page, pageSi
 	params := url.Values{}
 	if page > 0 {
 		params.Set("page", fmt.Sprintf("%d", page))
+		ctx = withPage(ctx, page)
 	}
 	if pageSize > 0 {
 		params.Set("page_size", fmt.Sprintf("%d", pageSize))
@@ -104,13 +106,29 @@ func (c *Client) GetHighlight(ctx context.Context, apiKey string, id string) (*t
 }
 
 // ExportHighlights exports all highlights with cursor-based pagination.
-// It loops through all pages and returns the complete result.
+// It loops through all pages and returns the complete result. Each page is
+// retried on its own, so a transient failure does not discard earlier pages.
 func (c *Client) ExportHighlights(ctx context.Context, apiKey string, updatedAfter string) (*types.CursorResponse[types.ExportSource], error) {
-	var allResults []types.ExportSource
-	totalCount := 0
+	result := &types.CursorResponse[types.ExportSource]{}
+	err := c.StreamExport(ctx, apiKey, updatedAfter, func(page *types.CursorResponse[types.ExportSource]) error {
+		result.Results = append(result.Results, page.Results...)
+		result.Count += page.Count
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StreamExport walks the highlight export page by page, calling fn with each
+// page as it arrives. Pages are not kept after fn returns, so memory use is
+// bounded by the page size rather than the library size. An error returned by
+// fn stops the export and is returned as is.
+func (c *Client) StreamExport(ctx context.Context, apiKey string, updatedAfter string, fn func(page *types.CursorResponse[types.ExportSource]) error) error {
 	cursor := ""
 
-	for {
+	for pageNum := 1; ; pageNum++ {
 		params := url.Values{}
 		if updatedAfter != "" {
 			params.Set("updatedAfter", updatedAfter)
@@ -124,29 +142,25 @@ func (c *Client) ExportHighlights(ctx context.Context, apiKey string, updatedAft
 			path += "?" + params.Encode()
 		}
 
-		body, err := c.GetV2(ctx, path, apiKey)
+		body, err := c.GetV2(withPage(ctx, pageNum), path, apiKey)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		var page types.CursorResponse[types.ExportSource]
 		if err := json.Unmarshal(body, &page); err != nil {
-			return nil, NewInternalError(fmt.Sprintf("failed to parse export response: %v", err))
+			return NewInternalError(fmt.Sprintf("failed to parse export response: %v", err))
 		}
 
-		allResults = append(allResults, page.Results...)
-		totalCount += page.Count
+		if err := fn(&page); err != nil {
+			return err
+		}
 
 		if page.NextPageCursor == "" {
-			break
+			return nil
 		}
 		cursor = page.NextPageCursor
 	}
-
-	return &types.CursorResponse[types.ExportSource]{
-		Count:   totalCount,
-		Results: allResults,
-	}, nil
 }
 
 // GetDailyReview returns today's daily review highlights.
@@ -206,8 +220,9 @@ func (c *Client) CreateHighlight(ctx context.Context, apiKey string, req types.C
 }
 
 // UpdateHighlight updates an existing highlight via PATCH.
+// The PATCH sets absolute field values, so it is retried like a GET.
 func (c *Client) UpdateHighlight(ctx context.Context, apiKey string, id string, req types.UpdateHighlightRequest) (*types.Highlight, error) {
-	body, err := c.PatchV2(ctx, fmt.Sprintf("/highlights/%s/", id), apiKey, req)
+	body, err := c.PatchV2(idempotent(ctx), fmt.Sprintf("/highlights/%s/", id), apiKey, req)
 	if err != nil {
 		return nil, err
 	}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -200,6 +201,38 @@ func TestExportHighlightsWithUpdatedAfter(t *testing.T) {
 	}
 }
 
+func TestStreamExportStopsOnCallbackError(t *testing.T) {
+	callCount := 0
+	client, ts := newTestV2Server(func(w http.ResponseWriter, r *http.Request) {
+		callCount++
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+			Count:          1,
+			NextPageCursor: "next",
+			Results:        []types.ExportSource{{UserBookID: int64(callCount)}},
+		})
+	})
+	defer ts.Close()
+
+	stop := errors.New("stop")
+	var seen []int64
+	err := client.StreamExport(context.Background(), "key", "", func(page *types.CursorResponse[types.ExportSource]) error {
+		seen = append(seen, page.Results[0].UserBookID)
+		if len(seen) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("StreamExport error = %v, want callback error", err)
+	}
+	if len(seen) != 2 || seen[0] != 1 || seen[1] != 2 {
+		t.Errorf("pages seen = %v, want [1 2]", seen)
+	}
+	if callCount != 2 {
+		t.Errorf("API call count = %d, want 2", callCount)
+	}
+}
+
 func TestGetDailyReview(t *testing.T) {
 	client, ts := newTestV2Server(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/review/" {
@@ -10,12 +10,23 @@ import (
 )
 
 // ListDocuments returns documents from the Reader v3 API with cursor-based pagination.
-// It paginates through all pages up to the specified limit.
+// It paginates through all pages up to the specified limit. Each page is
+// retried on its own, so a transient failure does not discard earlier pages.
 func (c *Client) ListDocuments(ctx context.Context, apiKey string, location, category, updatedAfter string, limit int) (*types.CursorResponse[types.Document], error) {
+	return c.listDocuments(ctx, apiKey, location, category, updatedAfter, limit, false)
+}
+
+// ListDocumentContent returns all documents updated after updatedAfter (all
+// documents if empty) including their HTML content.
+func (c *Client) ListDocumentContent(ctx context.Context, apiKey string, updatedAfter string) (*types.CursorResponse[types.Document], error) {
+	return c.listDocuments(ctx, apiKey, "", "", updatedAfter, 0, true)
+}
+
+func (c *Client) listDocuments(ctx context.Context, apiKey string, location, category, updatedAfter string, limit int, withContent bool) (*types.CursorResponse[types.Document], error) {
 	var allResults []types.Document
 	cursor := ""
 
-	for {
+	for pageNum := 1; ; pageNum++ {
 		params := url.Values{}
 		if location != "" {
 			params.Set("location", location)
@@ -26,6 +37,9 @@ func (c *Client) ListDocuments(ctx context.Context, apiKey string, location, cat
 		if updatedAfter != "" {
 			params.Set("updatedAfter", updatedAfter)
 		}
+		if withContent {
+			params.Set("withHtmlContent", "true")
+		}
 		if cursor != "" {
 			params.Set("pageCursor", cursor)
 		}
@@ -41,7 +55,7 @@ func (c *Client) ListDocuments(ctx context.Context, apiKey string, location, cat
 			path += "?" + params.Encode()
 		}
 
-		body, err := c.GetV3(ctx, path, apiKey)
+		body, err := c.GetV3(withPage(ctx, pageNum), path, apiKey)
 		if err != nil {
 			return nil, err
 		}
@@ -125,8 +139,9 @@ func (c *Client) SaveDocument(ctx context.Context, apiKey string, req types.Save
 }
 
 // UpdateDocument updates document metadata in Reader.
+// The PATCH sets absolute field values, so it is retried like a GET.
 func (c *Client) UpdateDocument(ctx context.Context, apiKey string, id string, req types.UpdateDocumentRequest) (*types.Document, error) {
-	body, err := c.PatchV3(ctx, fmt.Sprintf("/update/%s/", id), apiKey, req)
+	body, err := c.PatchV3(idempotent(ctx), fmt.Sprintf("/update/%s/", id), apiKey, req)
 	if err != nil {
 		return nil, err
 	}
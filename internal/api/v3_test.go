@@ -64,6 +64,31 @@ func TestListDocumentsWithFilters(t *testing.T) {
 	}
 }
 
+func TestListDocumentContent(t *testing.T) {
+	client, ts := newTestV3Server(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("withHtmlContent") != "true" {
+			t.Errorf("withHtmlContent = %q, want true", q.Get("withHtmlContent"))
+		}
+		if q.Get("updatedAfter") != "2024-01-01T00:00:00Z" {
+			t.Errorf("updatedAfter = %q, want 2024-01-01T00:00:00Z", q.Get("updatedAfter"))
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{
+			Count:   1,
+			Results: []types.Document{{ID: "doc1", Content: "<p>Body</p>"}},
+		})
+	})
+	defer ts.Close()
+
+	result, err := client.ListDocumentContent(context.Background(), "key", "2024-01-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("ListDocumentContent error: %v", err)
+	}
+	if len(result.Results) != 1 || result.Results[0].Content != "<p>Body</p>" {
+		t.Errorf("unexpected result: %+v", result.Results)
+	}
+}
+
 func TestListDocumentsPagination(t *testing.T) {
 	callCount := 0
 	client, ts := newTestV3Server(func(w http.ResponseWriter, r *http.Request) {
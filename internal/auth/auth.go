@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -31,12 +33,56 @@ func WithAPIKey(ctx context.Context, key string) context.Context {
 // APIKeyFromRequest extracts the Readwise API key from an MCP CallToolRequest.
 // The SDK populates req.Extra.Header with the HTTP request headers.
 func APIKeyFromRequest(req *mcp.CallToolRequest) string {
-	if req.Extra != nil && req.Extra.Header != nil {
-		return ExtractAPIKeyFromHeader(req.Extra.Header)
+	return APIKeyFromExtra(req.Extra)
+}
+
+// APIKeyFromExtra extracts the Readwise API key from the HTTP headers of any
+// MCP request, such as resources/read or prompts/get.
+func APIKeyFromExtra(extra *mcp.RequestExtra) string {
+	if extra != nil && extra.Header != nil {
+		return ExtractAPIKeyFromHeader(extra.Header)
 	}
 	return ""
 }
 
+// StaticKeyMiddleware returns MCP middleware that authenticates every tool
+// call, resource and prompt request with a fixed API key. It is used for
+// transports without HTTP headers, such as stdio, where the key comes from the
+// server's configuration.
+func StaticKeyMiddleware(apiKey string) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			switch r := req.(type) {
+			case *mcp.CallToolRequest:
+				r.Extra = withAuthorization(r.Extra, apiKey)
+			case *mcp.ReadResourceRequest:
+				r.Extra = withAuthorization(r.Extra, apiKey)
+			case *mcp.ListResourcesRequest:
+				r.Extra = withAuthorization(r.Extra, apiKey)
+			case *mcp.GetPromptRequest:
+				r.Extra = withAuthorization(r.Extra, apiKey)
+			}
+			return next(ctx, method, req)
+		}
+	}
+}
+
+// withAuthorization returns a copy of extra whose headers carry apiKey as a
+// Token Authorization header.
+func withAuthorization(extra *mcp.RequestExtra, apiKey string) *mcp.RequestExtra {
+	e := &mcp.RequestExtra{}
+	if extra != nil {
+		*e = *extra
+	}
+	if e.Header != nil {
+		e.Header = e.Header.Clone()
+	} else {
+		e.Header = http.Header{}
+	}
+	e.Header.Set("Authorization", "Token "+apiKey)
+	return e
+}
+
 // Middleware extracts the Readwise API key from the Authorization header
 // and injects it into the request context. Used for non-MCP HTTP endpoints.
 func Middleware(next http.Handler) http.Handler {
@@ -70,3 +116,10 @@ func ExtractAPIKeyFromHeader(h http.Header) string {
 
 	return ""
 }
+
+// HashAPIKey returns the SHA-256 hash of an API key, which identifies a user
+// in cache keys and rate limiter buckets without revealing the key.
+func HashAPIKey(apiKey string) string {
+	h := sha256.Sum256([]byte(apiKey))
+	return fmt.Sprintf("%x", h)
+}
@@ -0,0 +1,19 @@
+package auth
+
+import "testing"
+
+func TestHashAPIKey(t *testing.T) {
+	hash1 := HashAPIKey("key1")
+	hash2 := HashAPIKey("key2")
+	hash1again := HashAPIKey("key1")
+
+	if hash1 == hash2 {
+		t.Error("different keys should produce different hashes")
+	}
+	if hash1 != hash1again {
+		t.Error("same key should produce same hash")
+	}
+	if len(hash1) != 64 {
+		t.Errorf("SHA-256 hash should be 64 hex chars, got %d", len(hash1))
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contentEndpoint namespaces document content snapshots in the LRU, apart
+// from cached document listings so that invalidating those keeps the content.
+const contentEndpoint = "content:" + EndpointDocuments
+
+// ContentSnapshot holds the plain text of a user's Reader documents, keyed
+// by document ID, as of the last sync.
+type ContentSnapshot struct {
+	SyncedAt  time.Time         `json:"synced_at"`
+	Documents map[string]string `json:"documents"`
+}
+
+// SyncDocumentContent returns the plain text of the user's Reader documents
+// keyed by document ID. It works like SyncExport: the first call stores the
+// content of all documents, later calls are served from the snapshot until it
+// is older than the document list TTL or marked stale by a document write,
+// after which only documents updated since the last sync are fetched via
+// updatedAfter and merged in. fetch returns the text of the documents updated
+// after its argument, or of all documents if it is empty. Texts of deleted
+// documents stay in the snapshot; callers match them against the current
+// document list.
+func (m *Manager) SyncDocumentContent(ctx context.Context, apiKey string, fetch func(ctx context.Context, updatedAfter string) (map[string]string, error)) (_ map[string]string, err error) {
+	ctx, span := telemetry.Start(ctx, "cache.sync_content")
+	defer func() { telemetry.End(span, err) }()
+
+	key := buildKey(auth.HashAPIKey(apiKey), contentEndpoint, nil)
+	v, err := m.coalesce(ctx, key, EndpointDocuments, func(ctx context.Context) (any, error) {
+		if !m.enabled {
+			return fetch(ctx, "")
+		}
+		return m.syncContent(ctx, key, fetch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(map[string]string), nil
+}
+
+// syncContent performs a snapshot sync for SyncDocumentContent.
+func (m *Manager) syncContent(ctx context.Context, key string, fetch func(ctx context.Context, updatedAfter string) (map[string]string, error)) (map[string]string, error) {
+	var snap ContentSnapshot
+	hasSnapshot := false
+	if entry := m.cache.Get(key); entry != nil {
+		hasSnapshot = json.Unmarshal(entry.Data, &snap) == nil
+	}
+	stale := m.takeStale(key)
+
+	span := trace.SpanFromContext(ctx)
+	if hasSnapshot && !stale && time.Since(snap.SyncedAt) < defaultTTLs[EndpointDocuments] {
+		m.recordHit(contentEndpoint)
+		span.SetAttributes(attribute.String("cache.sync_mode", "snapshot"))
+		return snap.Documents, nil
+	}
+
+	startedAt := time.Now().UTC()
+	if !hasSnapshot {
+		m.recordMiss(contentEndpoint)
+		span.SetAttributes(attribute.String("cache.sync_mode", "full"))
+		docs, err := fetch(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		snap = ContentSnapshot{Documents: docs}
+	} else {
+		since := snap.SyncedAt.Format(time.RFC3339)
+		m.logger.Debug("syncing document content", "updated_after", since)
+		span.SetAttributes(attribute.String("cache.sync_mode", "delta"))
+		delta, err := fetch(ctx, since)
+		if err != nil {
+			if stale {
+				m.markStale(key)
+			}
+			return nil, err
+		}
+		merged := make(map[string]string, len(snap.Documents)+len(delta))
+		maps.Copy(merged, snap.Documents)
+		maps.Copy(merged, delta)
+		snap.Documents = merged
+		span.SetAttributes(attribute.Int("cache.delta_documents", len(delta)))
+	}
+	snap.SyncedAt = startedAt
+
+	if data, err := json.Marshal(snap); err == nil && !m.cache.Put(NewEntry(key, data, snapshotTTL)) {
+		m.logger.Warn("document content exceeds the cache size and is not cached; raise CACHE_MAX_SIZE_MB to keep it",
+			"size_bytes", len(data))
+	}
+	return snap.Documents, nil
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncDocumentContentFullThenCached(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	var calls []string
+	fetch := func(_ context.Context, updatedAfter string) (map[string]string, error) {
+		calls = append(calls, updatedAfter)
+		return map[string]string{"doc1": "body"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		docs, err := m.SyncDocumentContent(context.Background(), "api-key", fetch)
+		if err != nil {
+			t.Fatalf("SyncDocumentContent() error: %v", err)
+		}
+		if docs["doc1"] != "body" {
+			t.Fatalf("docs = %v, want doc1", docs)
+		}
+	}
+
+	if len(calls) != 1 || calls[0] != "" {
+		t.Errorf("fetch calls = %q, want a single full fetch", calls)
+	}
+}
+
+func TestSyncDocumentContentDeltaAfterWrite(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	var calls []string
+	fetch := func(_ context.Context, updatedAfter string) (map[string]string, error) {
+		calls = append(calls, updatedAfter)
+		if updatedAfter == "" {
+			return map[string]string{"doc1": "old body", "doc2": "other"}, nil
+		}
+		return map[string]string{"doc1": "new body", "doc3": "added"}, nil
+	}
+
+	before := time.Now().UTC().Add(-time.Second)
+	if _, err := m.SyncDocumentContent(context.Background(), "api-key", fetch); err != nil {
+		t.Fatalf("initial sync error: %v", err)
+	}
+
+	m.Invalidate("api-key", "update_document")
+
+	docs, err := m.SyncDocumentContent(context.Background(), "api-key", fetch)
+	if err != nil {
+		t.Fatalf("delta sync error: %v", err)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("fetch called %d times, want 2", len(calls))
+	}
+	since, err := time.Parse(time.RFC3339, calls[1])
+	if err != nil {
+		t.Fatalf("delta updatedAfter %q is not RFC 3339: %v", calls[1], err)
+	}
+	if since.Before(before) {
+		t.Errorf("delta updatedAfter = %v, want >= %v", since, before)
+	}
+	want := map[string]string{"doc1": "new body", "doc2": "other", "doc3": "added"}
+	if len(docs) != len(want) {
+		t.Fatalf("docs = %v, want %v", docs, want)
+	}
+	for id, text := range want {
+		if docs[id] != text {
+			t.Errorf("docs[%q] = %q, want %q", id, docs[id], text)
+		}
+	}
+}
+
+func TestSyncDocumentContentPerUser(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	fetchFor := func(text string) func(context.Context, string) (map[string]string, error) {
+		return func(context.Context, string) (map[string]string, error) {
+			return map[string]string{"doc": text}, nil
+		}
+	}
+
+	m.SyncDocumentContent(context.Background(), "alice", fetchFor("alice's"))
+	docs, _ := m.SyncDocumentContent(context.Background(), "bob", fetchFor("bob's"))
+	if docs["doc"] != "bob's" {
+		t.Errorf("bob got %q", docs["doc"])
+	}
+}
+
+func TestSyncDocumentContentLargerThanCache(t *testing.T) {
+	m := NewManager(1, 300, true)
+	other := NewEntry("other", []byte("kept"), time.Hour)
+	other.CreatedAt = time.Now().Add(-time.Minute)
+	m.cache.Put(other)
+
+	calls := 0
+	fetch := func(context.Context, string) (map[string]string, error) {
+		calls++
+		return map[string]string{"doc1": strings.Repeat("x", 2*1024*1024)}, nil
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := m.SyncDocumentContent(context.Background(), "api-key", fetch); err != nil {
+			t.Fatalf("SyncDocumentContent() error: %v", err)
+		}
+	}
+
+	if m.cache.Get("other") == nil {
+		t.Error("oversized content flushed other cache entries")
+	}
+	if calls != 2 {
+		t.Errorf("fetch calls = %d, want 2 since the content is not cached", calls)
+	}
+}
@@ -15,6 +15,7 @@ type LRU struct {
 	size     int64 // current total size
 	items    map[string]*list.Element
 	order    *list.List // front = most recently used
+	evicted  int64      // entries removed to stay within maxSize
 }
 
 // NewLRU creates a new LRU cache with the given maximum size in bytes.
@@ -48,10 +49,20 @@ func (c *LRU) Get(key string) *Entry {
 }
 
 // Put adds or updates an entry in the cache. Evicts LRU entries if over size limit.
-func (c *LRU) Put(entry *Entry) {
+// An entry larger than the whole cache is not stored, since making room for it
+// would flush every other entry, and Put reports false. Any previous entry
+// under its key is removed.
+func (c *LRU) Put(entry *Entry) bool {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if entry.Size > c.maxSize {
+		if elem, ok := c.items[entry.Key]; ok {
+			c.removeLocked(elem)
+		}
+		return false
+	}
+
 	// Update existing entry
 	if elem, ok := c.items[entry.Key]; ok {
 		old := elem.Value.(*Entry)
@@ -68,6 +79,7 @@ func (c *LRU) Put(entry *Entry) {
 
 	// Evict LRU entries if over size limit
 	c.evictLocked()
+	return true
 }
 
 // Delete removes an entry by key.
@@ -106,6 +118,14 @@ func (c *LRU) Len() int {
 	return len(c.items)
 }
 
+// Evictions returns the number of entries evicted to stay within the size limit.
+// Expired and deleted entries are not counted.
+func (c *LRU) Evictions() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.evicted
+}
+
 func (c *LRU) removeLocked(elem *list.Element) {
 	entry := elem.Value.(*Entry)
 	c.size -= entry.Size
@@ -128,5 +148,6 @@ func (c *LRU) evictLocked() {
 		}
 
 		c.removeLocked(elem)
+		c.evicted++
 	}
 }
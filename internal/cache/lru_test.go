@@ -69,6 +69,31 @@ func TestLRUEvictOnSizeLimit(t *testing.T) {
 	if c.Size() > 100 {
 		t.Errorf("cache size = %d, should be <= 100", c.Size())
 	}
+	if c.Evictions() != 1 {
+		t.Errorf("Evictions() = %d, want 1", c.Evictions())
+	}
+}
+
+func TestLRURejectsEntryLargerThanCache(t *testing.T) {
+	c := NewLRU(100)
+
+	small := NewEntry("small", make([]byte, 40), time.Hour)
+	small.CreatedAt = time.Now().Add(-time.Minute)
+	c.Put(small)
+	c.Put(NewEntry("big", make([]byte, 60), time.Hour))
+
+	if c.Put(NewEntry("big", make([]byte, 101), time.Hour)) {
+		t.Error("Put() = true for an entry larger than the cache")
+	}
+	if c.Get("small") == nil {
+		t.Error("small entry was evicted for an entry that does not fit")
+	}
+	if c.Get("big") != nil {
+		t.Error("previous entry under the rejected key should be removed")
+	}
+	if c.Evictions() != 0 {
+		t.Errorf("Evictions() = %d, want 0", c.Evictions())
+	}
 }
 
 func TestLRUUpdateExisting(t *testing.T) {
@@ -103,6 +128,16 @@ func TestLRUDelete(t *testing.T) {
 	}
 }
 
+func TestLRUDeleteNotCountedAsEviction(t *testing.T) {
+	c := NewLRU(1024)
+	c.Put(NewEntry("key1", []byte("data"), time.Hour))
+	c.Delete("key1")
+
+	if c.Evictions() != 0 {
+		t.Errorf("Evictions() = %d, want 0", c.Evictions())
+	}
+}
+
 func TestLRUDeleteByPrefix(t *testing.T) {
 	c := NewLRU(1024 * 1024)
 
@@ -1,34 +1,49 @@
 package cache
 
 import (
-	"crypto/sha256"
-	"fmt"
+	"context"
+	"log/slog"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cached endpoints. These form the second component of every cache key.
+const (
+	EndpointExport     = "/api/v2/export/"
+	EndpointBooks      = "/api/v2/books/"
+	EndpointDocuments  = "/api/v3/list/"
+	EndpointReaderTags = "/api/v3/tags/"
 )
 
 // Default TTLs for different endpoints.
 var defaultTTLs = map[string]time.Duration{
-	"/api/v2/export/": 5 * time.Minute,
-	"/api/v2/books/":  5 * time.Minute,
-	"/api/v3/list/":   5 * time.Minute,
-	"/api/v3/tags/":   10 * time.Minute,
+	EndpointExport:     5 * time.Minute,
+	EndpointBooks:      5 * time.Minute,
+	EndpointDocuments:  5 * time.Minute,
+	EndpointReaderTags: 10 * time.Minute,
 }
 
 // invalidationMap maps write/destructive operations to cached endpoints to invalidate.
 var invalidationMap = map[string][]string{
-	"create_highlight":       {"/api/v2/export/"},
-	"update_highlight":       {"/api/v2/export/"},
-	"delete_highlight":       {"/api/v2/export/"},
-	"bulk_create_highlights": {"/api/v2/export/"},
-	"add_source_tag":         {"/api/v2/books/"},
-	"delete_source_tag":      {"/api/v2/books/"},
-	"add_highlight_tag":      {"/api/v2/export/"},
-	"delete_highlight_tag":   {"/api/v2/export/"},
-	"save_document":          {"/api/v3/list/"},
-	"update_document":        {"/api/v3/list/"},
-	"delete_document":        {"/api/v3/list/"},
+	"create_highlight":       {EndpointExport},
+	"update_highlight":       {EndpointExport},
+	"delete_highlight":       {EndpointExport},
+	"bulk_create_highlights": {EndpointExport},
+	"add_source_tag":         {EndpointBooks, EndpointExport},
+	"delete_source_tag":      {EndpointBooks, EndpointExport},
+	"add_highlight_tag":      {EndpointExport},
+	"delete_highlight_tag":   {EndpointExport},
+	"save_document":          {EndpointDocuments},
+	"update_document":        {EndpointDocuments},
+	"delete_document":        {EndpointDocuments},
 }
 
 // Manager orchestrates cache operations with per-user isolation
@@ -37,6 +52,25 @@ type Manager struct {
 	cache      *LRU
 	enabled    bool
 	defaultTTL time.Duration
+	logger     *slog.Logger
+	flight     singleflight.Group
+
+	staleMu sync.Mutex
+	stale   map[string]bool // keys of snapshots that must be re-synced
+
+	statsMu sync.Mutex
+	hits    map[string]int64 // by endpoint
+	misses  map[string]int64 // by endpoint
+}
+
+// Stats is a point-in-time view of cache activity. Hits and misses are keyed
+// by endpoint and never include user information.
+type Stats struct {
+	Hits      map[string]int64
+	Misses    map[string]int64
+	Evictions int64
+	Bytes     int64
+	Entries   int
 }
 
 // NewManager creates a new cache manager.
@@ -46,9 +80,55 @@ func NewManager(maxSizeMB int, defaultTTLSeconds int, enabled bool) *Manager {
 		cache:      NewLRU(maxSizeBytes),
 		enabled:    enabled,
 		defaultTTL: time.Duration(defaultTTLSeconds) * time.Second,
+		logger:     slog.New(slog.DiscardHandler),
+		stale:      make(map[string]bool),
+		hits:       make(map[string]int64),
+		misses:     make(map[string]int64),
 	}
 }
 
+// Stats returns the cache's activity counters and current size.
+func (m *Manager) Stats() Stats {
+	m.statsMu.Lock()
+	st := Stats{
+		Hits:   make(map[string]int64, len(m.hits)),
+		Misses: make(map[string]int64, len(m.misses)),
+	}
+	for k, v := range m.hits {
+		st.Hits[k] = v
+	}
+	for k, v := range m.misses {
+		st.Misses[k] = v
+	}
+	m.statsMu.Unlock()
+
+	st.Evictions = m.cache.Evictions()
+	st.Bytes = m.cache.Size()
+	st.Entries = m.cache.Len()
+	return st
+}
+
+// recordHit counts a cache hit for the endpoint and logs it.
+func (m *Manager) recordHit(endpoint string) {
+	m.statsMu.Lock()
+	m.hits[endpoint]++
+	m.statsMu.Unlock()
+	m.logger.Debug("cache hit", "endpoint", endpoint)
+}
+
+// recordMiss counts a cache miss for the endpoint and logs it.
+func (m *Manager) recordMiss(endpoint string) {
+	m.statsMu.Lock()
+	m.misses[endpoint]++
+	m.statsMu.Unlock()
+	m.logger.Debug("cache miss", "endpoint", endpoint)
+}
+
+// SetLogger sets the logger used for cache hit/miss debug output.
+func (m *Manager) SetLogger(logger *slog.Logger) {
+	m.logger = logger
+}
+
 // buildKey constructs a cache key from the API key hash, endpoint, and query parameters.
 // The key preserves the prefix structure (apiKeyHash|endpoint|...) so that
 // DeleteByPrefix can invalidate all entries for a user+endpoint combination.
@@ -72,12 +152,6 @@ func buildKey(apiKeyHash, endpoint string, params map[string]string) string {
 	return apiKeyHash + "|" + endpoint
 }
 
-// HashAPIKey returns the SHA-256 hash of an API key for cache key construction.
-func HashAPIKey(apiKey string) string {
-	h := sha256.Sum256([]byte(apiKey))
-	return fmt.Sprintf("%x", h)
-}
-
 // userEndpointPrefix returns a prefix for finding all cache entries
 // for a specific user and endpoint combination.
 func userEndpointPrefix(apiKeyHash, endpoint string) string {
@@ -90,7 +164,7 @@ func (m *Manager) Get(apiKey, endpoint string, params map[string]string) []byte
 	if !m.enabled {
 		return nil
 	}
-	keyHash := HashAPIKey(apiKey)
+	keyHash := auth.HashAPIKey(apiKey)
 	key := buildKey(keyHash, endpoint, params)
 	entry := m.cache.Get(key)
 	if entry == nil {
@@ -104,7 +178,7 @@ func (m *Manager) Put(apiKey, endpoint string, params map[string]string, data []
 	if !m.enabled {
 		return
 	}
-	keyHash := HashAPIKey(apiKey)
+	keyHash := auth.HashAPIKey(apiKey)
 	key := buildKey(keyHash, endpoint, params)
 
 	ttl := m.defaultTTL
@@ -115,6 +189,69 @@ func (m *Manager) Put(apiKey, endpoint string, params map[string]string, data []
 	m.cache.Put(NewEntry(key, data, ttl))
 }
 
+// Fetch returns the cached response for the given parameters, calling fetch
+// and caching its result on a miss. Concurrent misses for the same key share
+// a single call to fetch. Errors from fetch are returned as-is and never
+// cached. When caching is disabled, fetch is still coalesced but its result
+// is not stored.
+func (m *Manager) Fetch(ctx context.Context, apiKey, endpoint string, params map[string]string, fetch func(ctx context.Context) ([]byte, error)) (_ []byte, err error) {
+	ctx, span := telemetry.Start(ctx, "cache.fetch", attribute.String("cache.endpoint", endpoint))
+	defer func() { telemetry.End(span, err) }()
+
+	if data := m.Get(apiKey, endpoint, params); data != nil {
+		m.recordHit(endpoint)
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return data, nil
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	if m.enabled {
+		m.recordMiss(endpoint)
+	}
+
+	key := buildKey(auth.HashAPIKey(apiKey), endpoint, params)
+	v, err := m.coalesce(ctx, key, endpoint, func(ctx context.Context) (any, error) {
+		data, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.Put(apiKey, endpoint, params, data)
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
+
+// fetchTimeout bounds a coalesced fetch, which no longer ends with the
+// context of the caller that started it.
+var fetchTimeout = 5 * time.Minute
+
+// coalesce runs fn once for all concurrent callers using the same cache key
+// and hands each of them the shared result. fn runs detached from the
+// cancellation of the caller that started it, so one caller giving up does
+// not fail the others; a caller whose own context ends stops waiting. fn is
+// cancelled after fetchTimeout so an upstream that never answers cannot
+// hold the key forever.
+func (m *Manager) coalesce(ctx context.Context, key, endpoint string, fn func(ctx context.Context) (any, error)) (any, error) {
+	ch := m.flight.DoChan(key, func() (any, error) {
+		fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		return fn(fctx)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Shared {
+			m.logger.Debug("coalesced upstream fetch", "endpoint", endpoint)
+		}
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cache.coalesced", res.Shared))
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 // Invalidate removes all cached entries for the given user and operation.
 func (m *Manager) Invalidate(apiKey string, operation string) {
 	if !m.enabled {
@@ -125,10 +262,16 @@ func (m *Manager) Invalidate(apiKey string, operation string) {
 		return
 	}
 
-	keyHash := HashAPIKey(apiKey)
+	keyHash := auth.HashAPIKey(apiKey)
 	for _, endpoint := range endpoints {
 		prefix := userEndpointPrefix(keyHash, endpoint)
 		m.cache.DeleteByPrefix(prefix)
+		switch endpoint {
+		case EndpointExport:
+			m.expireSnapshot(keyHash, operation)
+		case EndpointDocuments:
+			m.markStale(buildKey(keyHash, contentEndpoint, nil))
+		}
 	}
 }
 
@@ -137,7 +280,7 @@ func (m *Manager) InvalidateEndpoint(apiKey, endpoint string) {
 	if !m.enabled {
 		return
 	}
-	keyHash := HashAPIKey(apiKey)
+	keyHash := auth.HashAPIKey(apiKey)
 	prefix := userEndpointPrefix(keyHash, endpoint)
 	m.cache.DeleteByPrefix(prefix)
 }
@@ -1,7 +1,13 @@
 package cache
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestManagerGetPut(t *testing.T) {
@@ -53,6 +59,7 @@ func TestManagerInvalidationBooks(t *testing.T) {
 	m := NewManager(1, 300, true)
 
 	m.Put("api-key", "/api/v2/books/", nil, []byte("books data"))
+	m.Put("api-key", "/api/v2/export/", nil, []byte("export data"))
 
 	m.Invalidate("api-key", "add_source_tag")
 
@@ -60,6 +67,10 @@ func TestManagerInvalidationBooks(t *testing.T) {
 	if data != nil {
 		t.Error("books cache should be invalidated after add_source_tag")
 	}
+	// Export entries embed book tags, so they go stale too.
+	if m.Get("api-key", "/api/v2/export/", nil) != nil {
+		t.Error("export cache should be invalidated after add_source_tag")
+	}
 }
 
 func TestManagerInvalidationDocuments(t *testing.T) {
@@ -122,27 +133,205 @@ func TestManagerWithParams(t *testing.T) {
 	}
 }
 
-func TestHashAPIKey(t *testing.T) {
-	hash1 := HashAPIKey("key1")
-	hash2 := HashAPIKey("key2")
-	hash1again := HashAPIKey("key1")
+func TestManagerTotalSize(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	m.Put("key", "/api/v2/books/", nil, make([]byte, 100))
+	if m.TotalSize() != 100 {
+		t.Errorf("TotalSize() = %d, want 100", m.TotalSize())
+	}
+}
+
+func TestManagerFetchReadThrough(t *testing.T) {
+	m := NewManager(1, 300, true)
 
-	if hash1 == hash2 {
-		t.Error("different keys should produce different hashes")
+	calls := 0
+	fetch := func(context.Context) ([]byte, error) {
+		calls++
+		return []byte("export data"), nil
 	}
-	if hash1 != hash1again {
-		t.Error("same key should produce same hash")
+
+	for i := 0; i < 3; i++ {
+		data, err := m.Fetch(context.Background(), "api-key", EndpointExport, nil, fetch)
+		if err != nil {
+			t.Fatalf("Fetch() error: %v", err)
+		}
+		if string(data) != "export data" {
+			t.Errorf("data = %q, want %q", string(data), "export data")
+		}
 	}
-	if len(hash1) != 64 {
-		t.Errorf("SHA-256 hash should be 64 hex chars, got %d", len(hash1))
+	if calls != 1 {
+		t.Errorf("fetch called %d times, want 1", calls)
 	}
 }
 
-func TestManagerTotalSize(t *testing.T) {
+func TestManagerStats(t *testing.T) {
 	m := NewManager(1, 300, true)
 
-	m.Put("key", "/api/v2/books/", nil, make([]byte, 100))
-	if m.TotalSize() != 100 {
-		t.Errorf("TotalSize() = %d, want 100", m.TotalSize())
+	fetch := func(context.Context) ([]byte, error) { return []byte("data"), nil }
+	for i := 0; i < 3; i++ {
+		m.Fetch(context.Background(), "api-key", EndpointBooks, nil, fetch)
+	}
+	m.Fetch(context.Background(), "api-key", EndpointReaderTags, nil, fetch)
+
+	st := m.Stats()
+	if st.Hits[EndpointBooks] != 2 || st.Misses[EndpointBooks] != 1 {
+		t.Errorf("books hits/misses = %d/%d, want 2/1", st.Hits[EndpointBooks], st.Misses[EndpointBooks])
+	}
+	if st.Misses[EndpointReaderTags] != 1 {
+		t.Errorf("tags misses = %d, want 1", st.Misses[EndpointReaderTags])
+	}
+	if st.Entries != 2 || st.Bytes != 8 {
+		t.Errorf("entries/bytes = %d/%d, want 2/8", st.Entries, st.Bytes)
+	}
+}
+
+func TestManagerFetchErrorNotCached(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	_, err := m.Fetch(context.Background(), "api-key", EndpointBooks, nil, func(context.Context) ([]byte, error) {
+		return nil, errors.New("upstream failure")
+	})
+	if err == nil {
+		t.Fatal("expected error from fetch")
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 (errors must not be cached)", m.Len())
+	}
+}
+
+func TestManagerFetchDisabled(t *testing.T) {
+	m := NewManager(1, 300, false)
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		m.Fetch(context.Background(), "api-key", EndpointBooks, nil, func(context.Context) ([]byte, error) {
+			calls++
+			return []byte("data"), nil
+		})
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want 2 when cache is disabled", calls)
+	}
+}
+
+// runConcurrently starts n callers of call, releases the first in-flight
+// fetch once all callers have had a chance to join it, and waits for them.
+func runConcurrently(n int, release chan struct{}, call func()) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			call()
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+}
+
+func TestManagerFetchCoalescesConcurrentMisses(t *testing.T) {
+	// Disabled cache: every caller misses, so only coalescing can dedupe.
+	m := NewManager(1, 300, false)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var results []string
+
+	runConcurrently(10, release, func() {
+		data, err := m.Fetch(context.Background(), "api-key", EndpointDocuments, nil, func(context.Context) ([]byte, error) {
+			calls.Add(1)
+			<-release
+			return []byte("documents"), nil
+		})
+		if err != nil {
+			t.Errorf("Fetch() error: %v", err)
+			return
+		}
+		mu.Lock()
+		results = append(results, string(data))
+		mu.Unlock()
+	})
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fetch called %d times, want 1", n)
+	}
+	if len(results) != 10 {
+		t.Fatalf("got %d results, want 10", len(results))
+	}
+	for _, r := range results {
+		if r != "documents" {
+			t.Errorf("result = %q, want %q", r, "documents")
+		}
+	}
+}
+
+func TestManagerFetchDoesNotCoalesceAcrossUsers(t *testing.T) {
+	m := NewManager(1, 300, false)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var n atomic.Int32
+
+	runConcurrently(4, release, func() {
+		key := fmt.Sprintf("user-%d", n.Add(1)%2)
+		m.Fetch(context.Background(), key, EndpointDocuments, nil, func(context.Context) ([]byte, error) {
+			calls.Add(1)
+			<-release
+			return []byte("documents"), nil
+		})
+	})
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("fetch called %d times, want 2 (one per user)", got)
+	}
+}
+
+func TestManagerFetchCallerCancellation(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := m.Fetch(ctx, "api-key", EndpointExport, nil, func(ctx context.Context) ([]byte, error) {
+			<-release
+			return []byte("export"), ctx.Err()
+		})
+		errCh <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+
+	// The shared fetch keeps running and still populates the cache.
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for m.Get("api-key", EndpointExport, nil) == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if m.Get("api-key", EndpointExport, nil) == nil {
+		t.Error("detached fetch should have populated the cache")
+	}
+}
+
+func TestManagerFetchDetachedTimeout(t *testing.T) {
+	defer func(d time.Duration) { fetchTimeout = d }(fetchTimeout)
+	fetchTimeout = 20 * time.Millisecond
+
+	m := NewManager(1, 300, true)
+
+	_, err := m.Fetch(context.Background(), "api-key", EndpointExport, nil, func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
 	}
 }
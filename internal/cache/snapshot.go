@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// snapshotEndpoint namespaces export snapshots in the LRU. It deliberately does
+// not share the EndpointExport prefix, so invalidating cached export responses
+// leaves the snapshot in place for the next delta sync.
+const snapshotEndpoint = "snapshot:" + EndpointExport
+
+// snapshotTTL bounds how long a snapshot is built on deltas. Deletions made
+// outside this server never show up in an updatedAfter delta, so once the last
+// full export is older than this the next read performs a full export again.
+const snapshotTTL = 24 * time.Hour
+
+// snapshotResetOps lists operations whose effect cannot be observed through an
+// updatedAfter delta, so they discard the snapshot instead of marking it stale.
+var snapshotResetOps = map[string]bool{
+	"delete_highlight": true,
+}
+
+// ExportSnapshot is a user's full highlight export as of the last sync.
+type ExportSnapshot struct {
+	SyncedAt time.Time            `json:"synced_at"`
+	FullAt   time.Time            `json:"full_at"`
+	Sources  []types.ExportSource `json:"sources"`
+}
+
+// SyncExport returns the user's full highlight export from a per-user snapshot.
+// The first call stores a full export. Later calls are served from the
+// snapshot until it is older than the export TTL or marked stale by a write,
+// after which only the changes since the last sync are fetched via
+// updatedAfter and merged in. A snapshot whose last full export is older than
+// snapshotTTL is replaced by a new full export. Concurrent calls for the same user share one
+// sync. When caching is disabled, every call performs a full export.
+func (m *Manager) SyncExport(ctx context.Context, apiKey string, fetch func(ctx context.Context, updatedAfter string) ([]types.ExportSource, error)) (_ []types.ExportSource, err error) {
+	ctx, span := telemetry.Start(ctx, "cache.sync_export")
+	defer func() { telemetry.End(span, err) }()
+
+	keyHash := auth.HashAPIKey(apiKey)
+	key := buildKey(keyHash, snapshotEndpoint, nil)
+
+	v, err := m.coalesce(ctx, key, EndpointExport, func(ctx context.Context) (any, error) {
+		if !m.enabled {
+			return fetch(ctx, "")
+		}
+		return m.syncExport(ctx, keyHash, key, fetch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]types.ExportSource), nil
+}
+
+// syncExport performs a snapshot sync for SyncExport.
+func (m *Manager) syncExport(ctx context.Context, keyHash, key string, fetch func(ctx context.Context, updatedAfter string) ([]types.ExportSource, error)) ([]types.ExportSource, error) {
+	var snap ExportSnapshot
+	hasSnapshot := false
+	if entry := m.cache.Get(key); entry != nil {
+		hasSnapshot = json.Unmarshal(entry.Data, &snap) == nil
+	}
+	if hasSnapshot && time.Since(snap.FullAt) >= snapshotTTL {
+		hasSnapshot = false
+	}
+	stale := m.takeStale(key)
+
+	span := trace.SpanFromContext(ctx)
+	if hasSnapshot && !stale && time.Since(snap.SyncedAt) < defaultTTLs[EndpointExport] {
+		m.recordHit(snapshotEndpoint)
+		span.SetAttributes(attribute.String("cache.sync_mode", "snapshot"))
+		return snap.Sources, nil
+	}
+
+	startedAt := time.Now().UTC()
+	if !hasSnapshot {
+		m.recordMiss(snapshotEndpoint)
+		span.SetAttributes(attribute.String("cache.sync_mode", "full"))
+		sources, err := fetch(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		snap = ExportSnapshot{FullAt: startedAt, Sources: sources}
+	} else {
+		since := snap.SyncedAt.Format(time.RFC3339)
+		m.logger.Debug("syncing export snapshot", "updated_after", since)
+		span.SetAttributes(attribute.String("cache.sync_mode", "delta"))
+		delta, err := fetch(ctx, since)
+		if err != nil {
+			if stale {
+				m.markStale(key)
+			}
+			return nil, err
+		}
+		snap.Sources = MergeExport(snap.Sources, delta)
+		span.SetAttributes(attribute.Int("cache.delta_sources", len(delta)))
+	}
+	snap.SyncedAt = startedAt
+
+	// Delta syncs keep the expiry of the full export the snapshot is based on.
+	if data, err := json.Marshal(snap); err == nil && !m.cache.Put(NewEntry(key, data, snapshotTTL-time.Since(snap.FullAt))) {
+		m.logger.Warn("highlight export exceeds the cache size and is not cached; raise CACHE_MAX_SIZE_MB to keep it",
+			"size_bytes", len(data))
+	}
+	return snap.Sources, nil
+}
+
+// MergeExport merges changed sources from an updatedAfter export into base.
+// Sources are matched by user_book_id and highlights by id; changed entries
+// replace existing ones in place and new entries are appended. The result
+// does not alias either input.
+func MergeExport(base, delta []types.ExportSource) []types.ExportSource {
+	merged := make([]types.ExportSource, len(base))
+	copy(merged, base)
+
+	index := make(map[int64]int, len(merged))
+	for i, s := range merged {
+		index[s.UserBookID] = i
+	}
+
+	for _, changed := range delta {
+		i, ok := index[changed.UserBookID]
+		if !ok {
+			index[changed.UserBookID] = len(merged)
+			merged = append(merged, changed)
+			continue
+		}
+
+		existing := merged[i].Highlights
+		source := changed
+		source.Highlights = mergeHighlights(existing, changed.Highlights)
+		merged[i] = source
+	}
+
+	return merged
+}
+
+// mergeHighlights replaces highlights in base by id and appends new ones.
+func mergeHighlights(base, delta []types.Highlight) []types.Highlight {
+	merged := make([]types.Highlight, len(base), len(base)+len(delta))
+	copy(merged, base)
+
+	index := make(map[int64]int, len(merged))
+	for i, h := range merged {
+		index[h.ID] = i
+	}
+
+	for _, h := range delta {
+		if i, ok := index[h.ID]; ok {
+			merged[i] = h
+		} else {
+			index[h.ID] = len(merged)
+			merged = append(merged, h)
+		}
+	}
+	return merged
+}
+
+// expireSnapshot forces the next SyncExport for the user to contact the API,
+// either with a delta sync or, for operations in snapshotResetOps, a full export.
+func (m *Manager) expireSnapshot(keyHash, operation string) {
+	if snapshotResetOps[operation] {
+		m.cache.Delete(buildKey(keyHash, snapshotEndpoint, nil))
+		return
+	}
+	m.markStale(buildKey(keyHash, snapshotEndpoint, nil))
+}
+
+// markStale makes the next sync of the snapshot stored under key contact the API.
+func (m *Manager) markStale(key string) {
+	m.staleMu.Lock()
+	defer m.staleMu.Unlock()
+	m.stale[key] = true
+}
+
+// takeStale reports whether the snapshot stored under key was marked stale
+// and clears the mark.
+func (m *Manager) takeStale(key string) bool {
+	m.staleMu.Lock()
+	defer m.staleMu.Unlock()
+	stale := m.stale[key]
+	delete(m.stale, key)
+	return stale
+}
@@ -0,0 +1,270 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func TestMergeExportReplacesAndAppends(t *testing.T) {
+	base := []types.ExportSource{
+		{UserBookID: 1, Title: "Book 1", Highlights: []types.Highlight{
+			{ID: 10, Text: "old text"},
+			{ID: 11, Text: "unchanged"},
+		}},
+		{UserBookID: 2, Title: "Book 2", Highlights: []types.Highlight{{ID: 20, Text: "other"}}},
+	}
+	delta := []types.ExportSource{
+		{UserBookID: 1, Title: "Book 1 (renamed)", Highlights: []types.Highlight{
+			{ID: 10, Text: "new text"},
+			{ID: 12, Text: "added"},
+		}},
+		{UserBookID: 3, Title: "Book 3", Highlights: []types.Highlight{{ID: 30, Text: "new source"}}},
+	}
+
+	merged := MergeExport(base, delta)
+
+	if len(merged) != 3 {
+		t.Fatalf("len(merged) = %d, want 3", len(merged))
+	}
+	if merged[0].Title != "Book 1 (renamed)" {
+		t.Errorf("source metadata not replaced: title = %q", merged[0].Title)
+	}
+	hl := merged[0].Highlights
+	if len(hl) != 3 {
+		t.Fatalf("len(highlights) = %d, want 3", len(hl))
+	}
+	if hl[0].ID != 10 || hl[0].Text != "new text" {
+		t.Errorf("highlight 10 not replaced in place: %+v", hl[0])
+	}
+	if hl[1].ID != 11 || hl[2].ID != 12 {
+		t.Errorf("unexpected highlight order: %d, %d", hl[1].ID, hl[2].ID)
+	}
+	if merged[2].UserBookID != 3 {
+		t.Errorf("new source not appended: %+v", merged[2])
+	}
+	if base[0].Highlights[0].Text != "old text" {
+		t.Error("MergeExport must not modify its input")
+	}
+}
+
+func TestSyncExportFullThenCached(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	var calls []string
+	fetch := func(_ context.Context, updatedAfter string) ([]types.ExportSource, error) {
+		calls = append(calls, updatedAfter)
+		return []types.ExportSource{{UserBookID: 1}}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		sources, err := m.SyncExport(context.Background(), "api-key", fetch)
+		if err != nil {
+			t.Fatalf("SyncExport() error: %v", err)
+		}
+		if len(sources) != 1 {
+			t.Fatalf("len(sources) = %d, want 1", len(sources))
+		}
+	}
+
+	if len(calls) != 1 || calls[0] != "" {
+		t.Errorf("fetch calls = %q, want a single full export", calls)
+	}
+}
+
+func TestSyncExportDeltaAfterWrite(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	var calls []string
+	fetch := func(_ context.Context, updatedAfter string) ([]types.ExportSource, error) {
+		calls = append(calls, updatedAfter)
+		if updatedAfter == "" {
+			return []types.ExportSource{{UserBookID: 1, Highlights: []types.Highlight{{ID: 10, Text: "first"}}}}, nil
+		}
+		return []types.ExportSource{{UserBookID: 1, Highlights: []types.Highlight{{ID: 11, Text: "second"}}}}, nil
+	}
+
+	before := time.Now().UTC().Add(-time.Second)
+	if _, err := m.SyncExport(context.Background(), "api-key", fetch); err != nil {
+		t.Fatalf("initial sync error: %v", err)
+	}
+
+	m.Invalidate("api-key", "create_highlight")
+
+	sources, err := m.SyncExport(context.Background(), "api-key", fetch)
+	if err != nil {
+		t.Fatalf("delta sync error: %v", err)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("fetch called %d times, want 2", len(calls))
+	}
+	since, err := time.Parse(time.RFC3339, calls[1])
+	if err != nil {
+		t.Fatalf("delta updatedAfter %q is not RFC 3339: %v", calls[1], err)
+	}
+	if since.Before(before) {
+		t.Errorf("delta updatedAfter = %v, want >= %v", since, before)
+	}
+	if len(sources) != 1 || len(sources[0].Highlights) != 2 {
+		t.Errorf("delta not merged into snapshot: %+v", sources)
+	}
+}
+
+func TestSyncExportDeleteForcesFullExport(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	var calls []string
+	fetch := func(_ context.Context, updatedAfter string) ([]types.ExportSource, error) {
+		calls = append(calls, updatedAfter)
+		return nil, nil
+	}
+
+	m.SyncExport(context.Background(), "api-key", fetch)
+	m.Invalidate("api-key", "delete_highlight")
+	m.SyncExport(context.Background(), "api-key", fetch)
+
+	if len(calls) != 2 || calls[1] != "" {
+		t.Errorf("fetch calls = %q, want two full exports", calls)
+	}
+}
+
+func TestSyncExportSnapshotSurvivesEndpointInvalidation(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	m.SyncExport(context.Background(), "api-key", func(context.Context, string) ([]types.ExportSource, error) {
+		return []types.ExportSource{{UserBookID: 1}}, nil
+	})
+	m.InvalidateEndpoint("api-key", EndpointExport)
+
+	var since string
+	m.SyncExport(context.Background(), "api-key", func(_ context.Context, updatedAfter string) ([]types.ExportSource, error) {
+		since = updatedAfter
+		return nil, nil
+	})
+	if since != "" {
+		t.Errorf("unexpected fetch with updatedAfter %q; snapshot should still be fresh", since)
+	}
+}
+
+func TestSyncExportFullExportAfterSnapshotTTL(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	snap := ExportSnapshot{
+		SyncedAt: time.Now().UTC(),
+		FullAt:   time.Now().UTC().Add(-snapshotTTL - time.Minute),
+		Sources:  []types.ExportSource{{UserBookID: 1}, {UserBookID: 2}},
+	}
+	data, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := buildKey(auth.HashAPIKey("api-key"), snapshotEndpoint, nil)
+	m.cache.Put(NewEntry(key, data, time.Hour))
+
+	var calls []string
+	sources, err := m.SyncExport(context.Background(), "api-key", func(_ context.Context, updatedAfter string) ([]types.ExportSource, error) {
+		calls = append(calls, updatedAfter)
+		return []types.ExportSource{{UserBookID: 1}}, nil
+	})
+	if err != nil {
+		t.Fatalf("SyncExport() error: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != "" {
+		t.Errorf("fetch calls = %q, want a full export", calls)
+	}
+	if len(sources) != 1 {
+		t.Errorf("len(sources) = %d, want 1; deleted source should be gone", len(sources))
+	}
+}
+
+func TestSyncExportDeltaKeepsSnapshotExpiry(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	fetch := func(context.Context, string) ([]types.ExportSource, error) {
+		return []types.ExportSource{{UserBookID: 1}}, nil
+	}
+	m.SyncExport(context.Background(), "api-key", fetch)
+	key := buildKey(auth.HashAPIKey("api-key"), snapshotEndpoint, nil)
+	first := m.cache.Get(key)
+
+	time.Sleep(10 * time.Millisecond)
+	m.Invalidate("api-key", "update_highlight")
+	m.SyncExport(context.Background(), "api-key", fetch)
+	second := m.cache.Get(key)
+
+	if second.TTL >= first.TTL {
+		t.Errorf("snapshot TTL after delta = %v, want less than %v", second.TTL, first.TTL)
+	}
+}
+
+func TestSyncExportErrorKeepsStaleMark(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	m.SyncExport(context.Background(), "api-key", func(context.Context, string) ([]types.ExportSource, error) {
+		return []types.ExportSource{{UserBookID: 1}}, nil
+	})
+	m.Invalidate("api-key", "update_highlight")
+
+	_, err := m.SyncExport(context.Background(), "api-key", func(context.Context, string) ([]types.ExportSource, error) {
+		return nil, errors.New("upstream failure")
+	})
+	if err == nil {
+		t.Fatal("expected error from failed delta sync")
+	}
+
+	called := false
+	m.SyncExport(context.Background(), "api-key", func(context.Context, string) ([]types.ExportSource, error) {
+		called = true
+		return nil, nil
+	})
+	if !called {
+		t.Error("snapshot should remain stale after a failed delta sync")
+	}
+}
+
+func TestSyncExportDisabled(t *testing.T) {
+	m := NewManager(1, 300, false)
+
+	calls := 0
+	for i := 0; i < 2; i++ {
+		m.SyncExport(context.Background(), "api-key", func(_ context.Context, updatedAfter string) ([]types.ExportSource, error) {
+			calls++
+			if updatedAfter != "" {
+				t.Errorf("updatedAfter = %q, want full export when cache is disabled", updatedAfter)
+			}
+			return nil, nil
+		})
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want 2", calls)
+	}
+}
+
+func TestSyncExportCoalescesConcurrentSyncs(t *testing.T) {
+	m := NewManager(1, 300, true)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	runConcurrently(8, release, func() {
+		sources, err := m.SyncExport(context.Background(), "api-key", func(context.Context, string) ([]types.ExportSource, error) {
+			calls.Add(1)
+			<-release
+			return []types.ExportSource{{UserBookID: 1}}, nil
+		})
+		if err != nil || len(sources) != 1 {
+			t.Errorf("SyncExport() = %v, %v", sources, err)
+		}
+	})
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fetch called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func hasAttr(span sdktrace.ReadOnlySpan, kv attribute.KeyValue) bool {
+	for _, a := range span.Attributes() {
+		if a == kv {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTracingFetchHitAndMiss(t *testing.T) {
+	recorder := recordSpans(t)
+	m := NewManager(1, 300, true)
+
+	fetch := func(context.Context) ([]byte, error) { return []byte("data"), nil }
+	m.Fetch(context.Background(), "api-key", EndpointBooks, nil, fetch)
+	m.Fetch(context.Background(), "api-key", EndpointBooks, nil, fetch)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	if !hasAttr(spans[0], attribute.Bool("cache.hit", false)) {
+		t.Error("first fetch should record a miss")
+	}
+	if !hasAttr(spans[1], attribute.Bool("cache.hit", true)) {
+		t.Error("second fetch should record a hit")
+	}
+	if !hasAttr(spans[0], attribute.String("cache.endpoint", EndpointBooks)) {
+		t.Error("span missing cache.endpoint")
+	}
+}
+
+func TestTracingSyncExportModes(t *testing.T) {
+	recorder := recordSpans(t)
+	m := NewManager(1, 300, true)
+
+	fetch := func(ctx context.Context, updatedAfter string) ([]types.ExportSource, error) {
+		return []types.ExportSource{{UserBookID: 1}}, nil
+	}
+	m.SyncExport(context.Background(), "api-key", fetch)
+	m.SyncExport(context.Background(), "api-key", fetch)
+	m.Invalidate("api-key", "create_highlight")
+	m.SyncExport(context.Background(), "api-key", fetch)
+
+	spans := recorder.Ended()
+	want := []string{"full", "snapshot", "delta"}
+	if len(spans) != len(want) {
+		t.Fatalf("ended spans = %d, want %d", len(spans), len(want))
+	}
+	for i, mode := range want {
+		if !hasAttr(spans[i], attribute.String("cache.sync_mode", mode)) {
+			t.Errorf("span %d missing cache.sync_mode=%s", i, mode)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Item is a text to embed, such as a highlight, identified by ID.
+type Item struct {
+	ID   int64
+	Text string
+}
+
+// VectorCache keeps the embedding vectors of each user's items and embeds
+// only items that are new or whose text changed since the last call. It holds
+// the vectors of at most maxUsers users and drops the least recently used
+// user's vectors beyond that.
+type VectorCache struct {
+	embedder Embedder
+
+	mu       sync.Mutex
+	maxUsers int
+	users    map[string]*userVectors
+	clock    uint64 // incremented on every access to order users by recency
+}
+
+type userVectors struct {
+	vectors map[int64]vector
+	lastUse uint64
+}
+
+type vector struct {
+	textHash uint64
+	values   []float32
+}
+
+// NewVectorCache creates a cache embedding with embedder and holding vectors
+// for up to maxUsers users.
+func NewVectorCache(embedder Embedder, maxUsers int) *VectorCache {
+	return &VectorCache{
+		embedder: embedder,
+		maxUsers: max(1, maxUsers),
+		users:    make(map[string]*userVectors),
+	}
+}
+
+// Vectors returns the vectors of items keyed by item ID. user must identify
+// the user without revealing the API key (e.g. auth.HashAPIKey). Items with
+// empty text get no vector, and vectors of items no longer present are
+// dropped. When embedding fails, the vectors computed before the failure are
+// kept, so a retry only embeds the rest.
+func (c *VectorCache) Vectors(ctx context.Context, user string, items []Item) (_ map[int64][]float32, err error) {
+	ctx, span := telemetry.Start(ctx, "embeddings.update", attribute.Int("embeddings.items", len(items)))
+	defer func() { telemetry.End(span, err) }()
+
+	hashes := make([]uint64, len(items))
+	for i, item := range items {
+		hashes[i] = textHash(item.Text)
+	}
+
+	c.mu.Lock()
+	cached := c.touchLocked(user).vectors
+	var missing []int
+	for i, item := range items {
+		if v, ok := cached[item.ID]; item.Text != "" && (!ok || v.textHash != hashes[i]) {
+			missing = append(missing, i)
+		}
+	}
+	c.mu.Unlock()
+	span.SetAttributes(attribute.Int("embeddings.computed", len(missing)))
+
+	// Embed outside the lock; concurrent updates for one user are harmless.
+	var computed [][]float32
+	var embedErr error
+	if len(missing) > 0 {
+		texts := make([]string, len(missing))
+		for j, i := range missing {
+			texts[j] = items[i].Text
+		}
+		computed, embedErr = c.embedder.Embed(ctx, texts)
+		if len(computed) > len(texts) || (embedErr == nil && len(computed) != len(texts)) {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d texts", len(computed), len(texts))
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.touchLocked(user)
+	vectors := make(map[int64]vector, len(items))
+	for i, item := range items {
+		if v, ok := u.vectors[item.ID]; ok && v.textHash == hashes[i] {
+			vectors[item.ID] = v
+		}
+	}
+	for j, values := range computed {
+		i := missing[j]
+		vectors[items[i].ID] = vector{textHash: hashes[i], values: values}
+	}
+	u.vectors = vectors
+	if embedErr != nil {
+		return nil, embedErr
+	}
+
+	result := make(map[int64][]float32, len(vectors))
+	for id, v := range vectors {
+		result[id] = v.values
+	}
+	return result, nil
+}
+
+// Embed embeds a single text, such as a search query, without caching it.
+func (c *VectorCache) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := c.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("embedder returned %d vectors for 1 text", len(vectors))
+	}
+	return vectors[0], nil
+}
+
+// Len returns the number of users with cached vectors.
+func (c *VectorCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.users)
+}
+
+// touchLocked returns the entry of user, creating it if needed, marks it as
+// most recently used and evicts other users beyond maxUsers.
+func (c *VectorCache) touchLocked(user string) *userVectors {
+	c.clock++
+	u, ok := c.users[user]
+	if !ok {
+		u = &userVectors{vectors: make(map[int64]vector)}
+		c.users[user] = u
+	}
+	u.lastUse = c.clock
+
+	for len(c.users) > c.maxUsers {
+		var oldest string
+		var oldestUse uint64
+		for name, e := range c.users {
+			if oldest == "" || e.lastUse < oldestUse {
+				oldest, oldestUse = name, e.lastUse
+			}
+		}
+		delete(c.users, oldest)
+	}
+	return u
+}
+
+func textHash(text string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	return h.Sum64()
+}
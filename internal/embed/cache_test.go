@@ -0,0 +1,99 @@
+package embed
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// countingEmbedder records the texts it embeds.
+type countingEmbedder struct {
+	texts []string
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.texts = append(c.texts, texts...)
+	return NewLocal(8).Embed(ctx, texts)
+}
+
+func TestVectorCacheEmbedsOnlyChanges(t *testing.T) {
+	e := &countingEmbedder{}
+	c := NewVectorCache(e, 4)
+	ctx := context.Background()
+
+	vecs, err := c.Vectors(ctx, "user", []Item{{ID: 1, Text: "first"}, {ID: 2, Text: "second"}, {ID: 3}})
+	if err != nil {
+		t.Fatalf("Vectors() error: %v", err)
+	}
+	if len(vecs) != 2 || vecs[3] != nil {
+		t.Fatalf("expected vectors for the two non-empty items, got %d", len(vecs))
+	}
+
+	e.texts = nil
+	vecs, _ = c.Vectors(ctx, "user", []Item{{ID: 1, Text: "first"}, {ID: 2, Text: "second, edited"}, {ID: 4, Text: "new"}})
+	if !slices.Equal(e.texts, []string{"second, edited", "new"}) {
+		t.Errorf("embedded %q, want only the edited and the new item", e.texts)
+	}
+	if len(vecs) != 3 {
+		t.Errorf("got %d vectors, want 3", len(vecs))
+	}
+
+	e.texts = nil
+	vecs, _ = c.Vectors(ctx, "user", []Item{{ID: 1, Text: "first"}})
+	if len(e.texts) != 0 || len(vecs) != 1 {
+		t.Errorf("removing items embedded %q and returned %d vectors", e.texts, len(vecs))
+	}
+}
+
+// failingEmbedder embeds only the first n texts and fails after them.
+type failingEmbedder struct {
+	countingEmbedder
+	n int
+}
+
+func (f *failingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) <= f.n {
+		return f.countingEmbedder.Embed(ctx, texts)
+	}
+	vectors, _ := f.countingEmbedder.Embed(ctx, texts[:f.n])
+	return vectors, errors.New("embedding failed")
+}
+
+func TestVectorCacheKeepsPartialResults(t *testing.T) {
+	e := &failingEmbedder{n: 1}
+	c := NewVectorCache(e, 4)
+	ctx := context.Background()
+	items := []Item{{ID: 1, Text: "first"}, {ID: 2, Text: "second"}}
+
+	if _, err := c.Vectors(ctx, "user", items); err == nil {
+		t.Fatal("expected the embedding error")
+	}
+
+	e.texts = nil
+	vecs, err := c.Vectors(ctx, "user", items)
+	if err != nil {
+		t.Fatalf("Vectors() error: %v", err)
+	}
+	if !slices.Equal(e.texts, []string{"second"}) {
+		t.Errorf("retry embedded %q, want only the missing item", e.texts)
+	}
+	if len(vecs) != 2 {
+		t.Errorf("got %d vectors, want 2", len(vecs))
+	}
+}
+
+func TestVectorCachePerUser(t *testing.T) {
+	e := &countingEmbedder{}
+	c := NewVectorCache(e, 1)
+	ctx := context.Background()
+
+	c.Vectors(ctx, "alice", []Item{{ID: 1, Text: "text"}})
+	c.Vectors(ctx, "bob", []Item{{ID: 1, Text: "text"}})
+	if len(e.texts) != 2 {
+		t.Errorf("users must not share vectors, embedded %q", e.texts)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1 after eviction", c.Len())
+	}
+}
@@ -0,0 +1,76 @@
+// Package embed turns text into embedding vectors for semantic search. An
+// Embedder is either the built-in Local embedder, which works offline, or an
+// OpenAI-compatible embeddings endpoint.
+package embed
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Embedder computes embedding vectors. Embed returns one vector per text, in
+// the order of texts, and all vectors of an Embedder have the same length.
+// Along with an error, Embed may return the vectors of a prefix of texts that
+// were embedded before the failure.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 if either is a zero
+// vector or their lengths differ.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// normalize scales v to unit length in place. Zero vectors are left as is.
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+}
+
+// Embedding providers accepted by New.
+const (
+	ProviderLocal  = "local"
+	ProviderOpenAI = "openai"
+)
+
+// New creates the embedder for provider. url, model and apiKey configure the
+// OpenAI provider; empty url and model select DefaultOpenAIURL and
+// DefaultOpenAIModel.
+func New(provider, url, model, apiKey string) (Embedder, error) {
+	switch provider {
+	case "", ProviderLocal:
+		return NewLocal(DefaultLocalDimensions), nil
+	case ProviderOpenAI:
+		if url == "" {
+			url = DefaultOpenAIURL
+		}
+		if model == "" {
+			model = DefaultOpenAIModel
+		}
+		return NewOpenAI(url, model, apiKey), nil
+	}
+	return nil, fmt.Errorf("unknown embeddings provider %q: must be %q or %q", provider, ProviderLocal, ProviderOpenAI)
+}
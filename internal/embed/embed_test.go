@@ -0,0 +1,77 @@
+package embed
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{1, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 1}, 0},
+		{[]float32{1, 1}, []float32{-1, -1}, -1},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+		{[]float32{1}, []float32{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := Cosine(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Cosine(%v, %v) = %f, want %f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLocalDeterministic(t *testing.T) {
+	l := NewLocal(0)
+	first, _ := l.Embed(context.Background(), []string{"Embrace uncertainty"})
+	second, _ := NewLocal(DefaultLocalDimensions).Embed(context.Background(), []string{"Embrace uncertainty"})
+	if !reflect.DeepEqual(first, second) {
+		t.Error("Local embeddings differ between calls")
+	}
+	if len(first[0]) != DefaultLocalDimensions {
+		t.Errorf("vector length = %d, want %d", len(first[0]), DefaultLocalDimensions)
+	}
+}
+
+func TestLocalSimilarity(t *testing.T) {
+	vectors, err := NewLocal(0).Embed(context.Background(), []string{
+		"living with uncertainty",
+		"How to live with uncertain outcomes",
+		"A recipe for sourdough bread",
+	})
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	related := Cosine(vectors[0], vectors[1])
+	unrelated := Cosine(vectors[0], vectors[2])
+	if related <= unrelated {
+		t.Errorf("related similarity %f should exceed unrelated similarity %f", related, unrelated)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, provider := range []string{"", ProviderLocal} {
+		if e, err := New(provider, "", "", ""); err != nil {
+			t.Errorf("New(%q) error: %v", provider, err)
+		} else if _, ok := e.(*Local); !ok {
+			t.Errorf("New(%q) = %T, want *Local", provider, e)
+		}
+	}
+
+	e, err := New(ProviderOpenAI, "", "", "key")
+	if err != nil {
+		t.Fatalf("New(openai) error: %v", err)
+	}
+	o := e.(*OpenAI)
+	if o.baseURL != DefaultOpenAIURL || o.model != DefaultOpenAIModel {
+		t.Errorf("OpenAI defaults = %q/%q", o.baseURL, o.model)
+	}
+
+	if _, err := New("word2vec", "", "", ""); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
@@ -0,0 +1,64 @@
+package embed
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+)
+
+// DefaultLocalDimensions is the vector length of the Local embedder when none
+// is given.
+const DefaultLocalDimensions = 256
+
+// Local is a deterministic embedder that needs no network access. It hashes
+// word stems and character trigrams into a fixed number of dimensions, so
+// texts sharing words or word parts end up close together. It does not know
+// synonyms; use an embeddings endpoint for search by meaning.
+type Local struct {
+	dims int
+}
+
+// NewLocal creates a Local embedder producing vectors of dims dimensions, or
+// DefaultLocalDimensions if dims is not positive.
+func NewLocal(dims int) *Local {
+	if dims <= 0 {
+		dims = DefaultLocalDimensions
+	}
+	return &Local{dims: dims}
+}
+
+// Embed implements Embedder. It never fails.
+func (l *Local) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = l.embed(text)
+	}
+	return vectors, nil
+}
+
+func (l *Local) embed(text string) []float32 {
+	v := make([]float32, l.dims)
+	for _, term := range search.Tokenize(text) {
+		stem := search.Stem(term)
+		l.add(v, "w:"+stem, 1)
+		padded := []rune(" " + stem + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			l.add(v, "g:"+string(padded[i:i+3]), 0.5)
+		}
+	}
+	normalize(v)
+	return v
+}
+
+// add adds weight to the dimension feature hashes to, with a sign taken from
+// the hash so that collisions cancel out rather than pile up.
+func (l *Local) add(v []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum&1 == 1 {
+		weight = -weight
+	}
+	v[(sum>>1)%uint64(l.dims)] += weight
+}
@@ -0,0 +1,133 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Defaults of the OpenAI embedder.
+const (
+	DefaultOpenAIURL   = "https://api.openai.com/v1"
+	DefaultOpenAIModel = "text-embedding-3-small"
+
+	openAIBatchSize = 96
+	openAITimeout   = 60 * time.Second
+)
+
+// OpenAI computes embeddings with an OpenAI-compatible embeddings endpoint,
+// which many hosted and local model servers (e.g. Ollama, vLLM) provide.
+type OpenAI struct {
+	httpClient *http.Client
+	baseURL    string
+	model      string
+	apiKey     string
+}
+
+// NewOpenAI creates an embedder posting to baseURL + "/embeddings", e.g.
+// https://api.openai.com/v1. apiKey may be empty for endpoints without
+// authentication.
+func NewOpenAI(baseURL, model, apiKey string) *OpenAI {
+	return &OpenAI{
+		httpClient: &http.Client{Timeout: openAITimeout},
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		model:      model,
+		apiKey:     apiKey,
+	}
+}
+
+type embeddingsRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingsResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Embed implements Embedder, sending texts in batches. When a batch fails,
+// the vectors of the batches before it are returned with the error.
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += openAIBatchSize {
+		batch := texts[start:min(start+openAIBatchSize, len(texts))]
+		v, err := o.embedBatch(ctx, batch)
+		if err != nil {
+			return vectors, err
+		}
+		vectors = append(vectors, v...)
+	}
+	return vectors, nil
+}
+
+func (o *OpenAI) embedBatch(ctx context.Context, texts []string) (_ [][]float32, err error) {
+	ctx, span := telemetry.Start(ctx, "embeddings.request",
+		attribute.String("embeddings.model", o.model),
+		attribute.Int("embeddings.inputs", len(texts)),
+	)
+	defer func() { telemetry.End(span, err) }()
+
+	body, err := json.Marshal(embeddingsRequest{Model: o.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embeddings request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings response: %w", err)
+	}
+	var result embeddingsResponse
+	if err := json.Unmarshal(data, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode embeddings response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg := strings.TrimSpace(string(data))
+		if result.Error != nil {
+			msg = result.Error.Message
+		}
+		return nil, fmt.Errorf("embeddings endpoint returned %d: %s", resp.StatusCode, msg)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings response has unexpected index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("embeddings response is missing input %d", i)
+		}
+	}
+	return vectors, nil
+}
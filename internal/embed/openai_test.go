@@ -0,0 +1,108 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeEmbeddingsServer serves an OpenAI-compatible embeddings endpoint that
+// returns the length of each input as a one-dimensional vector, in reverse
+// order to check that indexes are honored.
+func fakeEmbeddingsServer(t *testing.T, requests *[]embeddingsRequest) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+			return
+		}
+		var req embeddingsRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		*requests = append(*requests, req)
+
+		var resp embeddingsResponse
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{i, []float32{float32(len(req.Input[i]))}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	var requests []embeddingsRequest
+	ts := fakeEmbeddingsServer(t, &requests)
+
+	texts := make([]string, openAIBatchSize+5)
+	for i := range texts {
+		texts[i] = strings.Repeat("x", i+1)
+	}
+	vectors, err := NewOpenAI(ts.URL+"/v1/", "test-model", "test-key").Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+
+	if len(requests) != 2 || len(requests[0].Input) != openAIBatchSize || requests[0].Model != "test-model" {
+		t.Fatalf("unexpected batching: %d requests", len(requests))
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("got %d vectors, want %d", len(vectors), len(texts))
+	}
+	for i, v := range vectors {
+		if v[0] != float32(i+1) {
+			t.Fatalf("vector %d = %v, want [%d]", i, v, i+1)
+		}
+	}
+}
+
+func TestOpenAIError(t *testing.T) {
+	var requests []embeddingsRequest
+	ts := fakeEmbeddingsServer(t, &requests)
+
+	_, err := NewOpenAI(ts.URL+"/v1", "test-model", "wrong").Embed(context.Background(), []string{"text"})
+	if err == nil || !strings.Contains(err.Error(), "401") || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("error = %v, want status and upstream message", err)
+	}
+}
+
+func TestOpenAIEmbedKeepsCompletedBatches(t *testing.T) {
+	var requests []embeddingsRequest
+	ts := fakeEmbeddingsServer(t, &requests)
+	o := NewOpenAI(ts.URL+"/v1", "test-model", "test-key")
+
+	texts := make([]string, openAIBatchSize+1)
+	for i := range texts {
+		texts[i] = "x"
+	}
+	// Fail the second batch by making its request unauthorized.
+	o.httpClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if len(requests) > 0 {
+			r.Header.Del("Authorization")
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	vectors, err := o.Embed(context.Background(), texts)
+	if err == nil {
+		t.Fatal("expected an error for the failed batch")
+	}
+	if len(vectors) != openAIBatchSize {
+		t.Errorf("got %d vectors, want the %d of the first batch", len(vectors), openAIBatchSize)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
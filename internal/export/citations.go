@@ -0,0 +1,360 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Citation formats.
+const (
+	FormatBibTeX  = "bibtex"
+	FormatCSLJSON = "csl-json"
+)
+
+// Reference is the bibliographic metadata of a Readwise source or Reader
+// document, the common input of the citation formats.
+type Reference struct {
+	// Key is the citation key, set by AssignCitationKeys.
+	Key string
+	// ID is the Readwise source ID or the Reader document ID.
+	ID        string
+	Title     string
+	Authors   []string
+	Category  string
+	URL       string
+	SiteName  string
+	Published time.Time
+	Tags      []string
+}
+
+// entryType is the BibTeX (biblatex) entry type and CSL item type of a
+// category.
+type entryType struct {
+	bibtex, csl string
+}
+
+// entryTypes maps Readwise and Reader categories to entry types. Categories
+// not listed become @misc and "document".
+var entryTypes = map[string]entryType{
+	"books":    {"book", "book"},
+	"epub":     {"book", "book"},
+	"articles": {"online", "webpage"},
+	"article":  {"online", "webpage"},
+	"rss":      {"online", "webpage"},
+	"email":    {"online", "webpage"},
+	"tweets":   {"online", "post"},
+	"tweet":    {"online", "post"},
+	"podcasts": {"misc", "broadcast"},
+	"video":    {"misc", "motion_picture"},
+}
+
+// keyStopWords are skipped when picking the title word of a citation key.
+var keyStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "of": true,
+	"on": true, "in": true, "to": true, "for": true, "how": true, "why": true,
+}
+
+// SourceReference returns the reference of a Readwise source.
+func SourceReference(s *types.ExportSource) Reference {
+	ref := Reference{
+		ID:       strconv.FormatInt(s.UserBookID, 10),
+		Title:    s.Title,
+		Authors:  splitAuthors(s.Author),
+		Category: s.Category,
+		URL:      s.SourceURL,
+	}
+	for _, t := range s.BookTags {
+		ref.Tags = append(ref.Tags, t.Name)
+	}
+	return ref
+}
+
+// DocumentReference returns the reference of a Reader document. The
+// published date is kept when Reader reports it as a date or timestamp.
+func DocumentReference(d *types.Document) Reference {
+	ref := Reference{
+		ID:       d.ID,
+		Title:    d.Title,
+		Authors:  splitAuthors(d.Author),
+		Category: d.Category,
+		URL:      d.SourceURL,
+		SiteName: d.SiteName,
+	}
+	if ref.URL == "" {
+		ref.URL = d.URL
+	}
+	if len(d.PublishedDate) >= len(time.DateOnly) {
+		if t, err := time.Parse(time.DateOnly, d.PublishedDate[:len(time.DateOnly)]); err == nil {
+			ref.Published = t
+		}
+	}
+	for key, t := range d.Tags {
+		name := t.Name
+		if name == "" {
+			name = key
+		}
+		ref.Tags = append(ref.Tags, name)
+	}
+	sort.Strings(ref.Tags)
+	return ref
+}
+
+// splitAuthors splits an author string on "and", ";" and on commas between
+// full names. "Newport, Cal" stays a single author because one side of the
+// comma has no space in it.
+func splitAuthors(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	var authors []string
+	for _, part := range strings.FieldsFunc(strings.ReplaceAll(s, " and ", ";"), func(r rune) bool { return r == ';' }) {
+		names := strings.Split(part, ",")
+		fullNames := len(names) > 1
+		for _, n := range names {
+			if !strings.Contains(strings.TrimSpace(n), " ") {
+				fullNames = false
+			}
+		}
+		if !fullNames {
+			names = []string{part}
+		}
+		for _, n := range names {
+			if n = strings.TrimSpace(n); n != "" {
+				authors = append(authors, n)
+			}
+		}
+	}
+	return authors
+}
+
+// AssignCitationKeys sets the key of each reference to the first author's
+// family name, the year and the first significant title word, such as
+// newport2016deep. The first reference with a key keeps it; later ones get a
+// letter suffix derived from their ID, such as newport2016deepqkm, so their
+// keys only change when they take over the plain key from a removed first
+// reference. Assigning keys over the whole library rather than a selection keeps
+// them independent of the selection.
+func AssignCitationKeys(refs []Reference) {
+	bases := make([]string, len(refs))
+	used := make(map[string]bool, len(refs))
+	for i := range refs {
+		bases[i] = baseKey(&refs[i])
+		used[bases[i]] = true
+	}
+	first := make(map[string]bool, len(refs))
+	for i := range refs {
+		key := bases[i]
+		if first[key] {
+			key = idSuffixedKey(key, refs[i].ID, used)
+		}
+		first[bases[i]] = true
+		used[key] = true
+		refs[i].Key = key
+	}
+}
+
+// idSuffixedKey appends letters derived from a hash of id to base, at least
+// three and more if the key is already used.
+func idSuffixedKey(base, id string, used map[string]bool) string {
+	h := sha256.Sum256([]byte(id))
+	key := base
+	for i := 0; ; i++ {
+		key += string(rune('a' + h[i%len(h)]%26))
+		if i >= 2 && !used[key] {
+			return key
+		}
+	}
+}
+
+func baseKey(r *Reference) string {
+	var b strings.Builder
+	if len(r.Authors) > 0 {
+		family, _ := splitName(r.Authors[0])
+		b.WriteString(keyWord(family))
+	}
+	if !r.Published.IsZero() {
+		b.WriteString(strconv.Itoa(r.Published.Year()))
+	}
+	for _, w := range strings.Fields(r.Title) {
+		if w = keyWord(w); w != "" && !keyStopWords[w] {
+			b.WriteString(w)
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "ref" + keyWord(r.ID)
+	}
+	return b.String()
+}
+
+// keyWord lowercases s and keeps only ASCII letters and digits, dropping
+// accents first so that "Müller" becomes "muller".
+func keyWord(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// splitName splits a personal name into family and given name. A name
+// without spaces, such as a website, is returned as the family name alone.
+func splitName(name string) (family, given string) {
+	if f, g, ok := strings.Cut(name, ","); ok {
+		return strings.TrimSpace(f), strings.TrimSpace(g)
+	}
+	if i := strings.LastIndex(name, " "); i >= 0 {
+		return name[i+1:], name[:i]
+	}
+	return name, ""
+}
+
+// WriteCitations writes refs as a BibTeX file or as a CSL-JSON array.
+func WriteCitations(w io.Writer, refs []Reference, format string) error {
+	switch format {
+	case FormatBibTeX, "":
+		return WriteBibTeX(w, refs)
+	case FormatCSLJSON:
+		return WriteCSLJSON(w, refs)
+	}
+	return fmt.Errorf("unsupported citation format %q", format)
+}
+
+// WriteBibTeX writes refs as biblatex entries.
+func WriteBibTeX(w io.Writer, refs []Reference) error {
+	for i, r := range refs {
+		var b strings.Builder
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		typ := entryTypeOf(r.Category).bibtex
+		fmt.Fprintf(&b, "@%s{%s,\n", typ, r.Key)
+		field := func(name, value string) {
+			if value != "" {
+				fmt.Fprintf(&b, "  %s = {%s},\n", name, value)
+			}
+		}
+		field("title", bibtexEscape(r.Title))
+		var authors []string
+		for _, a := range r.Authors {
+			if !strings.Contains(a, " ") && !strings.Contains(a, ",") {
+				// Protect single-word authors such as websites from
+				// being read as a family name to abbreviate.
+				a = "{" + bibtexEscape(a) + "}"
+			} else {
+				a = bibtexEscape(a)
+			}
+			authors = append(authors, a)
+		}
+		field("author", strings.Join(authors, " and "))
+		if !r.Published.IsZero() {
+			field("date", r.Published.Format(time.DateOnly))
+		}
+		if typ == "online" {
+			field("organization", bibtexEscape(r.SiteName))
+		} else {
+			field("publisher", bibtexEscape(r.SiteName))
+		}
+		field("url", bibtexURLEscape(r.URL))
+		field("keywords", bibtexEscape(strings.Join(r.Tags, ", ")))
+		b.WriteString("}\n")
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var bibtexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`, `}`, `\}`,
+	`&`, `\&`, `%`, `\%`, `$`, `\$`, `#`, `\#`, `_`, `\_`,
+	`~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`,
+)
+
+func bibtexEscape(s string) string {
+	return bibtexReplacer.Replace(s)
+}
+
+// bibtexURLReplacer keeps URLs, which are read verbatim, from unbalancing the
+// field braces: braces are percent-encoded and percent signs escaped.
+var bibtexURLReplacer = strings.NewReplacer(`{`, `\%7B`, `}`, `\%7D`, `%`, `\%`)
+
+func bibtexURLEscape(s string) string {
+	return bibtexURLReplacer.Replace(s)
+}
+
+// cslItem is a CSL-JSON item.
+type cslItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []cslName `json:"author,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+	Keyword        string    `json:"keyword,omitempty"`
+}
+
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// WriteCSLJSON writes refs as a CSL-JSON array, the format read by Zotero,
+// Pandoc and citeproc processors.
+func WriteCSLJSON(w io.Writer, refs []Reference) error {
+	items := make([]cslItem, 0, len(refs))
+	for _, r := range refs {
+		item := cslItem{
+			ID:             r.Key,
+			Type:           entryTypeOf(r.Category).csl,
+			Title:          r.Title,
+			ContainerTitle: r.SiteName,
+			URL:            r.URL,
+			Keyword:        strings.Join(r.Tags, ", "),
+		}
+		for _, a := range r.Authors {
+			family, given := splitName(a)
+			if given == "" {
+				item.Author = append(item.Author, cslName{Literal: a})
+			} else {
+				item.Author = append(item.Author, cslName{Family: family, Given: given})
+			}
+		}
+		if !r.Published.IsZero() {
+			p := r.Published
+			item.Issued = &cslDate{DateParts: [][]int{{p.Year(), int(p.Month()), p.Day()}}}
+		}
+		items = append(items, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+func entryTypeOf(category string) entryType {
+	if t, ok := entryTypes[strings.ToLower(category)]; ok {
+		return t
+	}
+	return entryType{"misc", "document"}
+}
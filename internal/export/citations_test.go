@@ -0,0 +1,170 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func citationReferences() []Reference {
+	refs := []Reference{
+		SourceReference(&types.ExportSource{UserBookID: 1, Title: "Deep Work", Author: "Cal Newport", Category: "books", BookTags: []types.Tag{{Name: "focus"}}}),
+		DocumentReference(&types.Document{
+			ID: "01abc", Title: "The 100% Solution & Co_", Author: "Anna Müller and Ben Ortiz", Category: "article",
+			SourceURL: "https://example.com/solution", SiteName: "Example", PublishedDate: "2021-04-05",
+			Tags: map[string]types.Tag{"b": {Name: "research"}, "a": {Name: "method"}},
+		}),
+		SourceReference(&types.ExportSource{UserBookID: 2, Title: "Deep Work Revisited", Author: "Newport, Cal", Category: "books"}),
+		SourceReference(&types.ExportSource{UserBookID: 3, Title: "Thread", Author: "nytimes.com", Category: "tweets", SourceURL: "https://x.com/1"}),
+	}
+	AssignCitationKeys(refs)
+	return refs
+}
+
+func TestAssignCitationKeys(t *testing.T) {
+	var keys []string
+	for _, r := range citationReferences() {
+		keys = append(keys, r.Key)
+	}
+	want := []string{"newportdeep", "muller2021100", "newportdeepelq", "nytimescomthread"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+
+	refs := make([]Reference, 28)
+	AssignCitationKeys(refs)
+	seen := make(map[string]bool)
+	for _, r := range refs {
+		if seen[r.Key] {
+			t.Errorf("duplicate key %q for empty references", r.Key)
+		}
+		seen[r.Key] = true
+	}
+	if refs[0].Key != "ref" {
+		t.Errorf("key of first empty reference = %q, want %q", refs[0].Key, "ref")
+	}
+}
+
+func TestAssignCitationKeysStableWhenDuplicateRemoved(t *testing.T) {
+	refs := []Reference{
+		{ID: "1", Title: "Deep Work", Authors: []string{"Cal Newport"}},
+		{ID: "2", Title: "Deep Work", Authors: []string{"Cal Newport"}},
+		{ID: "3", Title: "Deep Work", Authors: []string{"Cal Newport"}},
+	}
+	AssignCitationKeys(refs)
+	third := refs[2].Key
+
+	remaining := []Reference{refs[0], refs[2]}
+	AssignCitationKeys(remaining)
+	if remaining[1].Key != third {
+		t.Errorf("key after removing a duplicate = %q, want %q", remaining[1].Key, third)
+	}
+}
+
+func TestSplitAuthors(t *testing.T) {
+	tests := map[string][]string{
+		"":                      nil,
+		"Cal Newport":           {"Cal Newport"},
+		"Newport, Cal":          {"Newport, Cal"},
+		"Cal Newport, Jane Doe": {"Cal Newport", "Jane Doe"},
+		"A. Smith and B. Jones": {"A. Smith", "B. Jones"},
+		"Smith, A.; Jones, B.":  {"Smith, A.", "Jones, B."},
+		"nytimes.com":           {"nytimes.com"},
+	}
+	for in, want := range tests {
+		if got := splitAuthors(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitAuthors(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteBibTeX(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCitations(&b, citationReferences()[:2], FormatBibTeX); err != nil {
+		t.Fatalf("WriteCitations() error: %v", err)
+	}
+	want := `@book{newportdeep,
+  title = {Deep Work},
+  author = {Cal Newport},
+  keywords = {focus},
+}
+
+@online{muller2021100,
+  title = {The 100\% Solution \& Co\_},
+  author = {Anna Müller and Ben Ortiz},
+  date = {2021-04-05},
+  organization = {Example},
+  url = {https://example.com/solution},
+  keywords = {method, research},
+}
+`
+	if b.String() != want {
+		t.Errorf("BibTeX =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	WriteBibTeX(&b, citationReferences()[3:])
+	if !strings.HasPrefix(b.String(), "@online{nytimescomthread,") || !strings.Contains(b.String(), "author = {{nytimes.com}},") {
+		t.Errorf("single-word author not protected:\n%s", b.String())
+	}
+}
+
+func TestWriteBibTeXEscapesURL(t *testing.T) {
+	var b bytes.Buffer
+	WriteBibTeX(&b, []Reference{{Key: "k", Title: "T", URL: "https://example.com/a%20b?q={x}"}})
+	if want := `url = {https://example.com/a\%20b?q=\%7Bx\%7D},`; !strings.Contains(b.String(), want) {
+		t.Errorf("BibTeX =\n%s\nwant line %s", b.String(), want)
+	}
+}
+
+func TestWriteCSLJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCitations(&b, citationReferences(), FormatCSLJSON); err != nil {
+		t.Fatalf("WriteCitations() error: %v", err)
+	}
+	var items []cslItem
+	if err := json.Unmarshal(b.Bytes(), &items); err != nil {
+		t.Fatalf("output is not a JSON array: %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("got %d items, want 4", len(items))
+	}
+
+	want := cslItem{
+		ID:             "muller2021100",
+		Type:           "webpage",
+		Title:          "The 100% Solution & Co_",
+		Author:         []cslName{{Family: "Müller", Given: "Anna"}, {Family: "Ortiz", Given: "Ben"}},
+		Issued:         &cslDate{DateParts: [][]int{{2021, 4, 5}}},
+		ContainerTitle: "Example",
+		URL:            "https://example.com/solution",
+		Keyword:        "method, research",
+	}
+	if !reflect.DeepEqual(items[1], want) {
+		t.Errorf("item =\n%+v\nwant\n%+v", items[1], want)
+	}
+	if items[0].Type != "book" || items[3].Type != "post" {
+		t.Errorf("types = %q, %q, want book and post", items[0].Type, items[3].Type)
+	}
+	if got := items[2].Author; len(got) != 1 || got[0].Family != "Newport" || got[0].Given != "Cal" {
+		t.Errorf("inverted author = %+v", got)
+	}
+	if got := items[3].Author; len(got) != 1 || got[0].Literal != "nytimes.com" {
+		t.Errorf("website author = %+v, want a literal name", got)
+	}
+}
+
+func TestDocumentReferencePublishedTimestamp(t *testing.T) {
+	ref := DocumentReference(&types.Document{ID: "x", PublishedDate: "2020-02-29T10:00:00Z"})
+	if want := time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC); !ref.Published.Equal(want) {
+		t.Errorf("Published = %v, want %v", ref.Published, want)
+	}
+	if ref := DocumentReference(&types.Document{ID: "x", PublishedDate: "unknown"}); !ref.Published.IsZero() {
+		t.Errorf("Published = %v, want zero for an unparsable date", ref.Published)
+	}
+}
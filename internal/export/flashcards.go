@@ -0,0 +1,182 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// Flashcard file formats.
+const (
+	FormatTSV = "tsv"
+	FormatCSV = "csv"
+)
+
+// clozePattern matches Anki cloze deletions such as {{c1::answer}} and
+// {{c2::answer::hint}}.
+var clozePattern = regexp.MustCompile(`\{\{c\d+::(.*?)(?:::(.*?))?\}\}`)
+
+// FlashcardFilter selects the highlights turned into flashcards. Zero values
+// do not restrict.
+type FlashcardFilter struct {
+	SourceID int64
+	// Tag matches highlights tagged with it or belonging to a source tagged
+	// with it, ignoring case.
+	Tag string
+	// After and Before bound highlighted_at; After is inclusive and Before
+	// exclusive. Highlights without a date are left out when either is set.
+	After, Before time.Time
+}
+
+// Flashcard is a card derived from a highlight. GUID is derived from the
+// highlight ID only, so importing the same highlight again updates the card.
+type Flashcard struct {
+	HighlightID int64
+	GUID        string
+	Front       string
+	Back        string
+	Tags        []string
+}
+
+// Flashcards turns the highlights in sources matching filter into cards,
+// ordered by highlight ID. A highlight containing cloze deletions becomes a
+// card whose front hides them and whose back reveals them; otherwise its
+// note, if any, becomes the back. Highlights with neither make no card
+// because there is nothing to recall. Cards are tagged with the source
+// title and author and the highlight's own tags.
+func Flashcards(sources []types.ExportSource, filter FlashcardFilter) []Flashcard {
+	var cards []Flashcard
+	for _, s := range sources {
+		if filter.SourceID != 0 && s.UserBookID != filter.SourceID {
+			continue
+		}
+		for _, h := range s.Highlights {
+			if !filter.matches(&s, &h) {
+				continue
+			}
+			card, ok := flashcard(&s, &h)
+			if !ok {
+				continue
+			}
+			cards = append(cards, card)
+		}
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].HighlightID < cards[j].HighlightID
+	})
+	return cards
+}
+
+func (f FlashcardFilter) matches(s *types.ExportSource, h *types.Highlight) bool {
+	if f.Tag != "" && !hasTag(h.Tags, f.Tag) && !hasTag(s.BookTags, f.Tag) {
+		return false
+	}
+	if f.After.IsZero() && f.Before.IsZero() {
+		return true
+	}
+	at := h.HighlightedAt
+	if at.IsZero() {
+		return false
+	}
+	return (f.After.IsZero() || !at.Before(f.After)) && (f.Before.IsZero() || at.Before(f.Before))
+}
+
+func flashcard(s *types.ExportSource, h *types.Highlight) (Flashcard, bool) {
+	card := Flashcard{HighlightID: h.ID, GUID: fmt.Sprintf("readwise-%d", h.ID)}
+	switch {
+	case clozePattern.MatchString(h.Text):
+		card.Front = clozePattern.ReplaceAllStringFunc(h.Text, func(m string) string {
+			if hint := clozePattern.FindStringSubmatch(m)[2]; hint != "" {
+				return "[" + hint + "]"
+			}
+			return "[...]"
+		})
+		card.Back = clozePattern.ReplaceAllString(h.Text, "$1")
+		if h.Note != "" {
+			card.Back += "\n\n" + h.Note
+		}
+	case h.Note != "":
+		card.Front, card.Back = h.Text, h.Note
+	default:
+		return Flashcard{}, false
+	}
+
+	if t := ankiTag(s.Title); t != "" {
+		card.Tags = append(card.Tags, "source::"+t)
+	}
+	if t := ankiTag(s.Author); t != "" {
+		card.Tags = append(card.Tags, "author::"+t)
+	}
+	for _, tag := range h.Tags {
+		if t := ankiTag(tag.Name); t != "" {
+			card.Tags = append(card.Tags, t)
+		}
+	}
+	return card, true
+}
+
+// WriteFlashcards writes cards as a text file Anki can import, in TSV or CSV
+// format. Header lines tell Anki the separator and which columns hold the
+// GUID and the tags, so re-importing the file updates existing notes.
+func WriteFlashcards(w io.Writer, cards []Flashcard, format string) error {
+	cw := csv.NewWriter(w)
+	separator := "tab"
+	switch format {
+	case FormatTSV, "":
+		cw.Comma = '\t'
+	case FormatCSV:
+		separator = "comma"
+	default:
+		return fmt.Errorf("unsupported flashcard format %q", format)
+	}
+
+	header := fmt.Sprintf("#separator:%s\n#html:false\n#guid column:1\n#tags column:4\n", separator)
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	for _, c := range cards {
+		if err := cw.Write([]string{c.GUID, c.Front, c.Back, strings.Join(c.Tags, " ")}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ParseDate parses a date filter given as YYYY-MM-DD or as an RFC 3339
+// timestamp. An empty string yields the zero time.
+func ParseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD or an RFC 3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// ankiTag turns a name into a tag without spaces, which separate Anki tags.
+func ankiTag(name string) string {
+	return strings.Join(strings.FieldsFunc(name, unicode.IsSpace), "_")
+}
+
+func hasTag(tags []types.Tag, name string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t.Name, name) {
+			return true
+		}
+	}
+	return false
+}
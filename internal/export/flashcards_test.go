@@ -0,0 +1,105 @@
+package export
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func flashcardSources() []types.ExportSource {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	return []types.ExportSource{
+		{UserBookID: 2, Title: "Deep Work", Author: "Cal Newport", BookTags: []types.Tag{{Name: "focus"}}, Highlights: []types.Highlight{
+			{ID: 30, Text: "The {{c1::deep work}} hypothesis", HighlightedAt: day(3)},
+			{ID: 20, Text: "Attention residue", Note: "What happens when switching tasks?", HighlightedAt: day(2)},
+			{ID: 25, Text: "No note, no cloze", HighlightedAt: day(2)},
+		}},
+		{UserBookID: 1, Title: "Peak", Highlights: []types.Highlight{
+			{ID: 10, Text: "Practice {{c1::deliberately::how?}}, with {{c2::feedback}}", Note: "Ericsson", Tags: []types.Tag{{Name: "study skills"}}, HighlightedAt: day(1)},
+		}},
+	}
+}
+
+func TestFlashcards(t *testing.T) {
+	cards := Flashcards(flashcardSources(), FlashcardFilter{})
+
+	want := []Flashcard{
+		{HighlightID: 10, GUID: "readwise-10", Front: "Practice [how?], with [...]", Back: "Practice deliberately, with feedback\n\nEricsson", Tags: []string{"source::Peak", "study_skills"}},
+		{HighlightID: 20, GUID: "readwise-20", Front: "Attention residue", Back: "What happens when switching tasks?", Tags: []string{"source::Deep_Work", "author::Cal_Newport"}},
+		{HighlightID: 30, GUID: "readwise-30", Front: "The [...] hypothesis", Back: "The deep work hypothesis", Tags: []string{"source::Deep_Work", "author::Cal_Newport"}},
+	}
+	if !reflect.DeepEqual(cards, want) {
+		t.Errorf("Flashcards() =\n%+v\nwant\n%+v", cards, want)
+	}
+}
+
+func TestFlashcardFilter(t *testing.T) {
+	after, _ := ParseDate("2024-03-02")
+	before, _ := ParseDate("2024-03-03")
+	tests := []struct {
+		name   string
+		filter FlashcardFilter
+		want   []int64
+	}{
+		{"source", FlashcardFilter{SourceID: 1}, []int64{10}},
+		{"highlight tag", FlashcardFilter{Tag: "Study Skills"}, []int64{10}},
+		{"source tag", FlashcardFilter{Tag: "focus"}, []int64{20, 30}},
+		{"after", FlashcardFilter{After: after}, []int64{20, 30}},
+		{"date range", FlashcardFilter{After: after, Before: before}, []int64{20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			for _, c := range Flashcards(flashcardSources(), tt.filter) {
+				got = append(got, c.HighlightID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("highlights = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFlashcards(t *testing.T) {
+	cards := Flashcards(flashcardSources(), FlashcardFilter{SourceID: 1})
+
+	var tsv strings.Builder
+	if err := WriteFlashcards(&tsv, cards, FormatTSV); err != nil {
+		t.Fatalf("WriteFlashcards() error: %v", err)
+	}
+	want := "#separator:tab\n#html:false\n#guid column:1\n#tags column:4\n" +
+		"readwise-10\tPractice [how?], with [...]\t\"Practice deliberately, with feedback\n\nEricsson\"\tsource::Peak study_skills\n"
+	if tsv.String() != want {
+		t.Errorf("TSV =\n%q\nwant\n%q", tsv.String(), want)
+	}
+
+	var csv strings.Builder
+	if err := WriteFlashcards(&csv, cards, FormatCSV); err != nil {
+		t.Fatalf("WriteFlashcards() error: %v", err)
+	}
+	if !strings.HasPrefix(csv.String(), "#separator:comma\n") || !strings.Contains(csv.String(), `readwise-10,"Practice [how?], with [...]",`) {
+		t.Errorf("unexpected CSV:\n%s", csv.String())
+	}
+
+	if err := WriteFlashcards(&csv, cards, "xlsx"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	if d, err := ParseDate("2024-03-02"); err != nil || !d.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseDate(date) = %v, %v", d, err)
+	}
+	if d, err := ParseDate("2024-03-02T10:00:00Z"); err != nil || d.Hour() != 10 {
+		t.Errorf("ParseDate(timestamp) = %v, %v", d, err)
+	}
+	if d, err := ParseDate(""); err != nil || !d.IsZero() {
+		t.Errorf("ParseDate(\"\") = %v, %v", d, err)
+	}
+	if _, err := ParseDate("March 2"); err == nil {
+		t.Error("expected an error for an invalid date")
+	}
+}
@@ -0,0 +1,168 @@
+// Package export renders the Readwise highlight export in formats meant for
+// people and other tools rather than for the API.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// DefaultMarkdownTemplate renders a source as YAML frontmatter followed by
+// its highlights as blockquotes. Custom templates receive the same data: one
+// types.ExportSource per execution, with the functions listed in
+// markdownFuncs.
+const DefaultMarkdownTemplate = `---
+title: {{ yaml .Title }}
+author: {{ yaml .Author }}
+category: {{ yaml .Category }}
+{{- with .SourceURL }}
+source_url: {{ yaml . }}
+{{- end }}
+tags: {{ yamlList (tagNames .BookTags) }}
+---
+
+# {{ .Title }}
+{{ range .Highlights }}
+{{ blockquote .Text }}
+{{- with .Note }}
+
+**Note:** {{ . }}
+{{- end }}
+
+{{ with location . }}{{ . }} · {{ end }}[View in Readwise]({{ readwiseURL . }})
+{{ end -}}
+`
+
+// markdownFuncs are the functions available to Markdown templates.
+var markdownFuncs = template.FuncMap{
+	"yaml":        yamlString,
+	"yamlList":    yamlList,
+	"tagNames":    tagNames,
+	"join":        strings.Join,
+	"blockquote":  blockquote,
+	"location":    location,
+	"readwiseURL": readwiseURL,
+}
+
+// Markdown renders export sources with a text/template.
+type Markdown struct {
+	tmpl *template.Template
+}
+
+// NewMarkdown parses a Markdown template. An empty text selects
+// DefaultMarkdownTemplate.
+func NewMarkdown(text string) (*Markdown, error) {
+	if text == "" {
+		text = DefaultMarkdownTemplate
+	}
+	tmpl, err := template.New("markdown").Funcs(markdownFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Markdown{tmpl: tmpl}, nil
+}
+
+// LoadMarkdown reads and parses the Markdown template in path, or returns the
+// default template if path is empty.
+func LoadMarkdown(path string) (*Markdown, error) {
+	if path == "" {
+		return NewMarkdown("")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewMarkdown(string(data))
+}
+
+// Render writes one section per source to w, separated by blank lines.
+func (m *Markdown) Render(w io.Writer, sources []types.ExportSource) error {
+	for i := range sources {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := m.RenderSource(w, &sources[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderSource writes the section of a single source to w.
+func (m *Markdown) RenderSource(w io.Writer, source *types.ExportSource) error {
+	if err := m.tmpl.Execute(w, source); err != nil {
+		return fmt.Errorf("rendering %q: %w", source.Title, err)
+	}
+	return nil
+}
+
+// yamlString quotes s as a YAML double-quoted scalar. JSON strings are valid
+// YAML, which saves escaping rules of our own.
+func yamlString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// yamlList formats values as a YAML flow sequence of quoted strings.
+func yamlList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = yamlString(v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func tagNames(tags []types.Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// blockquote prefixes every line of text with "> ", using ">" alone for
+// empty lines so paragraphs stay inside the quote.
+func blockquote(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// location describes where a highlight is in its source, or returns "" when
+// the location carries no meaning for readers (such as an ordinal).
+func location(h types.Highlight) string {
+	switch h.LocationType {
+	case "page":
+		return fmt.Sprintf("Page %d", h.Location)
+	case "location":
+		return fmt.Sprintf("Location %d", h.Location)
+	case "time_offset":
+		d := time.Duration(h.Location) * time.Second
+		return fmt.Sprintf("%d:%02d", int(d.Minutes()), h.Location%60)
+	default:
+		return ""
+	}
+}
+
+// readwiseURL returns the link to a highlight in Readwise.
+func readwiseURL(h types.Highlight) string {
+	if h.ReadwiseURL != "" {
+		return h.ReadwiseURL
+	}
+	return fmt.Sprintf("https://readwise.io/open/%d", h.ID)
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func testSources() []types.ExportSource {
+	return []types.ExportSource{
+		{
+			UserBookID: 1,
+			Title:      "Deep Work",
+			Author:     "Cal Newport",
+			Category:   "books",
+			SourceURL:  "https://example.com/deep-work",
+			BookTags:   []types.Tag{{Name: "focus"}, {Name: "work \"deep\""}},
+			Highlights: []types.Highlight{
+				{ID: 10, Text: "Clarity about what matters\n\nprovides clarity about what does not.", Note: "Key idea", Location: 42, LocationType: "location", ReadwiseURL: "https://readwise.io/open/10"},
+				{ID: 11, Text: "Shallow work is inevitable.", Location: 3, LocationType: "order"},
+			},
+		},
+		{UserBookID: 2, Title: "Podcast", Category: "podcasts", Highlights: []types.Highlight{
+			{ID: 20, Text: "Listen closely.", Location: 754, LocationType: "time_offset"},
+		}},
+	}
+}
+
+func TestMarkdownDefaultTemplate(t *testing.T) {
+	m, err := NewMarkdown("")
+	if err != nil {
+		t.Fatalf("NewMarkdown() error: %v", err)
+	}
+	var b strings.Builder
+	if err := m.Render(&b, testSources()); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	want := `---
+title: "Deep Work"
+author: "Cal Newport"
+category: "books"
+source_url: "https://example.com/deep-work"
+tags: ["focus", "work \"deep\""]
+---
+
+# Deep Work
+
+> Clarity about what matters
+>
+> provides clarity about what does not.
+
+**Note:** Key idea
+
+Location 42 · [View in Readwise](https://readwise.io/open/10)
+
+> Shallow work is inevitable.
+
+[View in Readwise](https://readwise.io/open/11)
+
+---
+title: "Podcast"
+author: ""
+category: "podcasts"
+tags: []
+---
+
+# Podcast
+
+> Listen closely.
+
+12:34 · [View in Readwise](https://readwise.io/open/20)
+`
+	if got := b.String(); got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLoadMarkdownCustomTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.tmpl")
+	tmpl := "## {{ .Title }} ({{ join (tagNames .BookTags) \", \" }})\n{{ range .Highlights }}- {{ .Text }}\n{{ end }}"
+	if err := os.WriteFile(path, []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadMarkdown(path)
+	if err != nil {
+		t.Fatalf("LoadMarkdown() error: %v", err)
+	}
+	var b strings.Builder
+	if err := m.Render(&b, testSources()[:1]); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	want := "## Deep Work (focus, work \"deep\")\n- Clarity about what matters\n\nprovides clarity about what does not.\n- Shallow work is inevitable.\n"
+	if b.String() != want {
+		t.Errorf("Render() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestLoadMarkdownErrors(t *testing.T) {
+	if _, err := LoadMarkdown(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("expected an error for a missing template file")
+	}
+	if _, err := NewMarkdown("{{ .Title "); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+
+	m, err := NewMarkdown("{{ .NoSuchField }}")
+	if err != nil {
+		t.Fatalf("NewMarkdown() error: %v", err)
+	}
+	var b strings.Builder
+	if err := m.Render(&b, testSources()); err == nil || !strings.Contains(err.Error(), "Deep Work") {
+		t.Errorf("Render() error = %v, want an error naming the source", err)
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// NDJSONContentType is the media type of newline-delimited JSON.
+const NDJSONContentType = "application/x-ndjson"
+
+// NDJSON record units: one line per source with its highlights, or one line
+// per highlight with the fields of its source.
+const (
+	UnitSources    = "sources"
+	UnitHighlights = "highlights"
+)
+
+// NDJSONHighlight is the record written per highlight. It carries the
+// fields of the source needed to make sense of the highlight on its own.
+type NDJSONHighlight struct {
+	types.Highlight
+	SourceTitle    string `json:"source_title"`
+	SourceAuthor   string `json:"source_author"`
+	SourceCategory string `json:"source_category"`
+	SourceURL      string `json:"source_url,omitempty"`
+}
+
+// NDJSONWriter writes the highlight export as newline-delimited JSON. Each
+// call to Write encodes one page and keeps nothing, so an export of any size
+// can be streamed page by page.
+type NDJSONWriter struct {
+	enc  *json.Encoder
+	unit string
+}
+
+// NewNDJSONWriter returns a writer emitting one record per unit to w. An
+// empty unit selects UnitSources.
+func NewNDJSONWriter(w io.Writer, unit string) (*NDJSONWriter, error) {
+	switch unit {
+	case "":
+		unit = UnitSources
+	case UnitSources, UnitHighlights:
+	default:
+		return nil, fmt.Errorf("unsupported NDJSON unit %q: must be %q or %q", unit, UnitSources, UnitHighlights)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &NDJSONWriter{enc: enc, unit: unit}, nil
+}
+
+// Write encodes sources, one line per source or per highlight.
+func (nw *NDJSONWriter) Write(sources []types.ExportSource) error {
+	for i := range sources {
+		s := &sources[i]
+		if nw.unit == UnitSources {
+			if err := nw.enc.Encode(s); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, h := range s.Highlights {
+			record := NDJSONHighlight{
+				Highlight:      h,
+				SourceTitle:    s.Title,
+				SourceAuthor:   s.Author,
+				SourceCategory: s.Category,
+				SourceURL:      s.SourceURL,
+			}
+			if err := nw.enc.Encode(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func ndjsonLines(t *testing.T, unit string, pages ...[]types.ExportSource) []map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	nw, err := NewNDJSONWriter(&buf, unit)
+	if err != nil {
+		t.Fatalf("NewNDJSONWriter() error: %v", err)
+	}
+	for _, page := range pages {
+		if err := nw.Write(page); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestNDJSONWriterSources(t *testing.T) {
+	records := ndjsonLines(t, "", flashcardSources()[:1], flashcardSources()[1:])
+
+	if len(records) != 2 {
+		t.Fatalf("got %d lines, want one per source", len(records))
+	}
+	if records[0]["title"] != "Deep Work" || records[1]["title"] != "Peak" {
+		t.Errorf("unexpected titles: %v, %v", records[0]["title"], records[1]["title"])
+	}
+	if hl, _ := records[0]["highlights"].([]any); len(hl) != 3 {
+		t.Errorf("first source has %d highlights, want 3", len(hl))
+	}
+}
+
+func TestNDJSONWriterHighlights(t *testing.T) {
+	records := ndjsonLines(t, UnitHighlights, flashcardSources())
+
+	if len(records) != 4 {
+		t.Fatalf("got %d lines, want one per highlight", len(records))
+	}
+	first := records[0]
+	if first["id"] != float64(30) || first["source_title"] != "Deep Work" || first["source_author"] != "Cal Newport" {
+		t.Errorf("unexpected first record: %v", first)
+	}
+	if got := records[3]["text"]; got != "Practice {{c1::deliberately::how?}}, with {{c2::feedback}}" {
+		t.Errorf("last record text = %v", got)
+	}
+}
+
+func TestNDJSONWriterRejectsUnknownUnit(t *testing.T) {
+	if _, err := NewNDJSONWriter(&bytes.Buffer{}, "books"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
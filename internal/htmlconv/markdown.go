@@ -0,0 +1,515 @@
+package htmlconv
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// markdownEscaper escapes characters that would otherwise start Markdown
+// emphasis, code or links in text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`,
+)
+
+// trackingParams lists query parameters added to links for tracking only.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true,
+	"mc_cid": true, "mc_eid": true, "_hsenc": true, "_hsmi": true, "mkt_tok": true,
+}
+
+// ToMarkdown converts an HTML document or fragment to Markdown. Headings,
+// paragraphs, lists, block quotes, tables, links, emphasis and code are kept;
+// images become links to their source so no image data is inlined. Like
+// ToText it drops scripts and styles, and it also drops hidden elements,
+// tracking pixels and tracking parameters in link URLs.
+func ToMarkdown(src string) string {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		// html.Parse only fails on read errors, which strings.Reader never returns.
+		return ""
+	}
+	var w markdownWriter
+	w.walk(doc)
+	return strings.TrimSpace(w.b.String())
+}
+
+// markdownWriter accumulates Markdown. Like textWriter it collapses
+// whitespace and tracks pending line breaks; in addition every line starts
+// with prefix, which holds block quote markers and list indentation.
+type markdownWriter struct {
+	b        strings.Builder
+	newlines int  // line breaks to write before the next output
+	space    bool // a space is pending before the next output
+	prefix   string
+	// gap is the prefix of blank lines between blocks: the shorter of the
+	// prefixes when the break was requested and when it is written, so that
+	// the blank line after a block quote does not belong to it.
+	gap string
+	// marker replaces prefix on the next line, to start a list item.
+	marker string
+	// inLink renders images as their alt text, since links cannot nest.
+	inLink bool
+	depth  int // list nesting
+}
+
+func (w *markdownWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] || hidden(n) {
+			return
+		}
+		if w.element(n) {
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blocks[n.DataAtom]
+	if block {
+		w.breakLine(2)
+	}
+	w.children(n)
+	if block {
+		w.breakLine(2)
+	}
+}
+
+func (w *markdownWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+}
+
+// element renders elements with Markdown syntax of their own and reports
+// whether it did.
+func (w *markdownWriter) element(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Br:
+		w.breakLine(1)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		w.breakLine(2)
+		if s := w.inline(n); s != "" {
+			w.emit(strings.Repeat("#", level) + " " + s)
+		}
+		w.breakLine(2)
+	case atom.Hr:
+		w.breakLine(2)
+		w.emit("---")
+		w.breakLine(2)
+	case atom.Ul, atom.Ol:
+		w.list(n)
+	case atom.Blockquote:
+		w.blockquote(n)
+	case atom.Pre:
+		w.codeBlock(n)
+	case atom.Table:
+		w.table(n)
+	case atom.Code, atom.Kbd, atom.Samp:
+		w.inlineCode(n)
+	case atom.Strong, atom.B:
+		w.wrap(n, "**")
+	case atom.Em, atom.I:
+		w.wrap(n, "*")
+	case atom.Del, atom.S, atom.Strike:
+		w.wrap(n, "~~")
+	case atom.A:
+		w.link(n)
+	case atom.Img:
+		w.image(n)
+	default:
+		return false
+	}
+	return true
+}
+
+// breakLine requests at least n line breaks before the next output.
+func (w *markdownWriter) breakLine(n int) {
+	if w.newlines == 0 || len(w.prefix) < len(w.gap) {
+		w.gap = w.prefix
+	}
+	w.newlines = max(w.newlines, n)
+	w.space = false
+}
+
+// emit writes s, which must not contain line breaks, after the pending line
+// breaks or space.
+func (w *markdownWriter) emit(s string) {
+	if w.b.Len() == 0 || w.newlines > 0 {
+		if w.b.Len() > 0 {
+			gap := w.gap
+			if len(w.prefix) < len(gap) {
+				gap = w.prefix
+			}
+			for i := 1; i < w.newlines; i++ {
+				w.b.WriteString("\n" + strings.TrimRight(gap, " "))
+			}
+			w.b.WriteString("\n")
+		}
+		if w.marker != "" {
+			w.b.WriteString(w.marker)
+			w.marker = ""
+		} else {
+			w.b.WriteString(w.prefix)
+		}
+	} else if w.space {
+		w.b.WriteByte(' ')
+	}
+	w.newlines, w.space = 0, false
+	w.b.WriteString(s)
+}
+
+func (w *markdownWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if isSpace(s[0]) {
+		w.space = true
+	}
+	for i, word := range strings.Fields(s) {
+		if i > 0 {
+			w.space = true
+		}
+		word = markdownEscaper.Replace(word)
+		if w.b.Len() == 0 || w.newlines > 0 {
+			word = escapeLineStart(word)
+		}
+		w.emit(word)
+	}
+	w.space = w.space || isSpace(s[len(s)-1])
+}
+
+// escapeLineStart escapes a word at the start of a line that Markdown would
+// otherwise read as a heading, list item, block quote or rule.
+func escapeLineStart(word string) string {
+	switch {
+	case strings.HasPrefix(word, ">"):
+		return `\` + word
+	case strings.Trim(word, "#") == "" && len(word) <= 6,
+		strings.Trim(word, "-") == "", word == "+":
+		return `\` + word
+	}
+	digits := strings.TrimLeft(word, "0123456789")
+	if digits != word && len(word)-len(digits) <= 9 && (digits == "." || digits == ")") {
+		return word[:len(word)-1] + `\` + digits
+	}
+	return word
+}
+
+// inline renders the children of n on a single line.
+func (w *markdownWriter) inline(n *html.Node) string {
+	sub := markdownWriter{inLink: w.inLink}
+	sub.children(n)
+	return strings.Join(strings.Fields(sub.b.String()), " ")
+}
+
+// emitInline writes s in place of n, keeping the whitespace around n's text.
+func (w *markdownWriter) emitInline(n *html.Node, s string) {
+	text := textContent(n)
+	if text != "" && isSpace(text[0]) {
+		w.space = true
+	}
+	w.emit(s)
+	if text != "" && isSpace(text[len(text)-1]) {
+		w.space = true
+	}
+}
+
+// emitEmpty stands in for an element whose visible content is empty, keeping
+// only a space between the words around it.
+func (w *markdownWriter) emitEmpty(n *html.Node) {
+	if strings.ContainsAny(textContent(n), " \t\n\r\f") {
+		w.space = true
+	}
+}
+
+func (w *markdownWriter) wrap(n *html.Node, delim string) {
+	if s := w.inline(n); s != "" {
+		w.emitInline(n, delim+s+delim)
+	} else {
+		w.emitEmpty(n)
+	}
+}
+
+func (w *markdownWriter) link(n *html.Node) {
+	w.inLink = true
+	s := w.inline(n)
+	w.inLink = false
+	if s == "" {
+		w.emitEmpty(n)
+		return
+	}
+	href := linkURL(attr(n, "href"))
+	if href == "" {
+		w.emitInline(n, s)
+		return
+	}
+	w.emitInline(n, "["+s+"]("+href+")")
+}
+
+func (w *markdownWriter) image(n *html.Node) {
+	if trackingPixel(n) {
+		return
+	}
+	alt := markdownEscaper.Replace(strings.Join(strings.Fields(attr(n, "alt")), " "))
+	if w.inLink {
+		if alt != "" {
+			w.emit(alt)
+		}
+		return
+	}
+	src := linkURL(attr(n, "src"))
+	if src == "" {
+		return
+	}
+	if alt == "" {
+		alt = "image"
+	}
+	w.emit("[" + alt + "](" + src + ")")
+}
+
+func (w *markdownWriter) inlineCode(n *html.Node) {
+	code := strings.Join(strings.Fields(textContent(n)), " ")
+	if code == "" {
+		return
+	}
+	delim := "`"
+	for strings.Contains(code, delim) {
+		delim += "`"
+	}
+	if len(delim) > 1 || strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	w.emitInline(n, delim+code+delim)
+}
+
+// codeBlock renders a pre element as a fenced code block, taking the
+// language from a language-* or lang-* class on it or its code element.
+func (w *markdownWriter) codeBlock(n *html.Node) {
+	code := strings.Trim(preText(n), "\n")
+	lang := codeLanguage(n)
+	if lang == "" {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Code {
+				lang = codeLanguage(c)
+			}
+		}
+	}
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+
+	w.breakLine(2)
+	w.emit(fence + lang)
+	for _, line := range strings.Split(code, "\n") {
+		w.breakLine(1)
+		w.emit(strings.TrimRight(line, " \t\r"))
+	}
+	w.breakLine(1)
+	w.emit(fence)
+	w.breakLine(2)
+}
+
+func (w *markdownWriter) list(n *html.Node) {
+	if w.depth > 0 {
+		w.breakLine(1)
+	} else {
+		w.breakLine(2)
+	}
+	w.depth++
+	number := 1
+	if v, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = v
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li || hidden(c) {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+		outer := w.prefix
+		w.breakLine(1)
+		w.marker = outer + marker
+		w.prefix = outer + strings.Repeat(" ", len(marker))
+		w.children(c)
+		w.prefix, w.marker = outer, ""
+		w.breakLine(1)
+	}
+	w.depth--
+	if w.depth > 0 {
+		w.breakLine(1)
+	} else {
+		w.breakLine(2)
+	}
+}
+
+func (w *markdownWriter) blockquote(n *html.Node) {
+	w.breakLine(2)
+	outer := w.prefix
+	w.prefix += "> "
+	if w.marker != "" {
+		w.marker += "> "
+	}
+	w.children(n)
+	w.breakLine(2)
+	w.prefix = outer
+}
+
+// table renders a table as a pipe table, taking the first row as header.
+func (w *markdownWriter) table(n *html.Node) {
+	var rows [][]string
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || hidden(c) {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(c)
+			case atom.Tr:
+				var cells []string
+				for td := c.FirstChild; td != nil; td = td.NextSibling {
+					if td.DataAtom == atom.Td || td.DataAtom == atom.Th {
+						cells = append(cells, strings.ReplaceAll(w.inline(td), "|", `\|`))
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return
+	}
+
+	w.breakLine(2)
+	for i, cells := range rows {
+		w.breakLine(1)
+		w.emit("| " + strings.Join(cells, " | ") + " |")
+		if i == 0 {
+			w.breakLine(1)
+			w.emit("|" + strings.Repeat(" --- |", len(cells)))
+		}
+	}
+	w.breakLine(2)
+}
+
+// hidden reports whether n is hidden from readers, which covers most
+// tracking markup that is not an image.
+func hidden(n *html.Node) bool {
+	if hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// trackingPixel reports whether an image is too small to be seen.
+func trackingPixel(n *html.Node) bool {
+	for _, dim := range []string{"width", "height"} {
+		if v := strings.TrimSuffix(attr(n, dim), "px"); v == "0" || v == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+// linkURL returns href without tracking parameters, or "" for links that
+// lead nowhere outside the document: fragments, scripts and inline data.
+func linkURL(href string) string {
+	href = strings.TrimSpace(href)
+	lower := strings.ToLower(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "data:") {
+		return ""
+	}
+	if u, err := url.Parse(href); err == nil && u.RawQuery != "" {
+		q := u.Query()
+		changed := false
+		for k := range q {
+			if trackingParams[strings.ToLower(k)] || strings.HasPrefix(strings.ToLower(k), "utm_") {
+				q.Del(k)
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = q.Encode()
+			href = u.String()
+		}
+	}
+	if strings.ContainsAny(href, " ()") {
+		href = "<" + href + ">"
+	}
+	return href
+}
+
+func codeLanguage(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		for _, p := range []string{"language-", "lang-"} {
+			if lang, ok := strings.CutPrefix(class, p); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+// preText returns the text of a pre element as is, with br as line break.
+func preText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.DataAtom == atom.Br:
+			b.WriteByte('\n')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
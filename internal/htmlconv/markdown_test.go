@@ -0,0 +1,48 @@
+package htmlconv
+
+import "testing"
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{"plain text", "Just text", "Just text"},
+		{"paragraphs", "<p>First paragraph.</p><p>Second\n   paragraph.</p>", "First paragraph.\n\nSecond paragraph."},
+		{"headings", "<h1>Title</h1><p>Intro</p><h3>Sub <em>heading</em></h3>", "# Title\n\nIntro\n\n### Sub *heading*"},
+		{"emphasis", "<p>Deep <b>work</b> is <i>rare</i>, <strong> really </strong>rare.</p>", "Deep **work** is *rare*, **really** rare."},
+		{"no space between inline elements", "<p>un<em>believ</em>able</p>", "un*believ*able"},
+		{"escaping", "<p>2*3 = [six] with snake_case</p>", `2\*3 = \[six\] with snake\_case`},
+		{"links", `<p>See <a href="https://example.com/a?id=1&utm_source=news&fbclid=x">the post</a> and <a href="#top">top</a>.</p>`, "See [the post](https://example.com/a?id=1) and top."},
+		{"script link", `<a href="javascript:void(0)">click</a>`, "click"},
+		{"link with spaces", `<a href="https://example.com/a b">x</a>`, "[x](<https://example.com/a b>)"},
+		{"images as links", `<p><img src="https://example.com/chart.png" alt="A chart"> <img src="/i.png"></p>`, "[A chart](https://example.com/chart.png) [image](/i.png)"},
+		{"image inside link", `<a href="https://example.com"><img src="logo.png" alt="Logo"></a>`, "[Logo](https://example.com)"},
+		{"inline data image", `<img src="data:image/png;base64,AAAA" alt="x">`, ""},
+		{"tracking pixel", `<p>Hi<img src="https://t.example.com/open.gif" width="1" height="1"></p>`, "Hi"},
+		{"hidden elements", `<p>Shown</p><div style="display: none">Preheader</div><span hidden>x</span><span aria-hidden="true">#</span>`, "Shown"},
+		{"skipped elements", "<html><head><title>T</title><style>p{}</style></head><body><script>x()</script><p>Body</p></body></html>", "Body"},
+		{"unordered list", "<ul><li>one</li><li>two <b>bold</b></li></ul>", "- one\n- two **bold**"},
+		{"ordered list", `<ol start="3"><li>three</li><li>four</li></ol>`, "3. three\n4. four"},
+		{"nested list", "<ul><li>one<ul><li>inner</li></ul></li><li>two</li></ul><p>after</p>", "- one\n  - inner\n- two\n\nafter"},
+		{"list item paragraphs", "<ol><li><p>first</p><p>more</p></li></ol>", "1. first\n\n   more"},
+		{"blockquote", "<p>Before</p><blockquote><p>Quoted</p><p>Second</p></blockquote><p>After</p>", "Before\n\n> Quoted\n>\n> Second\n\nAfter"},
+		{"code block", "<pre><code class=\"language-go\">func main() {\n\tfmt.Println(\"*hi*\")\n}\n</code></pre>", "```go\nfunc main() {\n\tfmt.Println(\"*hi*\")\n}\n```"},
+		{"code block with fence", "<pre>a\n```\nb</pre>", "````\na\n```\nb\n````"},
+		{"inline code", "<p>Run <code>go  test</code> or <code>a`b</code></p>", "Run `go test` or `` a`b ``"},
+		{"line breaks", "line one<br>line two", "line one\nline two"},
+		{"rule", "<p>a</p><hr><p>b</p>", "a\n\n---\n\nb"},
+		{"table", "<table><thead><tr><th>Name</th><th>Value</th></tr></thead><tbody><tr><td>a|b</td><td><b>1</b></td></tr></tbody></table>", "| Name | Value |\n| --- | --- |\n| a\\|b | **1** |"},
+		{"entities", "<p>Fish &amp; chips &mdash; &quot;good&quot;</p>", `Fish & chips — "good"`},
+		{"empty inline elements", `<p>a<strong><script>track()</script></strong> <a href="https://t.example.com"><span style="display:none">hidden tracker text</span></a>b<em> </em>c</p>`, "a b c"},
+		{"block markers in text", "<p># not a heading</p><p>- not a list</p><p>+ nor this</p><p>> no quote</p><p>1. not ordered</p><p>---</p><p>a - b # c</p>", "\\# not a heading\n\n\\- not a list\n\n\\+ nor this\n\n\\> no quote\n\n1\\. not ordered\n\n\\---\n\na - b # c"},
+		{"block markers in list items", "<ul><li># tag</li></ul>", "- \\# tag"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToMarkdown(tt.html); got != tt.want {
+				t.Errorf("ToMarkdown(%q) =\n%s\nwant\n%s", tt.html, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package htmlconv converts the HTML content of Reader documents into
+// formats better suited for search and for language models.
+package htmlconv
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped lists elements whose content is never shown as text.
+var skipped = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Iframe:   true,
+}
+
+// blocks lists elements that start a new paragraph.
+var blocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Td: true, atom.Th: true, atom.Tr: true, atom.Ul: true,
+}
+
+// ToText returns the visible text of an HTML document or fragment. Block
+// elements become paragraphs separated by blank lines, line breaks are kept
+// and other whitespace is collapsed. Scripts, styles and similar elements
+// are dropped. Malformed HTML is handled the way browsers do.
+func ToText(src string) string {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		// html.Parse only fails on read errors, which strings.Reader never returns.
+		return ""
+	}
+	var w textWriter
+	w.walk(doc)
+	return strings.TrimSpace(w.b.String())
+}
+
+// textWriter accumulates text, collapsing whitespace and tracking how many
+// line breaks are pending before the next word.
+type textWriter struct {
+	b        strings.Builder
+	newlines int  // line breaks to write before the next word
+	space    bool // a space is pending before the next word
+}
+
+func (w *textWriter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+		if skipped[n.DataAtom] {
+			return
+		}
+		if n.DataAtom == atom.Br {
+			w.breakLine(1)
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blocks[n.DataAtom]
+	if block {
+		w.breakLine(2)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.walk(c)
+	}
+	if block {
+		w.breakLine(2)
+	}
+}
+
+// breakLine requests at least n line breaks before the next word.
+func (w *textWriter) breakLine(n int) {
+	w.newlines = max(w.newlines, n)
+	w.space = false
+}
+
+func (w *textWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if isSpace(s[0]) {
+		w.space = true
+	}
+	for _, word := range strings.Fields(s) {
+		switch {
+		case w.b.Len() == 0:
+		case w.newlines > 0:
+			w.b.WriteString(strings.Repeat("\n", w.newlines))
+		case w.space:
+			w.b.WriteByte(' ')
+		}
+		w.newlines, w.space = 0, true
+		w.b.WriteString(word)
+	}
+	w.space = isSpace(s[len(s)-1])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
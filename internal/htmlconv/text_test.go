@@ -0,0 +1,27 @@
+package htmlconv
+
+import "testing"
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name, html, want string
+	}{
+		{"plain text", "Just text", "Just text"},
+		{"paragraphs", "<p>First paragraph.</p><p>Second\n   paragraph.</p>", "First paragraph.\n\nSecond paragraph."},
+		{"inline elements", "<p>Deep <b>work</b> is <a href='#'>rare</a>.</p>", "Deep work is rare."},
+		{"no space between inline elements", "<p>un<em>believ</em>able</p>", "unbelievable"},
+		{"line breaks", "line one<br>line two", "line one\nline two"},
+		{"lists", "<ul><li>one</li><li>two</li></ul>", "one\n\ntwo"},
+		{"skipped elements", "<html><head><title>T</title><style>p{}</style></head><body><script>x()</script><p>Body</p></body></html>", "Body"},
+		{"entities", "<p>Fish &amp; chips &mdash; &quot;good&quot;</p>", `Fish & chips — "good"`},
+		{"malformed", "<div><p>Unclosed <b>bold<div>next", "Unclosed bold\n\nnext"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToText(tt.html); got != tt.want {
+				t.Errorf("ToText(%q) = %q, want %q", tt.html, got, tt.want)
+			}
+		})
+	}
+}
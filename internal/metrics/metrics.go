@@ -0,0 +1,202 @@
+// Package metrics exposes Prometheus metrics for the MCP server. It owns a
+// private registry and adapts the API client, cache and MCP server to it, so
+// the other packages stay free of Prometheus dependencies.
+//
+// No metric carries API keys or other user information in its labels.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+)
+
+const namespace = "readwise_mcp"
+
+// Metrics holds the server's collectors and the registry they are exposed from.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	toolCalls        *prometheus.CounterVec
+	toolDuration     *prometheus.HistogramVec
+	upstreamRequests *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+	rateLimited      *prometheus.CounterVec
+	rateLimitWait    *prometheus.HistogramVec
+}
+
+// New creates the metrics registry with Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tool_calls_total",
+			Help:      "MCP tool calls by tool and outcome.",
+		}, []string{"tool", "outcome"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "MCP tool call latency by tool.",
+			Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"tool"}),
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_requests_total",
+			Help:      "Readwise API requests by API family, method, endpoint and status code (0 when no response was received).",
+		}, []string{"family", "method", "endpoint", "status"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Readwise API request latency by API family and endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"family", "endpoint"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_rate_limited_total",
+			Help:      "Readwise API responses with status 429 by API family.",
+		}, []string{"family"}),
+		rateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Time requests spent queued for the local per-key rate limit by API family.",
+			Buckets:   []float64{.1, .5, 1, 2.5, 5, 10, 30, 60},
+		}, []string{"family"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.toolCalls,
+		m.toolDuration,
+		m.upstreamRequests,
+		m.upstreamDuration,
+		m.rateLimited,
+		m.rateLimitWait,
+	)
+	return m
+}
+
+// Handler returns the HTTP handler serving the registry in the Prometheus
+// exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest implements api.Observer.
+func (m *Metrics) ObserveRequest(family, method, endpoint string, status int, duration time.Duration) {
+	m.upstreamRequests.WithLabelValues(family, method, endpoint, strconv.Itoa(status)).Inc()
+	m.upstreamDuration.WithLabelValues(family, endpoint).Observe(duration.Seconds())
+	if status == http.StatusTooManyRequests {
+		m.rateLimited.WithLabelValues(family).Inc()
+	}
+}
+
+// ObserveRateLimitWait implements api.Observer.
+func (m *Metrics) ObserveRateLimitWait(family string, wait time.Duration) {
+	m.rateLimitWait.WithLabelValues(family).Observe(wait.Seconds())
+}
+
+// unknownTool is the tool label of calls to tools that are not registered, so
+// clients cannot create a series per made-up tool name.
+const unknownTool = "unknown"
+
+// Middleware returns MCP middleware that counts and times tool calls. A call
+// whose handler fails or returns an error result counts as outcome "error".
+// Calls to tools other than the given registered ones are labeled "unknown".
+func (m *Metrics) Middleware(tools []string) mcp.Middleware {
+	known := make(map[string]bool, len(tools))
+	for _, name := range tools {
+		known[name] = true
+	}
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok || call.Params == nil {
+				return next(ctx, method, req)
+			}
+
+			start := time.Now()
+			result, err := next(ctx, method, req)
+
+			outcome := "success"
+			if r, ok := result.(*mcp.CallToolResult); err != nil || (ok && r.IsError) {
+				outcome = "error"
+			}
+			tool := call.Params.Name
+			if !known[tool] {
+				tool = unknownTool
+			}
+			m.toolCalls.WithLabelValues(tool, outcome).Inc()
+			m.toolDuration.WithLabelValues(tool).Observe(time.Since(start).Seconds())
+			return result, err
+		}
+	}
+}
+
+// RegisterSessions reports the number of connected MCP sessions of s.
+func (m *Metrics) RegisterSessions(s *mcp.Server) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Connected MCP sessions.",
+	}, func() float64 {
+		n := 0
+		for range s.Sessions() {
+			n++
+		}
+		return float64(n)
+	}))
+}
+
+// RegisterCache reports the activity and size of cm.
+func (m *Metrics) RegisterCache(cm *cache.Manager) {
+	m.registry.MustRegister(&cacheCollector{cm: cm})
+}
+
+var (
+	cacheHitsDesc = prometheus.NewDesc(namespace+"_cache_hits_total",
+		"Cache hits by endpoint.", []string{"endpoint"}, nil)
+	cacheMissesDesc = prometheus.NewDesc(namespace+"_cache_misses_total",
+		"Cache misses by endpoint.", []string{"endpoint"}, nil)
+	cacheEvictionsDesc = prometheus.NewDesc(namespace+"_cache_evictions_total",
+		"Cache entries evicted to stay within the size limit.", nil, nil)
+	cacheBytesDesc = prometheus.NewDesc(namespace+"_cache_size_bytes",
+		"Current size of all cached entries in bytes.", nil, nil)
+	cacheEntriesDesc = prometheus.NewDesc(namespace+"_cache_entries",
+		"Current number of cached entries.", nil, nil)
+)
+
+// cacheCollector reads cache.Manager statistics at scrape time.
+type cacheCollector struct {
+	cm *cache.Manager
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheHitsDesc
+	ch <- cacheMissesDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheBytesDesc
+	ch <- cacheEntriesDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	st := c.cm.Stats()
+	for endpoint, n := range st.Hits {
+		ch <- prometheus.MustNewConstMetric(cacheHitsDesc, prometheus.CounterValue, float64(n), endpoint)
+	}
+	for endpoint, n := range st.Misses {
+		ch <- prometheus.MustNewConstMetric(cacheMissesDesc, prometheus.CounterValue, float64(n), endpoint)
+	}
+	ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(st.Evictions))
+	ch <- prometheus.MustNewConstMetric(cacheBytesDesc, prometheus.GaugeValue, float64(st.Bytes))
+	ch <- prometheus.MustNewConstMetric(cacheEntriesDesc, prometheus.GaugeValue, float64(st.Entries))
+}
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+)
+
+func TestObserveRequest(t *testing.T) {
+	m := New()
+
+	m.ObserveRequest("v2", "GET", "/books/:id/", 200, 50*time.Millisecond)
+	m.ObserveRequest("v2", "GET", "/books/:id/", 429, 10*time.Millisecond)
+	m.ObserveRequest("v3", "GET", "/list/", 0, time.Second)
+
+	if got := testutil.ToFloat64(m.upstreamRequests.WithLabelValues("v2", "GET", "/books/:id/", "200")); got != 1 {
+		t.Errorf("upstream_requests_total{status=200} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.upstreamRequests.WithLabelValues("v3", "GET", "/list/", "0")); got != 1 {
+		t.Errorf("upstream_requests_total{status=0} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.rateLimited.WithLabelValues("v2")); got != 1 {
+		t.Errorf("upstream_rate_limited_total{family=v2} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.rateLimited.WithLabelValues("v3")); got != 0 {
+		t.Errorf("upstream_rate_limited_total{family=v3} = %v, want 0", got)
+	}
+}
+
+func TestMiddlewareCountsOutcomes(t *testing.T) {
+	m := New()
+	mw := m.Middleware([]string{"list_sources"})
+
+	ok := mw(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.CallToolResult{}, nil
+	})
+	toolErr := mw(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.CallToolResult{IsError: true}, nil
+	})
+
+	call := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "list_sources"}}
+	ok(context.Background(), "tools/call", call)
+	ok(context.Background(), "tools/call", call)
+	toolErr(context.Background(), "tools/call", call)
+
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("list_sources", "success")); got != 2 {
+		t.Errorf("tool_calls_total{outcome=success} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("list_sources", "error")); got != 1 {
+		t.Errorf("tool_calls_total{outcome=error} = %v, want 1", got)
+	}
+	if n := testutil.CollectAndCount(m.toolDuration); n != 1 {
+		t.Errorf("tool_call_duration_seconds series = %d, want 1", n)
+	}
+}
+
+func TestMiddlewareLabelsUnknownTools(t *testing.T) {
+	m := New()
+	handler := m.Middleware([]string{"list_sources"})(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return nil, errors.New("unknown tool")
+	})
+
+	for _, name := range []string{"made_up_1", "made_up_2", "made_up_3"} {
+		handler(context.Background(), "tools/call", &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: name}})
+	}
+
+	if n := testutil.CollectAndCount(m.toolCalls); n != 1 {
+		t.Errorf("tool_calls_total series = %d, want 1", n)
+	}
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("unknown", "error")); got != 3 {
+		t.Errorf("tool_calls_total{tool=unknown} = %v, want 3", got)
+	}
+}
+
+func TestMiddlewareIgnoresOtherMethods(t *testing.T) {
+	m := New()
+	handler := m.Middleware(nil)(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.ListToolsResult{}, nil
+	})
+
+	handler(context.Background(), "tools/list", &mcp.ListToolsRequest{})
+
+	if n := testutil.CollectAndCount(m.toolCalls); n != 0 {
+		t.Errorf("tool_calls_total series = %d, want 0", n)
+	}
+}
+
+func TestCacheCollector(t *testing.T) {
+	m := New()
+	cm := cache.NewManager(1, 300, true)
+	m.RegisterCache(cm)
+
+	fetch := func(ctx context.Context) ([]byte, error) { return []byte(`{"ok":true}`), nil }
+	cm.Fetch(context.Background(), "key", cache.EndpointBooks, nil, fetch)
+	cm.Fetch(context.Background(), "key", cache.EndpointBooks, nil, fetch)
+
+	expected := `
+# HELP readwise_mcp_cache_hits_total Cache hits by endpoint.
+# TYPE readwise_mcp_cache_hits_total counter
+readwise_mcp_cache_hits_total{endpoint="/api/v2/books/"} 1
+# HELP readwise_mcp_cache_misses_total Cache misses by endpoint.
+# TYPE readwise_mcp_cache_misses_total counter
+readwise_mcp_cache_misses_total{endpoint="/api/v2/books/"} 1
+# HELP readwise_mcp_cache_entries Current number of cached entries.
+# TYPE readwise_mcp_cache_entries gauge
+readwise_mcp_cache_entries 1
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected),
+		"readwise_mcp_cache_hits_total", "readwise_mcp_cache_misses_total", "readwise_mcp_cache_entries")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHandlerNeverExposesAPIKeys(t *testing.T) {
+	const apiKey = "secret-readwise-key"
+
+	m := New()
+	cm := cache.NewManager(1, 300, true)
+	m.RegisterCache(cm)
+	cm.Fetch(context.Background(), apiKey, cache.EndpointBooks, nil, func(ctx context.Context) ([]byte, error) {
+		return []byte(`{}`), nil
+	})
+	m.ObserveRequest("v2", "GET", "/books/", 200, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "readwise_mcp_upstream_requests_total") {
+		t.Fatal("metrics output missing upstream_requests_total")
+	}
+	if strings.Contains(body, apiKey) || strings.Contains(body, auth.HashAPIKey(apiKey)) {
+		t.Error("metrics output contains the API key or its hash")
+	}
+}
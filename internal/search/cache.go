@@ -0,0 +1,234 @@
+package search
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// IndexCache keeps the highlight and document indexes of each user and
+// rebuilds them only when the data they were built from changes. It holds
+// the indexes of at most maxUsers users and drops the least recently used
+// user's indexes beyond that.
+type IndexCache struct {
+	mu       sync.Mutex
+	maxUsers int
+	users    map[string]*userIndexes
+	clock    uint64 // incremented on every access to order users by recency
+}
+
+type userIndexes struct {
+	highlights            *HighlightIndex
+	highlightsFingerprint uint64
+	documents             *DocumentIndex
+	documentsFingerprint  uint64
+	content               *DocumentIndex
+	contentFingerprint    uint64
+	lastUse               uint64
+}
+
+// NewIndexCache creates a cache holding indexes for up to maxUsers users.
+func NewIndexCache(maxUsers int) *IndexCache {
+	return &IndexCache{
+		maxUsers: max(1, maxUsers),
+		users:    make(map[string]*userIndexes),
+	}
+}
+
+// Highlights returns the highlight index of user, which must identify the
+// user without revealing the API key (e.g. auth.HashAPIKey). The cached
+// index is reused while sources hold the same highlights, and rebuilt
+// otherwise.
+func (c *IndexCache) Highlights(user string, sources []types.ExportSource) *HighlightIndex {
+	fp := highlightsFingerprint(sources)
+
+	c.mu.Lock()
+	if u := c.touchLocked(user); u.highlights != nil && u.highlightsFingerprint == fp {
+		c.mu.Unlock()
+		return u.highlights
+	}
+	c.mu.Unlock()
+
+	// Build outside the lock; concurrent rebuilds for one user are harmless.
+	index := NewHighlightIndex(sources)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.touchLocked(user)
+	u.highlights, u.highlightsFingerprint = index, fp
+	return index
+}
+
+// Documents returns the document index of user, rebuilt when docs differ
+// from the documents the cached index was built from.
+func (c *IndexCache) Documents(user string, docs []types.Document) *DocumentIndex {
+	fp := documentsFingerprint(docs)
+
+	c.mu.Lock()
+	if u := c.touchLocked(user); u.documents != nil && u.documentsFingerprint == fp {
+		c.mu.Unlock()
+		return u.documents
+	}
+	c.mu.Unlock()
+
+	index := NewDocumentIndex(docs)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.touchLocked(user)
+	u.documents, u.documentsFingerprint = index, fp
+	return index
+}
+
+// DocumentContent returns the index of user's documents including their body
+// text, rebuilt when docs or the content of any listed document changes.
+// content maps document IDs to plain text as for NewDocumentContentIndex.
+func (c *IndexCache) DocumentContent(user string, docs []types.Document, content map[string]string) *DocumentIndex {
+	fp := contentFingerprint(docs, content)
+
+	c.mu.Lock()
+	if u := c.touchLocked(user); u.content != nil && u.contentFingerprint == fp {
+		c.mu.Unlock()
+		return u.content
+	}
+	c.mu.Unlock()
+
+	index := NewDocumentContentIndex(docs, content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.touchLocked(user)
+	u.content, u.contentFingerprint = index, fp
+	return index
+}
+
+// Len returns the number of users with cached indexes.
+func (c *IndexCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.users)
+}
+
+// touchLocked returns the entry of user, creating it if needed, marks it as
+// most recently used and evicts other users beyond maxUsers.
+func (c *IndexCache) touchLocked(user string) *userIndexes {
+	c.clock++
+	u, ok := c.users[user]
+	if !ok {
+		u = &userIndexes{}
+		c.users[user] = u
+	}
+	u.lastUse = c.clock
+
+	for len(c.users) > c.maxUsers {
+		var oldest string
+		var oldestUse uint64
+		for name, e := range c.users {
+			if oldest == "" || e.lastUse < oldestUse {
+				oldest, oldestUse = name, e.lastUse
+			}
+		}
+		delete(c.users, oldest)
+	}
+	return u
+}
+
+// fingerprinter hashes the values an index is built from, so any added,
+// removed or edited item yields a different fingerprint.
+type fingerprinter struct {
+	h   hash.Hash64
+	buf [8]byte
+}
+
+func newFingerprinter() *fingerprinter {
+	return &fingerprinter{h: fnv.New64a()}
+}
+
+func (f *fingerprinter) int(v int64) {
+	binary.LittleEndian.PutUint64(f.buf[:], uint64(v))
+	f.h.Write(f.buf[:])
+}
+
+func (f *fingerprinter) string(s string) {
+	f.int(int64(len(s)))
+	f.h.Write([]byte(s))
+}
+
+func (f *fingerprinter) bool(b bool) {
+	if b {
+		f.int(1)
+	} else {
+		f.int(0)
+	}
+}
+
+func highlightsFingerprint(sources []types.ExportSource) uint64 {
+	f := newFingerprinter()
+	f.int(int64(len(sources)))
+	for _, s := range sources {
+		f.int(s.UserBookID)
+		f.string(s.Title)
+		f.string(s.Author)
+		f.string(s.Category)
+		f.int(int64(len(s.Highlights)))
+		for _, h := range s.Highlights {
+			f.int(h.ID)
+			f.int(h.UpdatedAt.UnixNano())
+			f.int(h.HighlightedAt.UnixNano())
+			f.string(h.Text)
+			f.string(h.Note)
+			f.string(h.Color)
+			f.bool(h.IsFavorite)
+			f.int(int64(len(h.Tags)))
+			for _, t := range h.Tags {
+				f.string(t.Name)
+			}
+		}
+	}
+	return f.h.Sum64()
+}
+
+func documentsFingerprint(docs []types.Document) uint64 {
+	f := newFingerprinter()
+	writeDocuments(f, docs)
+	return f.h.Sum64()
+}
+
+// contentFingerprint covers docs and the content of each listed document.
+// Content of documents no longer listed does not affect the index.
+func contentFingerprint(docs []types.Document, content map[string]string) uint64 {
+	f := newFingerprinter()
+	writeDocuments(f, docs)
+	for _, d := range docs {
+		f.string(content[d.ID])
+	}
+	return f.h.Sum64()
+}
+
+func writeDocuments(f *fingerprinter, docs []types.Document) {
+	f.int(int64(len(docs)))
+	for _, d := range docs {
+		f.string(d.ID)
+		f.int(d.UpdatedAt.UnixNano())
+		f.int(d.SavedAt.UnixNano())
+		f.string(d.Title)
+		f.string(d.Author)
+		f.string(d.Summary)
+		f.string(d.Notes)
+		f.string(d.Category)
+		f.string(d.Location)
+		keys := make([]string, 0, len(d.Tags))
+		for k := range d.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		f.int(int64(len(keys)))
+		for _, k := range keys {
+			f.string(k)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func testSources(text string) []types.ExportSource {
+	return []types.ExportSource{
+		{UserBookID: 1, Title: "Book", Highlights: []types.Highlight{{ID: 1, Text: text}}},
+	}
+}
+
+func TestIndexCacheReusesIndex(t *testing.T) {
+	c := NewIndexCache(4)
+
+	first := c.Highlights("user", testSources("stable text"))
+	second := c.Highlights("user", testSources("stable text"))
+	if first != second {
+		t.Error("expected the index to be reused for unchanged export data")
+	}
+}
+
+func TestIndexCacheRebuildsOnChange(t *testing.T) {
+	c := NewIndexCache(4)
+
+	first := c.Highlights("user", testSources("old text"))
+	second := c.Highlights("user", testSources("new text"))
+	if first == second {
+		t.Fatal("expected a new index after the export changed")
+	}
+	if hits, _ := second.Search("new", HighlightOptions{}); len(hits) != 1 {
+		t.Errorf("rebuilt index should find the edited highlight, got %d hits", len(hits))
+	}
+}
+
+func TestIndexCachePerUser(t *testing.T) {
+	c := NewIndexCache(4)
+
+	a := c.Highlights("alice", testSources("alice text"))
+	b := c.Highlights("bob", testSources("bob text"))
+	if a == b {
+		t.Fatal("users must not share an index")
+	}
+	if hits, _ := b.Search("alice", HighlightOptions{}); len(hits) != 0 {
+		t.Error("bob's index contains alice's highlights")
+	}
+}
+
+func TestIndexCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewIndexCache(2)
+
+	alice := c.Highlights("alice", testSources("a"))
+	c.Highlights("bob", testSources("b"))
+	c.Highlights("alice", testSources("a"))
+	c.Highlights("carol", testSources("c"))
+
+	if c.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", c.Len())
+	}
+	if c.Highlights("alice", testSources("a")) != alice {
+		t.Error("recently used index was evicted")
+	}
+}
+
+func TestIndexCacheDocumentContent(t *testing.T) {
+	c := NewIndexCache(4)
+	docs := []types.Document{{ID: "a", Title: "Doc"}}
+
+	first := c.DocumentContent("user", docs, map[string]string{"a": "old body"})
+	if c.DocumentContent("user", docs, map[string]string{"a": "old body", "gone": "x"}) != first {
+		t.Error("content of unlisted documents should not rebuild the index")
+	}
+	second := c.DocumentContent("user", docs, map[string]string{"a": "new body"})
+	if second == first {
+		t.Fatal("expected a new index after the content changed")
+	}
+	if hits, _ := second.Search("new", DocumentOptions{}); len(hits) != 1 {
+		t.Errorf("rebuilt index should find the new body, got %d hits", len(hits))
+	}
+	if c.Documents("user", docs) == second {
+		t.Error("content and metadata indexes must be cached separately")
+	}
+}
@@ -0,0 +1,152 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// documentWeights ranks title matches highest, followed by the author and
+// the descriptive fields.
+var documentWeights = Weights{
+	FieldTitle:   1.0,
+	FieldAuthor:  0.8,
+	FieldSummary: 0.6,
+	FieldNote:    0.6,
+}
+
+// contentDocumentWeights adds the document body to documentWeights. The body
+// is long and mostly incidental to what a document is about, so it weighs
+// less than the descriptive fields.
+var contentDocumentWeights = Weights{
+	FieldTitle:   1.0,
+	FieldAuthor:  0.8,
+	FieldSummary: 0.6,
+	FieldNote:    0.6,
+	FieldContent: 0.4,
+}
+
+// DocumentIndex indexes Reader documents by title, author, summary and notes,
+// and optionally by their body text.
+type DocumentIndex struct {
+	docs  []types.Document
+	index *Index
+}
+
+// DocumentOptions restricts a document search.
+type DocumentOptions struct {
+	// Location and Category limit results to documents with these values;
+	// empty values do not restrict.
+	Location string
+	Category string
+	// Limit caps the number of results; 0 returns all matches.
+	Limit int
+	// Fuzzy lets terms also match words a few typos away.
+	Fuzzy bool
+}
+
+// DocumentHit is a document matching a search.
+type DocumentHit struct {
+	Document *types.Document
+	Score    float64
+	// Snippets show where the query matched the document.
+	Snippets []Snippet
+}
+
+// NewDocumentIndex builds an index over docs. The index keeps docs and the
+// returned hits point into it, so callers must not modify docs afterwards.
+func NewDocumentIndex(docs []types.Document) *DocumentIndex {
+	return &DocumentIndex{docs: docs, index: NewIndex(documentFields(docs, nil), documentWeights)}
+}
+
+// NewDocumentContentIndex builds an index like NewDocumentIndex that also
+// matches the body text of docs. content maps document IDs to plain text;
+// documents without an entry are indexed without a body.
+func NewDocumentContentIndex(docs []types.Document, content map[string]string) *DocumentIndex {
+	return &DocumentIndex{docs: docs, index: NewIndex(documentFields(docs, content), contentDocumentWeights)}
+}
+
+func documentFields(docs []types.Document, content map[string]string) []Document {
+	fields := make([]Document, len(docs))
+	for i, d := range docs {
+		fields[i] = Document{
+			FieldTitle:   d.Title,
+			FieldAuthor:  d.Author,
+			FieldSummary: d.Summary,
+			FieldNote:    d.Notes,
+			FieldContent: content[d.ID],
+		}
+	}
+	return fields
+}
+
+// Search returns the documents matching query, best match first. It returns
+// a *ParseError for invalid queries and unsupported filters.
+func (dx *DocumentIndex) Search(query string, opts DocumentOptions) ([]DocumentHit, error) {
+	q, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	q.Fuzzy = opts.Fuzzy
+
+	accept := func(doc int) bool {
+		d := &dx.docs[doc]
+		return (opts.Location == "" || d.Location == opts.Location) &&
+			(opts.Category == "" || d.Category == opts.Category)
+	}
+
+	hits, err := dx.index.Search(q, dx.compileFilter, accept)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Limit > 0 && len(hits) > opts.Limit {
+		hits = hits[:opts.Limit]
+	}
+
+	results := make([]DocumentHit, len(hits))
+	for i, hit := range hits {
+		results[i] = DocumentHit{
+			Document: &dx.docs[hit.Doc],
+			Score:    roundScore(hit.Score),
+			Snippets: dx.index.Snippets(hit.Doc, q),
+		}
+	}
+	return results, nil
+}
+
+// compileFilter implements FilterCompiler for documents. Dates refer to
+// saved_at.
+func (dx *DocumentIndex) compileFilter(f Filter) (FilterFunc, error) {
+	switch f.Key {
+	case "tag":
+		return func(doc int) bool {
+			for key, t := range dx.docs[doc].Tags {
+				if strings.EqualFold(key, f.Value) || strings.EqualFold(t.Name, f.Value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "category":
+		return func(doc int) bool {
+			return strings.EqualFold(dx.docs[doc].Category, f.Value)
+		}, nil
+	case "location":
+		return func(doc int) bool {
+			return strings.EqualFold(dx.docs[doc].Location, f.Value)
+		}, nil
+	case "before", "after":
+		return dateFilter(f, func(doc int) time.Time {
+			return dx.docs[doc].SavedAt
+		})
+	default:
+		return nil, &ParseError{Pos: f.Pos, Msg: fmt.Sprintf("%s: is not supported for documents", f.Key)}
+	}
+}
+
+// Len returns the number of indexed documents.
+func (dx *DocumentIndex) Len() int {
+	return dx.index.Len()
+}
@@ -0,0 +1,113 @@
+package search
+
+import "unicode/utf8"
+
+// Weights of term variants relative to an exact match. Stemmed variants are
+// other forms of the same word; fuzzy variants may be a different word
+// altogether and score lower the more edits they need.
+const stemWeight = 0.9
+
+var fuzzyWeights = [...]float64{1: 0.6, 2: 0.4}
+
+// maxEdits returns how many edits a fuzzy match of term may need. Short terms
+// must match exactly since one edit already turns them into unrelated words.
+func maxEdits(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// variants returns the indexed terms matching term together with their
+// weight: the term itself, terms sharing its stem and, if fuzzy is set, terms
+// within maxEdits edits.
+func (ix *Index) variants(term string, fuzzy bool) map[string]float64 {
+	found := make(map[string]float64)
+	if _, ok := ix.postings[term]; ok {
+		found[term] = 1
+	}
+	for _, v := range ix.stems[Stem(term)] {
+		if _, ok := found[v]; !ok {
+			found[v] = stemWeight
+		}
+	}
+	if !fuzzy {
+		return found
+	}
+	limit := maxEdits(term)
+	if limit == 0 {
+		return found
+	}
+	r := []rune(term)
+	for v := range ix.postings {
+		if _, ok := found[v]; ok {
+			continue
+		}
+		if d := editDistance(r, v, limit); d <= limit {
+			found[v] = fuzzyWeights[d]
+		}
+	}
+	return found
+}
+
+// sameWord reports whether the indexed word matches the query term exactly,
+// by stem or, if fuzzy is set, within maxEdits edits.
+func sameWord(word, term string, fuzzy bool) bool {
+	if word == term || Stem(word) == Stem(term) {
+		return true
+	}
+	if !fuzzy {
+		return false
+	}
+	limit := maxEdits(term)
+	return limit > 0 && editDistance([]rune(term), word, limit) <= limit
+}
+
+// editDistance returns the edit distance between a and b, counting
+// insertions, deletions, substitutions and swaps of adjacent letters, or
+// limit+1 if it exceeds limit.
+func editDistance(a []rune, b string, limit int) int {
+	if abs(len(a)-utf8.RuneCountInString(b)) > limit {
+		return limit + 1
+	}
+
+	rb := []rune(b)
+	// Rows of the distance matrix for the prefixes b[:j-2], b[:j-1] and b[:j].
+	prev2 := make([]int, len(a)+1)
+	prev := make([]int, len(a)+1)
+	cur := make([]int, len(a)+1)
+	for i := range prev {
+		prev[i] = i
+	}
+	for j := 1; j <= len(rb); j++ {
+		cur[0] = j
+		best := cur[0]
+		for i := 1; i <= len(a); i++ {
+			cost := 1
+			if a[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[i] = min(prev[i]+1, cur[i-1]+1, prev[i-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == rb[j-2] && a[i-2] == rb[j-1] {
+				cur[i] = min(cur[i], prev2[i-2]+1)
+			}
+			best = min(best, cur[i])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(a)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
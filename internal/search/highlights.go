@@ -0,0 +1,242 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// highlightWeights favors the highlight itself over the source metadata that
+// every highlight of a source shares.
+var highlightWeights = Weights{
+	FieldText:   1.0,
+	FieldNote:   0.8,
+	FieldTitle:  0.5,
+	FieldAuthor: 0.4,
+}
+
+// HighlightIndex indexes the highlights of an export by highlight text,
+// note, source title and source author.
+type HighlightIndex struct {
+	sources []types.ExportSource
+	refs    []highlightRef
+	index   *Index
+}
+
+// highlightRef locates an indexed highlight in the export.
+type highlightRef struct {
+	source, highlight int
+}
+
+// HighlightOptions restricts a highlight search.
+type HighlightOptions struct {
+	// SourceID limits results to one source; 0 searches all sources.
+	SourceID int64
+	// Limit caps the number of results; 0 returns all matches.
+	Limit int
+	// Fuzzy lets terms also match words a few typos away.
+	Fuzzy bool
+}
+
+// HighlightHit is a highlight matching a search together with its source.
+type HighlightHit struct {
+	Source    *types.ExportSource
+	Highlight *types.Highlight
+	Score     float64
+	// Snippets show where the query matched the highlight and its source.
+	Snippets []Snippet
+}
+
+// NewHighlightIndex builds an index over all highlights in sources. The
+// index keeps sources and the returned hits point into it, so callers must
+// not modify sources afterwards.
+func NewHighlightIndex(sources []types.ExportSource) *HighlightIndex {
+	var docs []Document
+	var refs []highlightRef
+	for si, source := range sources {
+		for hi, h := range source.Highlights {
+			docs = append(docs, Document{
+				FieldText:   h.Text,
+				FieldNote:   h.Note,
+				FieldTitle:  source.Title,
+				FieldAuthor: source.Author,
+			})
+			refs = append(refs, highlightRef{source: si, highlight: hi})
+		}
+	}
+
+	return &HighlightIndex{
+		sources: sources,
+		refs:    refs,
+		index:   NewIndex(docs, highlightWeights),
+	}
+}
+
+// Search returns the highlights matching query, best match first. It
+// returns a *ParseError for invalid queries and unsupported filters.
+func (hx *HighlightIndex) Search(query string, opts HighlightOptions) ([]HighlightHit, error) {
+	q, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	q.Fuzzy = opts.Fuzzy
+
+	var accept FilterFunc
+	if opts.SourceID != 0 {
+		accept = func(doc int) bool {
+			return hx.source(doc).UserBookID == opts.SourceID
+		}
+	}
+
+	hits, err := hx.index.Search(q, hx.compileFilter, accept)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Limit > 0 && len(hits) > opts.Limit {
+		hits = hits[:opts.Limit]
+	}
+
+	results := make([]HighlightHit, len(hits))
+	for i, hit := range hits {
+		results[i] = HighlightHit{
+			Source:    hx.source(hit.Doc),
+			Highlight: hx.highlight(hit.Doc),
+			Score:     roundScore(hit.Score),
+			Snippets:  hx.index.Snippets(hit.Doc, q),
+		}
+	}
+	return results, nil
+}
+
+// RelatedOptions restricts a search for related highlights.
+type RelatedOptions struct {
+	// IncludeSameSource also returns highlights from the source of the
+	// highlight the search starts from.
+	IncludeSameSource bool
+	// Limit caps the number of results; 0 returns all related highlights.
+	Limit int
+}
+
+// Related returns the highlights most similar to the highlight with the
+// given ID, ranked by Index.Similar. It reports false if no indexed highlight
+// has that ID.
+func (hx *HighlightIndex) Related(id int64, opts RelatedOptions) ([]HighlightHit, bool) {
+	doc := -1
+	for i := range hx.refs {
+		if hx.highlight(i).ID == id {
+			doc = i
+			break
+		}
+	}
+	if doc < 0 {
+		return nil, false
+	}
+
+	var accept FilterFunc
+	if !opts.IncludeSameSource {
+		source := hx.refs[doc].source
+		accept = func(other int) bool {
+			return hx.refs[other].source != source
+		}
+	}
+
+	hits := hx.index.Similar(doc, accept)
+	if opts.Limit > 0 && len(hits) > opts.Limit {
+		hits = hits[:opts.Limit]
+	}
+
+	results := make([]HighlightHit, len(hits))
+	for i, hit := range hits {
+		results[i] = HighlightHit{
+			Source:    hx.source(hit.Doc),
+			Highlight: hx.highlight(hit.Doc),
+			Score:     roundScore(hit.Score),
+		}
+	}
+	return results, true
+}
+
+// compileFilter implements FilterCompiler for highlights. Dates refer to
+// highlighted_at, and category to the category of the source.
+func (hx *HighlightIndex) compileFilter(f Filter) (FilterFunc, error) {
+	switch f.Key {
+	case "tag":
+		return func(doc int) bool {
+			return hasTag(hx.highlight(doc).Tags, f.Value)
+		}, nil
+	case "color":
+		return func(doc int) bool {
+			return strings.EqualFold(hx.highlight(doc).Color, f.Value)
+		}, nil
+	case "category":
+		return func(doc int) bool {
+			return strings.EqualFold(hx.source(doc).Category, f.Value)
+		}, nil
+	case "before", "after":
+		return dateFilter(f, func(doc int) time.Time {
+			return hx.highlight(doc).HighlightedAt
+		})
+	case "is":
+		if !strings.EqualFold(f.Value, "favorite") {
+			return nil, f.errorf("unsupported value %q for is:, use is:favorite", f.Value)
+		}
+		return func(doc int) bool {
+			return hx.highlight(doc).IsFavorite
+		}, nil
+	default:
+		return nil, &ParseError{Pos: f.Pos, Msg: fmt.Sprintf("%s: is not supported for highlights", f.Key)}
+	}
+}
+
+func (hx *HighlightIndex) source(doc int) *types.ExportSource {
+	return &hx.sources[hx.refs[doc].source]
+}
+
+func (hx *HighlightIndex) highlight(doc int) *types.Highlight {
+	ref := hx.refs[doc]
+	return &hx.sources[ref.source].Highlights[ref.highlight]
+}
+
+// Len returns the number of indexed highlights.
+func (hx *HighlightIndex) Len() int {
+	return hx.index.Len()
+}
+
+// hasTag reports whether tags contain a tag named name, ignoring case.
+func hasTag(tags []types.Tag, name string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// dateFilter compiles a before: or after: filter on the date returned by
+// date. before: excludes the given day, after: includes it. Items without a
+// date never match.
+func dateFilter(f Filter, date func(doc int) time.Time) (FilterFunc, error) {
+	bound, err := parseDate(f)
+	if err != nil {
+		return nil, err
+	}
+	before := f.Key == "before"
+	return func(doc int) bool {
+		d := date(doc)
+		if d.IsZero() {
+			return false
+		}
+		if before {
+			return d.Before(bound)
+		}
+		return !d.Before(bound)
+	}, nil
+}
+
+// roundScore keeps four decimals, which is enough to rank and easier to read.
+func roundScore(score float64) float64 {
+	return math.Round(score*1e4) / 1e4
+}
@@ -0,0 +1,390 @@
+package search
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Field identifies a searchable part of a document.
+type Field int
+
+const (
+	FieldText Field = iota
+	FieldNote
+	FieldTitle
+	FieldAuthor
+	FieldSummary
+	FieldContent
+
+	// NumFields is the number of fields; it sizes Document and Weights.
+	NumFields int = iota
+)
+
+// Document is the searchable text of one item, indexed by Field.
+type Document [NumFields]string
+
+// Weights sets how much a term occurrence in each field contributes to the
+// score. Fields with weight 0 are not indexed.
+type Weights [NumFields]float64
+
+// BM25 parameters: k1 controls term frequency saturation and b the strength
+// of field length normalization.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type posting struct {
+	doc  int
+	freq [NumFields]int
+}
+
+// Index is an inverted index ranking documents with BM25F: term frequencies
+// are length-normalized per field, weighted and summed before saturation.
+// An Index is immutable once built and safe for concurrent use.
+type Index struct {
+	docs     []Document
+	weights  Weights
+	postings map[string][]posting
+	stems    map[string][]string // stem -> indexed terms with that stem
+	lengths  [][NumFields]int
+	avgLen   [NumFields]float64
+
+	// norms holds the length of each document's term vector. Only Similar
+	// needs them, so they are computed on its first call.
+	norms     []float64
+	normsOnce sync.Once
+}
+
+// Hit is a document matching a search, identified by its position in the
+// slice passed to NewIndex.
+type Hit struct {
+	Doc   int
+	Score float64
+}
+
+// NewIndex tokenizes and indexes docs.
+func NewIndex(docs []Document, weights Weights) *Index {
+	ix := &Index{
+		docs:     docs,
+		weights:  weights,
+		postings: make(map[string][]posting),
+		stems:    make(map[string][]string),
+		lengths:  make([][NumFields]int, len(docs)),
+	}
+
+	var total [NumFields]int
+	for i, doc := range docs {
+		entries := make(map[string]*posting)
+		for f, text := range doc {
+			if weights[f] == 0 || text == "" {
+				continue
+			}
+			terms := Tokenize(text)
+			ix.lengths[i][f] = len(terms)
+			total[f] += len(terms)
+			for _, term := range terms {
+				p := entries[term]
+				if p == nil {
+					p = &posting{doc: i}
+					entries[term] = p
+				}
+				p.freq[f]++
+			}
+		}
+		for term, p := range entries {
+			ix.postings[term] = append(ix.postings[term], *p)
+		}
+	}
+
+	for term := range ix.postings {
+		stem := Stem(term)
+		ix.stems[stem] = append(ix.stems[stem], term)
+	}
+
+	for f := range total {
+		if len(docs) > 0 {
+			ix.avgLen[f] = float64(total[f]) / float64(len(docs))
+		}
+	}
+
+	return ix
+}
+
+// FilterFunc reports whether the document with the given number passes a
+// field filter.
+type FilterFunc func(doc int) bool
+
+// FilterCompiler turns a field filter into a FilterFunc. It returns an error
+// for filters the indexed kind of item does not support and invalid values.
+// The author: and title: filters are handled by the index itself.
+type FilterCompiler func(f Filter) (FilterFunc, error)
+
+// Search returns the documents matching q, best match first. Documents
+// rejected by accept are skipped; a nil accept allows all.
+//
+// Terms also match other forms of the same word, such as "negotiating" for
+// "negotiation", and with q.Fuzzy set words a few typos away. Such matches
+// score below exact ones.
+func (ix *Index) Search(q *Query, compile FilterCompiler, accept FilterFunc) ([]Hit, error) {
+	preds := make(map[*filterNode]FilterFunc)
+	if err := ix.compileFilters(q.root, compile, preds, q.Fuzzy); err != nil {
+		return nil, err
+	}
+
+	found, ok := ix.eval(q.root, preds, q.Fuzzy)
+	if !ok {
+		return nil, nil
+	}
+
+	var hits []Hit
+	for doc, score := range found {
+		if accept == nil || accept(doc) {
+			hits = append(hits, Hit{Doc: doc, Score: score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Doc < hits[j].Doc
+	})
+	return hits, nil
+}
+
+// compileFilters resolves every filter in the tree to a predicate.
+func (ix *Index) compileFilters(n node, compile FilterCompiler, preds map[*filterNode]FilterFunc, fuzzy bool) error {
+	switch n := n.(type) {
+	case *filterNode:
+		pred, err := ix.compileFilter(n.filter, compile, fuzzy)
+		if err != nil {
+			return err
+		}
+		preds[n] = pred
+	case *andNode:
+		for _, c := range n.children {
+			if err := ix.compileFilters(c, compile, preds, fuzzy); err != nil {
+				return err
+			}
+		}
+	case *orNode:
+		for _, c := range n.children {
+			if err := ix.compileFilters(c, compile, preds, fuzzy); err != nil {
+				return err
+			}
+		}
+	case *notNode:
+		return ix.compileFilters(n.child, compile, preds, fuzzy)
+	}
+	return nil
+}
+
+func (ix *Index) compileFilter(f Filter, compile FilterCompiler, fuzzy bool) (FilterFunc, error) {
+	var field Field
+	switch f.Key {
+	case "author":
+		field = FieldAuthor
+	case "title":
+		field = FieldTitle
+	default:
+		return compile(f)
+	}
+
+	terms := Tokenize(f.Value)
+	if len(terms) == 0 {
+		return nil, f.errorf("%s: needs a value with searchable words", f.Key)
+	}
+	return func(doc int) bool {
+		return containsPhrase(ix.docs[doc][field], terms, fuzzy)
+	}, nil
+}
+
+// matches maps matching document numbers to their scores.
+type matches map[int]float64
+
+// eval returns the documents matching n. It returns false for nodes that do
+// not constrain the result, such as words consisting of stop words only.
+func (ix *Index) eval(n node, preds map[*filterNode]FilterFunc, fuzzy bool) (matches, bool) {
+	switch n := n.(type) {
+	case *termNode:
+		return ix.termMatches(n.term, fuzzy), true
+
+	case *phraseNode:
+		found, _ := ix.eval(&andNode{children: termNodes(n.terms)}, preds, fuzzy)
+		for doc := range found {
+			if !ix.hasPhrase(doc, n.terms, fuzzy) {
+				delete(found, doc)
+			}
+		}
+		return found, true
+
+	case *filterNode:
+		pred := preds[n]
+		found := make(matches)
+		for doc := range ix.docs {
+			if pred(doc) {
+				found[doc] = 0
+			}
+		}
+		return found, true
+
+	case *andNode:
+		var found matches
+		var excluded []matches
+		constrained := false
+		for _, c := range n.children {
+			if not, ok := c.(*notNode); ok {
+				if m, ok := ix.eval(not.child, preds, fuzzy); ok {
+					excluded = append(excluded, m)
+				}
+				continue
+			}
+			m, ok := ix.eval(c, preds, fuzzy)
+			if !ok {
+				continue
+			}
+			if !constrained {
+				found, constrained = m, true
+				continue
+			}
+			for doc, score := range found {
+				if s, ok := m[doc]; ok {
+					found[doc] = score + s
+				} else {
+					delete(found, doc)
+				}
+			}
+		}
+		if !constrained {
+			if len(excluded) == 0 {
+				return nil, false
+			}
+			found = ix.allDocs()
+		}
+		for _, m := range excluded {
+			for doc := range m {
+				delete(found, doc)
+			}
+		}
+		return found, true
+
+	case *orNode:
+		var found matches
+		for _, c := range n.children {
+			m, ok := ix.eval(c, preds, fuzzy)
+			if !ok {
+				continue
+			}
+			if found == nil {
+				found = make(matches)
+			}
+			for doc, score := range m {
+				found[doc] += score
+			}
+		}
+		return found, found != nil
+
+	case *notNode:
+		return ix.eval(&andNode{children: []node{n}}, preds, fuzzy)
+	}
+	return nil, false
+}
+
+// termMatches scores every document containing term or one of its variants.
+// A document containing several variants gets the score of the best one.
+func (ix *Index) termMatches(term string, fuzzy bool) matches {
+	found := make(matches)
+	for v, weight := range ix.variants(term, fuzzy) {
+		postings := ix.postings[v]
+		idf := ix.idf(len(postings))
+		for _, p := range postings {
+			if score := weight * idf * ix.saturate(p); score > found[p.doc] {
+				found[p.doc] = score
+			}
+		}
+	}
+	return found
+}
+
+// hasPhrase reports whether any indexed field of doc contains terms in order.
+func (ix *Index) hasPhrase(doc int, terms []string, fuzzy bool) bool {
+	for f, text := range ix.docs[doc] {
+		if ix.weights[f] != 0 && containsPhrase(text, terms, fuzzy) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ix *Index) allDocs() matches {
+	found := make(matches, len(ix.docs))
+	for doc := range ix.docs {
+		found[doc] = 0
+	}
+	return found
+}
+
+func termNodes(terms []string) []node {
+	nodes := make([]node, len(terms))
+	for i, t := range terms {
+		nodes[i] = &termNode{term: t}
+	}
+	return nodes
+}
+
+// containsPhrase reports whether the terms of text include terms as a
+// consecutive run, comparing words as sameWord does. Stop words are skipped
+// on both sides.
+func containsPhrase(text string, terms []string, fuzzy bool) bool {
+	if text == "" {
+		return false
+	}
+	words := Tokenize(text)
+	for i := 0; i+len(terms) <= len(words); i++ {
+		if slices.EqualFunc(words[i:i+len(terms)], terms, func(w, t string) bool {
+			return sameWord(w, t, fuzzy)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of indexed documents.
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// idf is the BM25 inverse document frequency of a term found in df documents.
+func (ix *Index) idf(df int) float64 {
+	n := float64(len(ix.lengths))
+	return math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+}
+
+// saturate combines the field frequencies of a posting into a BM25F term score.
+func (ix *Index) saturate(p posting) float64 {
+	var tf float64
+	for f, freq := range p.freq {
+		if freq == 0 {
+			continue
+		}
+		norm := 1 - bm25B + bm25B*float64(ix.lengths[p.doc][f])/ix.avgLen[f]
+		tf += ix.weights[f] * float64(freq) / norm
+	}
+	return tf / (bm25K1 + tf)
+}
+
+// uniqueTerms returns terms without duplicates, keeping the first occurrence.
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,254 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func mustSearch(t *testing.T, ix *Index, query string) []Hit {
+	t.Helper()
+	q, err := Parse(query)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", query, err)
+	}
+	hits, err := ix.Search(q, nil, nil)
+	if err != nil {
+		t.Fatalf("Search(%q) error: %v", query, err)
+	}
+	return hits
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("The Habit's power: 2 RULES for Änderung!")
+	want := []string{"habit", "power", "2", "rules", "änderung"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize() = %q, want %q", got, want)
+	}
+}
+
+func TestIndexRequiresAllTerms(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "habit formation takes time"},
+		{FieldText: "formation of a new habit"},
+		{FieldText: "habit stacking"},
+	}, highlightWeights)
+
+	hits := mustSearch(t, ix, "habit formation")
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %+v", hits)
+	}
+	for _, h := range hits {
+		if h.Doc == 2 {
+			t.Errorf("document without all terms matched: %+v", hits)
+		}
+	}
+
+	if hits := mustSearch(t, ix, "habit unknown"); len(hits) != 0 {
+		t.Errorf("expected no hits for unknown term, got %+v", hits)
+	}
+	if hits := mustSearch(t, ix, "the of"); len(hits) != 0 {
+		t.Errorf("expected no hits for stop words only, got %+v", hits)
+	}
+}
+
+func TestIndexBM25Ranking(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "a long highlight that mentions focus once among many other words"},
+		{FieldText: "focus"},
+		{FieldText: "focus focus and deep focus"},
+	}, highlightWeights)
+
+	hits := mustSearch(t, ix, "focus")
+	if len(hits) != 3 {
+		t.Fatalf("expected 3 hits, got %+v", hits)
+	}
+	if hits[2].Doc != 0 {
+		t.Errorf("long document should rank last, got order %+v", hits)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i-1].Score < hits[i].Score {
+			t.Errorf("hits not sorted by score: %+v", hits)
+		}
+	}
+}
+
+func TestIndexRareTermsWeighMore(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "common rare"},
+		{FieldText: "common words"},
+		{FieldText: "common phrases"},
+		{FieldText: "common again"},
+	}, highlightWeights)
+
+	common := mustSearch(t, ix, "common")
+	rare := mustSearch(t, ix, "rare")
+	if rare[0].Score <= common[0].Score {
+		t.Errorf("rare term score %f should exceed common term score %f", rare[0].Score, common[0].Score)
+	}
+}
+
+func TestIndexFieldWeights(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "other words", FieldTitle: "stoicism"},
+		{FieldText: "stoicism", FieldTitle: "other words"},
+	}, highlightWeights)
+
+	hits := mustSearch(t, ix, "stoicism")
+	if len(hits) != 2 || hits[0].Doc != 1 {
+		t.Errorf("text match should outrank title match, got %+v", hits)
+	}
+}
+
+func TestHighlightIndexSearch(t *testing.T) {
+	sources := []types.ExportSource{
+		{UserBookID: 1, Title: "Atomic Habits", Author: "James Clear", Highlights: []types.Highlight{
+			{ID: 10, Text: "You do not rise to the level of your goals"},
+			{ID: 11, Text: "Every action is a vote", Note: "identity habits"},
+		}},
+		{UserBookID: 2, Title: "Deep Work", Author: "Cal Newport", Highlights: []types.Highlight{
+			{ID: 20, Text: "Habits of deep focus"},
+		}},
+	}
+	ix := NewHighlightIndex(sources)
+	if ix.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", ix.Len())
+	}
+
+	hits, _ := ix.Search("habits", HighlightOptions{})
+	if len(hits) != 3 {
+		t.Fatalf("expected 3 hits (title, note and text), got %d", len(hits))
+	}
+
+	hits, _ = ix.Search("clear goals", HighlightOptions{})
+	if len(hits) != 1 || hits[0].Highlight.ID != 10 || hits[0].Source.Title != "Atomic Habits" {
+		t.Errorf("author and text terms should match highlight 10, got %+v", hits)
+	}
+
+	hits, _ = ix.Search("habits", HighlightOptions{SourceID: 2})
+	if len(hits) != 1 || hits[0].Highlight.ID != 20 {
+		t.Errorf("source filter failed: %+v", hits)
+	}
+
+	hits, _ = ix.Search("habits", HighlightOptions{Limit: 1})
+	if len(hits) != 1 {
+		t.Errorf("limit not applied: got %d hits", len(hits))
+	}
+}
+
+func TestIndexMatchesWordForms(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "negotiating a salary"},
+		{FieldText: "the negotiation failed"},
+		{FieldText: "salary bands"},
+	}, highlightWeights)
+
+	hits := mustSearch(t, ix, "negotiation")
+	if len(hits) != 2 {
+		t.Fatalf("expected both word forms to match, got %+v", hits)
+	}
+	if hits[0].Doc != 1 {
+		t.Errorf("exact word form should rank first, got %+v", hits)
+	}
+
+	if hits := mustSearch(t, ix, `"negotiate salary"`); len(hits) != 1 || hits[0].Doc != 0 {
+		t.Errorf("phrase should match other word forms, got %+v", hits)
+	}
+}
+
+func TestIndexFuzzy(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "deliberate practice", FieldAuthor: "Cal Newport"},
+		{FieldText: "deliberately slow", FieldAuthor: "Anders Ericsson"},
+		{FieldText: "delibrate practise", FieldAuthor: "Someone Else"},
+	}, highlightWeights)
+
+	search := func(query string, fuzzy bool) []Hit {
+		t.Helper()
+		q, err := Parse(query)
+		if err != nil {
+			t.Fatalf("Parse(%q) error: %v", query, err)
+		}
+		q.Fuzzy = fuzzy
+		hits, err := ix.Search(q, nil, nil)
+		if err != nil {
+			t.Fatalf("Search(%q) error: %v", query, err)
+		}
+		return hits
+	}
+
+	if hits := search("delibrate", false); len(hits) != 1 || hits[0].Doc != 2 {
+		t.Errorf("without fuzzy only the exact spelling should match, got %+v", hits)
+	}
+
+	hits := search("delibrate", true)
+	if len(hits) != 2 {
+		t.Fatalf("expected the typo to match documents 0 and 2, got %+v", hits)
+	}
+	if hits[0].Doc != 2 {
+		t.Errorf("exact match should outrank fuzzy matches, got %+v", hits)
+	}
+
+	if hits := search("author:newprot", true); len(hits) != 1 || hits[0].Doc != 0 {
+		t.Errorf("fuzzy author filter failed, got %+v", hits)
+	}
+	if hits := search("author:newprot", false); len(hits) != 0 {
+		t.Errorf("author filter should be exact without fuzzy, got %+v", hits)
+	}
+	if hits := search("cat", true); len(hits) != 0 {
+		t.Errorf("short terms must not match fuzzily, got %+v", hits)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"kitten", "sitting", 3, 3},
+		{"focus", "focus", 1, 0},
+		{"focus", "fcous", 2, 1},
+		{"newport", "newprot", 1, 1},
+		{"focus", "locust", 1, 2},
+		{"über", "uber", 1, 1},
+		{"habit", "habitual", 2, 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance([]rune(tt.a), tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentContentIndex(t *testing.T) {
+	docs := []types.Document{
+		{ID: "a", Title: "Negotiation"},
+		{ID: "b", Title: "Cooking"},
+		{ID: "c", Title: "Gardening"},
+	}
+	content := map[string]string{
+		"b": "Start every negotiation by listening to the other side.",
+		"c": "Water the tomatoes in the morning.",
+	}
+
+	if hits, _ := NewDocumentIndex(docs).Search("listening", DocumentOptions{}); len(hits) != 0 {
+		t.Errorf("index without content matched body text: %d hits", len(hits))
+	}
+
+	hits, err := NewDocumentContentIndex(docs, content).Search("negotiation", DocumentOptions{})
+	if err != nil {
+		t.Fatalf("Search() error: %v", err)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(hits))
+	}
+	if hits[0].Document.ID != "a" {
+		t.Errorf("title match should rank above body match, got %q first", hits[0].Document.ID)
+	}
+	want := []Snippet{{Field: "content", Text: "Start every **negotiation** by listening to the other side."}}
+	if !reflect.DeepEqual(hits[1].Snippets, want) {
+		t.Errorf("Snippets = %v, want %v", hits[1].Snippets, want)
+	}
+}
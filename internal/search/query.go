@@ -0,0 +1,352 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query syntax:
+//
+//	habit formation          both words, in any field and order
+//	"deep work"              the words next to each other
+//	focus OR attention       either word
+//	NOT email, -email        exclude matches
+//	(a OR b) c               grouping; AND binds tighter than OR
+//	author:newport           field filter; quote values with spaces: title:"deep work"
+//
+// Operators must be upper case; lower case "and", "or" and "not" are stop
+// words. Field filters are listed in filterKeys.
+
+// filterKeys are the recognized field filter names. A word with any other
+// prefix before a colon, such as "re:invent", is searched as text.
+var filterKeys = map[string]bool{
+	"author": true, "title": true, "tag": true, "color": true,
+	"category": true, "location": true, "before": true, "after": true,
+	"is": true,
+}
+
+// ParseError reports an invalid query. Pos is the 1-based character
+// position of the problem in the query.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// Filter is a field filter such as author:clear in a query.
+type Filter struct {
+	Key   string
+	Value string
+	// Pos is the 1-based character position of the filter in the query.
+	Pos int
+}
+
+// errorf returns a ParseError pointing at the filter's value.
+func (f Filter) errorf(format string, args ...any) error {
+	return &ParseError{Pos: f.Pos + utf8.RuneCountInString(f.Key) + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Query is a parsed search query.
+type Query struct {
+	root node
+	// Fuzzy lets terms also match words a few typos away.
+	Fuzzy bool
+}
+
+// Parse parses a query. It returns a *ParseError for invalid syntax.
+func Parse(query string) (*Query, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &ParseError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return &Query{root: root}, nil
+}
+
+// Terms returns the search terms of the query outside NOT clauses, in query
+// order. Filter values are not included.
+func (q *Query) Terms() []string {
+	var terms []string
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case *termNode:
+			terms = append(terms, n.term)
+		case *phraseNode:
+			terms = append(terms, n.terms...)
+		case *andNode:
+			for _, c := range n.children {
+				walk(c)
+			}
+		case *orNode:
+			for _, c := range n.children {
+				walk(c)
+			}
+		}
+	}
+	walk(q.root)
+	return uniqueTerms(terms)
+}
+
+// node is an element of the query syntax tree.
+type node interface{ isNode() }
+
+type termNode struct{ term string }
+
+type phraseNode struct{ terms []string }
+
+type filterNode struct{ filter Filter }
+
+type andNode struct{ children []node }
+
+type orNode struct{ children []node }
+
+type notNode struct{ child node }
+
+// emptyNode stands for words consisting only of stop words. It constrains
+// nothing inside AND and OR, and matches nothing on its own.
+type emptyNode struct{}
+
+func (*termNode) isNode()   {}
+func (*phraseNode) isNode() {}
+func (*filterNode) isNode() {}
+func (*andNode) isNode()    {}
+func (*orNode) isNode()     {}
+func (*notNode) isNode()    {}
+func (*emptyNode) isNode()  {}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokPhrase
+	tokFilter
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	// filter is set for tokFilter.
+	filter Filter
+}
+
+// lex splits a query into tokens. Positions are 1-based rune offsets.
+func lex(query string) ([]token, error) {
+	runes := []rune(query)
+	var tokens []token
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i + 1})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')' &&
+			(i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '('):
+			tokens = append(tokens, token{kind: tokNot, text: "-", pos: i + 1})
+			i++
+		case r == '"':
+			text, end, err := lexQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokPhrase, text: text, pos: i + 1})
+			i = end
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			word := string(runes[start:i])
+
+			if key, value, ok := strings.Cut(word, ":"); ok && filterKeys[strings.ToLower(key)] {
+				f := Filter{Key: strings.ToLower(key), Value: value, Pos: start + 1}
+				if value == "" && i < len(runes) && runes[i] == '"' {
+					text, end, err := lexQuoted(runes, i)
+					if err != nil {
+						return nil, err
+					}
+					f.Value = text
+					i = end
+				}
+				if strings.TrimSpace(f.Value) == "" {
+					return nil, &ParseError{Pos: start + 1, Msg: fmt.Sprintf("missing value for %s:", f.Key)}
+				}
+				tokens = append(tokens, token{kind: tokFilter, text: string(runes[start:i]), pos: start + 1, filter: f})
+				continue
+			}
+
+			kind := tokWord
+			switch word {
+			case "AND":
+				kind = tokAnd
+			case "OR":
+				kind = tokOr
+			case "NOT":
+				kind = tokNot
+			}
+			tokens = append(tokens, token{kind: kind, text: word, pos: start + 1})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "end of query", pos: len(runes) + 1}), nil
+}
+
+// lexQuoted reads the quoted string starting at runes[start] and returns its
+// content and the index after the closing quote.
+func lexQuoted(runes []rune, start int) (string, int, error) {
+	for i := start + 1; i < len(runes); i++ {
+		if runes[i] == '"' {
+			return string(runes[start+1 : i]), i + 1, nil
+		}
+	}
+	return "", 0, &ParseError{Pos: start + 1, Msg: "unterminated quote"}
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *parser) parseOr() (node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []node{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &orNode{children: children}, nil
+}
+
+// parseAnd parses: unary (["AND"] unary)*
+func (p *parser) parseAnd() (node, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	children := []node{first}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokPhrase, tokFilter, tokNot, tokLParen:
+		default:
+			if len(children) == 1 {
+				return first, nil
+			}
+			return &andNode{children: children}, nil
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, n)
+	}
+}
+
+// parseUnary parses: ("NOT" | "-") unary | primary
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses: "(" or ")" | phrase | filter | word
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &ParseError{Pos: t.pos, Msg: "unclosed parenthesis"}
+		}
+		return n, nil
+	case tokPhrase, tokWord:
+		return textNode(Tokenize(t.text)), nil
+	case tokFilter:
+		return &filterNode{filter: t.filter}, nil
+	case tokEOF:
+		return nil, &ParseError{Pos: t.pos, Msg: "unexpected end of query, expected a search term"}
+	default:
+		return nil, &ParseError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q, expected a search term", t.text)}
+	}
+}
+
+// textNode builds the node for a word or quoted phrase. A word the
+// tokenizer splits, such as "habit-forming", must match as a phrase.
+func textNode(terms []string) node {
+	switch len(terms) {
+	case 0:
+		return &emptyNode{}
+	case 1:
+		return &termNode{term: terms[0]}
+	default:
+		return &phraseNode{terms: terms}
+	}
+}
+
+// parseDate parses the value of a before: or after: filter.
+func parseDate(f Filter) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, f.Value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, f.errorf("invalid date %q for %s:, use YYYY-MM-DD", f.Value, f.Key)
+}
@@ -0,0 +1,177 @@
+package search
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{`"deep work`, 1},
+		{`habit (focus OR`, 16},
+		{`(habit focus`, 1},
+		{`habit)`, 6},
+		{`habit AND`, 10},
+		{`OR habit`, 1},
+		{`focus author:`, 7},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.query)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Parse(%q) error = %v, want *ParseError", tt.query, err)
+			continue
+		}
+		if parseErr.Pos != tt.pos {
+			t.Errorf("Parse(%q) position = %d, want %d (%v)", tt.query, parseErr.Pos, tt.pos, err)
+		}
+	}
+}
+
+func TestFilterValueErrors(t *testing.T) {
+	ix := NewHighlightIndex(nil)
+	tests := []struct {
+		query string
+		pos   int
+	}{
+		{`focus after:yesterday`, 13},
+		{`is:read`, 4},
+		{`habit location:later`, 7},
+	}
+
+	for _, tt := range tests {
+		_, err := ix.Search(tt.query, HighlightOptions{})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Search(%q) error = %v, want *ParseError", tt.query, err)
+			continue
+		}
+		if parseErr.Pos != tt.pos {
+			t.Errorf("Search(%q) position = %d, want %d (%v)", tt.query, parseErr.Pos, tt.pos, err)
+		}
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	q, err := Parse(`"deep work" (focus OR attention) NOT email author:newport`)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	want := []string{"deep", "work", "focus", "attention"}
+	if got := q.Terms(); !slices.Equal(got, want) {
+		t.Errorf("Terms() = %q, want %q", got, want)
+	}
+}
+
+func highlightIDs(t *testing.T, ix *HighlightIndex, query string) []int64 {
+	t.Helper()
+	hits, err := ix.Search(query, HighlightOptions{})
+	if err != nil {
+		t.Fatalf("Search(%q) error: %v", query, err)
+	}
+	var ids []int64
+	for _, h := range hits {
+		ids = append(ids, h.Highlight.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestHighlightQueries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	ix := NewHighlightIndex([]types.ExportSource{
+		{UserBookID: 1, Title: "The Craft", Author: "Cal Newport", Category: "books", Highlights: []types.Highlight{
+			{ID: 1, Text: "Deep work is rare and valuable", HighlightedAt: day(1), Color: "yellow"},
+			{ID: 2, Text: "Work deep into the night", HighlightedAt: day(5), IsFavorite: true},
+			{ID: 3, Text: "Shallow email work", HighlightedAt: day(10), Tags: []types.Tag{{Name: "Focus"}}},
+		}},
+		{UserBookID: 2, Title: "Indistractable", Author: "Nir Eyal", Category: "articles", Highlights: []types.Highlight{
+			{ID: 4, Text: "Attention is the new currency", HighlightedAt: day(15), Color: "blue"},
+		}},
+	})
+
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{`deep work`, []int64{1, 2}},
+		{`"deep work"`, []int64{1}},
+		{`email OR attention`, []int64{3, 4}},
+		{`work NOT email`, []int64{1, 2}},
+		{`work -email`, []int64{1, 2}},
+		{`(email OR night) work`, []int64{2, 3}},
+		{`author:newport`, []int64{1, 2, 3}},
+		{`author:"nir eyal"`, []int64{4}},
+		{`title:craft night`, []int64{2}},
+		{`tag:focus`, []int64{3}},
+		{`color:BLUE`, []int64{4}},
+		{`category:articles`, []int64{4}},
+		{`before:2024-03-05`, []int64{1}},
+		{`after:2024-03-05 before:2024-03-11`, []int64{2, 3}},
+		{`is:favorite`, []int64{2}},
+		{`NOT author:newport`, []int64{4}},
+		{`the`, nil},
+	}
+
+	for _, tt := range tests {
+		if got := highlightIDs(t, ix, tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"location:later", "is:archived", "title:the"} {
+		if _, err := ix.Search(query, HighlightOptions{}); err == nil {
+			t.Errorf("Search(%q) expected an error", query)
+		}
+	}
+}
+
+func TestDocumentQueries(t *testing.T) {
+	ix := NewDocumentIndex([]types.Document{
+		{ID: "a", Title: "Go Programming", Author: "Rob Pike", Location: "later", Category: "article",
+			SavedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Tags: map[string]types.Tag{"golang": {Name: "golang"}}},
+		{ID: "b", Title: "Rust Programming", Author: "Mozilla", Location: "archive", Category: "pdf",
+			SavedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "c", Title: "Cooking", Summary: "Recipes for programming minds", Location: "new", Category: "article"},
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{`programming`, []string{"a", "b", "c"}},
+		{`programming location:later`, []string{"a"}},
+		{`programming NOT category:pdf`, []string{"a", "c"}},
+		{`tag:golang`, []string{"a"}},
+		{`after:2024-03-01`, []string{"b"}},
+		{`author:pike OR author:mozilla`, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		hits, err := ix.Search(tt.query, DocumentOptions{})
+		if err != nil {
+			t.Fatalf("Search(%q) error: %v", tt.query, err)
+		}
+		var got []string
+		for _, h := range hits {
+			got = append(got, h.Document.ID)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"color:yellow", "is:favorite"} {
+		if _, err := ix.Search(query, DocumentOptions{}); err == nil {
+			t.Errorf("Search(%q) expected an error for a highlight-only filter", query)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// Similar returns the documents most similar to doc, best match first,
+// leaving out doc itself and documents rejected by accept (which may be nil).
+// Documents are compared as term vectors: each word stem is weighted by its
+// field-weighted frequency, dampened logarithmically, times its inverse
+// document frequency, and documents are ranked by the cosine of the angle
+// between their vectors. Only documents sharing at least one stem with doc
+// are returned.
+func (ix *Index) Similar(doc int, accept FilterFunc) []Hit {
+	if doc < 0 || doc >= len(ix.docs) {
+		return nil
+	}
+	norms := ix.vectorNorms()
+	if norms[doc] == 0 {
+		return nil
+	}
+
+	stems := make(map[string]bool)
+	for f, text := range ix.docs[doc] {
+		if ix.weights[f] == 0 {
+			continue
+		}
+		for _, term := range Tokenize(text) {
+			stems[Stem(term)] = true
+		}
+	}
+
+	dots := make(map[int]float64)
+	for stem := range stems {
+		freqs := ix.stemFreqs(stem)
+		idf := ix.idf(len(freqs))
+		qw := vectorWeight(freqs[doc], idf)
+		for other, tf := range freqs {
+			if other == doc || (accept != nil && !accept(other)) {
+				continue
+			}
+			dots[other] += qw * vectorWeight(tf, idf)
+		}
+	}
+
+	hits := make([]Hit, 0, len(dots))
+	for other, dot := range dots {
+		if dot > 0 {
+			hits = append(hits, Hit{Doc: other, Score: dot / (norms[doc] * norms[other])})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Doc < hits[j].Doc
+	})
+	return hits
+}
+
+// vectorNorms returns the length of each document's term vector, computing
+// them on the first call.
+func (ix *Index) vectorNorms() []float64 {
+	ix.normsOnce.Do(func() {
+		ix.norms = make([]float64, len(ix.docs))
+		for stem := range ix.stems {
+			freqs := ix.stemFreqs(stem)
+			idf := ix.idf(len(freqs))
+			for doc, tf := range freqs {
+				w := vectorWeight(tf, idf)
+				ix.norms[doc] += w * w
+			}
+		}
+		for i, n := range ix.norms {
+			ix.norms[i] = math.Sqrt(n)
+		}
+	})
+	return ix.norms
+}
+
+// stemFreqs returns the field-weighted frequency of the terms sharing stem in
+// each document containing one of them.
+func (ix *Index) stemFreqs(stem string) map[int]float64 {
+	freqs := make(map[int]float64)
+	for _, term := range ix.stems[stem] {
+		for _, p := range ix.postings[term] {
+			for f, freq := range p.freq {
+				freqs[p.doc] += ix.weights[f] * float64(freq)
+			}
+		}
+	}
+	return freqs
+}
+
+// vectorWeight is the weight of a stem in a term vector given its weighted
+// frequency tf in the document and its inverse document frequency.
+func vectorWeight(tf, idf float64) float64 {
+	if tf == 0 {
+		return 0
+	}
+	return math.Log1p(tf) * idf
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func TestIndexSimilar(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "deliberate practice builds expertise"},
+		{FieldText: "expertise grows through deliberate practicing"},
+		{FieldText: "practice makes perfect"},
+		{FieldText: "sourdough bread recipe"},
+	}, Weights{FieldText: 1})
+
+	hits := ix.Similar(0, nil)
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2: %+v", len(hits), hits)
+	}
+	if hits[0].Doc != 1 || hits[1].Doc != 2 {
+		t.Errorf("order = %d, %d, want 1, 2", hits[0].Doc, hits[1].Doc)
+	}
+	if hits[0].Score <= hits[1].Score || hits[0].Score > 1 {
+		t.Errorf("scores = %v, %v, want descending cosines", hits[0].Score, hits[1].Score)
+	}
+
+	if hits := ix.Similar(0, func(doc int) bool { return doc != 1 }); len(hits) != 1 || hits[0].Doc != 2 {
+		t.Errorf("accept should exclude doc 1, got %+v", hits)
+	}
+	if hits := ix.Similar(7, nil); hits != nil {
+		t.Errorf("out of range doc returned %+v", hits)
+	}
+}
+
+func TestIndexSimilarConcurrentFirstCalls(t *testing.T) {
+	ix := NewIndex([]Document{
+		{FieldText: "deliberate practice builds expertise"},
+		{FieldText: "expertise grows through deliberate practicing"},
+	}, Weights{FieldText: 1})
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if hits := ix.Similar(0, nil); len(hits) != 1 {
+				t.Errorf("got %d hits, want 1", len(hits))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestHighlightIndexRelated(t *testing.T) {
+	hx := NewHighlightIndex([]types.ExportSource{
+		{UserBookID: 1, Title: "Peak", Highlights: []types.Highlight{
+			{ID: 1, Text: "Deliberate practice requires focused attention"},
+			{ID: 2, Text: "Deliberate practice needs feedback"},
+		}},
+		{UserBookID: 2, Title: "Deep Work", Highlights: []types.Highlight{
+			{ID: 3, Text: "Focused attention on deliberate practice"},
+			{ID: 4, Text: "Email is shallow"},
+		}},
+	})
+
+	hits, ok := hx.Related(1, RelatedOptions{})
+	if !ok {
+		t.Fatal("highlight 1 not found")
+	}
+	if len(hits) != 1 || hits[0].Highlight.ID != 3 || hits[0].Source.UserBookID != 2 {
+		t.Errorf("related = %+v, want only highlight 3", hits)
+	}
+
+	hits, _ = hx.Related(1, RelatedOptions{IncludeSameSource: true})
+	if len(hits) != 2 || hits[0].Highlight.ID != 3 || hits[1].Highlight.ID != 2 {
+		t.Errorf("related with same source = %+v, want highlights 3 and 2", hits)
+	}
+
+	if _, ok := hx.Related(99, RelatedOptions{}); ok {
+		t.Error("unknown highlight should not be found")
+	}
+}
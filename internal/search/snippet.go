@@ -0,0 +1,139 @@
+package search
+
+import (
+	"slices"
+	"strings"
+)
+
+// Snippet sizes: words of context on each side of a match and the number of
+// excerpts taken from one field.
+const (
+	snippetContext   = 6
+	snippetsPerField = 2
+)
+
+// snippetFields lists the fields snippets are taken from, in output order.
+// Author names are left out since they rarely explain a match.
+var snippetFields = []Field{FieldText, FieldNote, FieldTitle, FieldSummary, FieldContent}
+
+var fieldNames = [NumFields]string{
+	FieldText:    "text",
+	FieldNote:    "note",
+	FieldTitle:   "title",
+	FieldAuthor:  "author",
+	FieldSummary: "summary",
+	FieldContent: "content",
+}
+
+// String returns the lower case name of the field, such as "text".
+func (f Field) String() string {
+	return fieldNames[f]
+}
+
+// Snippet is a short excerpt of a field around words matching a query.
+// Matched words are wrapped in ** as in Markdown bold, and an ellipsis marks
+// text left out at either end.
+type Snippet struct {
+	Field string `json:"field"`
+	Text  string `json:"text"`
+}
+
+// wordSpan is the byte range of a word in a text and its lowercase form.
+type wordSpan struct {
+	start, end int
+	term       string
+}
+
+// Snippets returns excerpts of doc around the words matching the terms of q,
+// matched the same way Search matches them.
+func (ix *Index) Snippets(doc int, q *Query) []Snippet {
+	terms := q.Terms()
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var snippets []Snippet
+	for _, f := range snippetFields {
+		text := ix.docs[doc][f]
+		if ix.weights[f] == 0 || text == "" {
+			continue
+		}
+		for _, s := range fieldSnippets(text, terms, q.Fuzzy) {
+			snippets = append(snippets, Snippet{Field: f.String(), Text: s})
+		}
+	}
+	return snippets
+}
+
+// fieldSnippets returns up to snippetsPerField excerpts of text around words
+// matching terms. Excerpts whose context overlaps are merged.
+func fieldSnippets(text string, terms []string, fuzzy bool) []string {
+	words := wordSpans(text)
+	matched := make([]bool, len(words))
+	type window struct{ first, last int }
+	var windows []window
+	for i, w := range words {
+		if stopWords[w.term] || !slices.ContainsFunc(terms, func(t string) bool { return sameWord(w.term, t, fuzzy) }) {
+			continue
+		}
+		matched[i] = true
+		first, last := max(0, i-snippetContext), min(len(words)-1, i+snippetContext)
+		if n := len(windows); n > 0 && first <= windows[n-1].last+1 {
+			windows[n-1].last = last
+			continue
+		}
+		if len(windows) == snippetsPerField {
+			break
+		}
+		windows = append(windows, window{first, last})
+	}
+
+	snippets := make([]string, len(windows))
+	for i, w := range windows {
+		var b strings.Builder
+		pos := words[w.first].start
+		if w.first == 0 {
+			pos = 0
+		} else {
+			b.WriteString("…")
+		}
+		for j := w.first; j <= w.last; j++ {
+			b.WriteString(text[pos:words[j].start])
+			if matched[j] {
+				b.WriteString("**" + text[words[j].start:words[j].end] + "**")
+			} else {
+				b.WriteString(text[words[j].start:words[j].end])
+			}
+			pos = words[j].end
+		}
+		if w.last == len(words)-1 {
+			b.WriteString(text[pos:])
+		} else {
+			b.WriteString("…")
+		}
+		snippets[i] = strings.Join(strings.Fields(b.String()), " ")
+	}
+	return snippets
+}
+
+// wordSpans splits text into words the same way Tokenize does, keeping stop
+// words and the position of each word.
+func wordSpans(text string) []wordSpan {
+	var spans []wordSpan
+	start := -1
+	for i, r := range text {
+		switch {
+		case !isSeparator(r):
+			if start < 0 {
+				start = i
+			}
+		case start >= 0:
+			spans = append(spans, wordSpan{start: start, end: i, term: strings.ToLower(text[start:i])})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{start: start, end: len(text), term: strings.ToLower(text[start:])})
+	}
+	return spans
+}
@@ -0,0 +1,55 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func snippetsFor(t *testing.T, doc Document, query string, fuzzy bool) []Snippet {
+	t.Helper()
+	q, err := Parse(query)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", query, err)
+	}
+	q.Fuzzy = fuzzy
+	return NewIndex([]Document{doc}, highlightWeights).Snippets(0, q)
+}
+
+func TestSnippets(t *testing.T) {
+	doc := Document{
+		FieldText:   "In the first chapter the author explains why habits compound over time through daily improvements nobody notices at first, and in the last chapter how small habits shape identity.",
+		FieldNote:   "Compare with the habit loop",
+		FieldTitle:  "Atomic Habits",
+		FieldAuthor: "James Clear",
+	}
+
+	got := snippetsFor(t, doc, "habits", false)
+	want := []Snippet{
+		{Field: "text", Text: "…first chapter the author explains why **habits** compound over time through daily improvements…"},
+		{Field: "text", Text: "…in the last chapter how small **habits** shape identity."},
+		{Field: "note", Text: "Compare with the **habit** loop"},
+		{Field: "title", Text: "Atomic **Habits**"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Snippets() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSnippetsMergeNearbyMatches(t *testing.T) {
+	got := snippetsFor(t, Document{FieldText: "Deep work:\nprofessional activity performed in a state of distraction-free concentration"}, `"deep work" concentration -email`, false)
+	want := []Snippet{{Field: "text", Text: "**Deep** **work**: professional activity performed in a state of distraction-free **concentration**"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Snippets() = %q, want %q", got, want)
+	}
+}
+
+func TestSnippetsFuzzy(t *testing.T) {
+	doc := Document{FieldText: "Focus on deliberate practice"}
+	if got := snippetsFor(t, doc, "delibrate", false); got != nil {
+		t.Errorf("Snippets() without fuzzy = %q, want none", got)
+	}
+	got := snippetsFor(t, doc, "delibrate", true)
+	if len(got) != 1 || got[0].Text != "Focus on **deliberate** practice" {
+		t.Errorf("Snippets() with fuzzy = %q", got)
+	}
+}
@@ -0,0 +1,49 @@
+package search
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	// Examples from the description of the Porter algorithm.
+	tests := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"feed":           "feed",
+		"agreed":         "agre",
+		"plastered":      "plaster",
+		"motoring":       "motor",
+		"sing":           "sing",
+		"conflated":      "conflat",
+		"sized":          "size",
+		"hopping":        "hop",
+		"falling":        "fall",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"conditional":    "condit",
+		"digitizer":      "digit",
+		"vietnamization": "vietnam",
+		"decisiveness":   "decis",
+		"hopefulness":    "hope",
+		"sensibiliti":    "sensibl",
+		"triplicate":     "triplic",
+		"electrical":     "electr",
+		"goodness":       "good",
+		"allowance":      "allow",
+		"adjustable":     "adjust",
+		"replacement":    "replac",
+		"adoption":       "adopt",
+		"communism":      "commun",
+		"effective":      "effect",
+		"controll":       "control",
+		"negotiating":    "negoti",
+		"negotiation":    "negoti",
+		"is":             "is",
+		"änderung":       "änderung",
+	}
+	for word, want := range tests {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
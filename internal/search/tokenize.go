@@ -0,0 +1,40 @@
+// Package search implements full-text search over the user's library: a
+// tokenizer, a BM25F-ranked inverted index and a per-user index cache.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are common English words that carry little meaning for ranking.
+// They are dropped from both indexed text and queries.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "he": true, "her": true, "his": true, "i": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "me": true,
+	"my": true, "no": true, "not": true, "of": true, "on": true, "or": true,
+	"our": true, "s": true, "she": true, "so": true, "such": true, "t": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "to": true, "was": true,
+	"we": true, "were": true, "what": true, "when": true, "which": true,
+	"who": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// Tokenize splits text into lowercase terms at every character that is not a
+// letter or digit and drops stop words.
+func Tokenize(text string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(text, isSeparator) {
+		term := strings.ToLower(word)
+		if !stopWords[term] {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -139,3 +139,39 @@ func TestAPIKeyFromContextEmpty(t *testing.T) {
 		t.Errorf("APIKeyFromContext(empty) = %q, want empty", got)
 	}
 }
+
+func TestStaticKeyMiddleware(t *testing.T) {
+	var gotKey string
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		gotKey = APIKeyFromRequest(req.(*mcp.CallToolRequest))
+		return nil, nil
+	}
+	handler := auth.StaticKeyMiddleware("static-key")(next)
+
+	if _, err := handler(context.Background(), "tools/call", &mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+	if gotKey != "static-key" {
+		t.Errorf("APIKeyFromRequest() = %q, want %q", gotKey, "static-key")
+	}
+}
+
+func TestStaticKeyMiddlewareOverridesHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Token client-key")
+	req := &mcp.CallToolRequest{Extra: &mcp.RequestExtra{Header: h}}
+
+	var gotKey string
+	next := func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		gotKey = APIKeyFromRequest(req.(*mcp.CallToolRequest))
+		return nil, nil
+	}
+	auth.StaticKeyMiddleware("static-key")(next)(context.Background(), "tools/call", req)
+
+	if gotKey != "static-key" {
+		t.Errorf("APIKeyFromRequest() = %q, want %q", gotKey, "static-key")
+	}
+	if h.Get("Authorization") != "Token client-key" {
+		t.Error("middleware modified the original request headers")
+	}
+}
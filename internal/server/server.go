@@ -4,25 +4,38 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
 	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/embed"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/metrics"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
 	"github.com/rhuss/readwise-mcp-server/internal/tools"
 	"github.com/rhuss/readwise-mcp-server/internal/types"
 )
 
+// Version is the server version reported to MCP clients and in traces.
+const Version = "1.0.0"
+
 // Server wraps the MCP server and HTTP infrastructure.
 type Server struct {
 	MCPServer  *mcp.Server
 	Config     types.Config
 	Logger     *slog.Logger
+	Metrics    *metrics.Metrics
+	apiClient  *api.Client
 	handler    *mcp.StreamableHTTPHandler
 	mux        *http.ServeMux
 	healthMux  *http.ServeMux
@@ -32,13 +45,32 @@ type Server struct {
 	tlsLn      net.Listener
 }
 
+// NewAPIClient creates a Readwise API client with the retry policy and rate
+// limits of cfg.
+func NewAPIClient(cfg types.Config, logger *slog.Logger) *api.Client {
+	client := api.NewClient()
+	client.SetLogger(logger)
+	client.SetRetryPolicy(api.RetryPolicy{
+		MaxAttempts:   cfg.RetryMaxAttempts,
+		BaseDelay:     time.Duration(cfg.RetryBaseDelayMS) * time.Millisecond,
+		MaxDelay:      time.Duration(cfg.RetryMaxDelayMS) * time.Millisecond,
+		MaxRetryAfter: time.Duration(cfg.RetryAfterMaxSeconds) * time.Second,
+	})
+	client.SetRateLimits(api.RateLimits{
+		V2PerMinute:     cfg.RateLimitV2PerMinute,
+		V2ListPerMinute: cfg.RateLimitV2ListPerMinute,
+		V3PerMinute:     cfg.RateLimitV3PerMinute,
+	})
+	return client
+}
+
 // New creates a new Server with the given configuration.
 // Returns an error if profile resolution fails.
 func New(cfg types.Config, logger *slog.Logger) (*Server, error) {
 	mcpServer := mcp.NewServer(
 		&mcp.Implementation{
 			Name:    "readwise-mcp-server",
-			Version: "1.0.0",
+			Version: Version,
 		},
 		&mcp.ServerOptions{
 			Instructions: "Readwise MCP Server provides access to Readwise and Reader APIs. " +
@@ -51,14 +83,31 @@ func New(cfg types.Config, logger *slog.Logger) (*Server, error) {
 		MCPServer: mcpServer,
 		Config:    cfg,
 		Logger:    logger,
+		Metrics:   metrics.New(),
 	}
+	s.Metrics.RegisterSessions(mcpServer)
 
 	// Register tools based on active profiles
-	apiClient := api.NewClient()
+	apiClient := NewAPIClient(cfg, logger)
+	apiClient.SetObserver(s.Metrics)
+	s.apiClient = apiClient
 	cm := cache.NewManager(cfg.CacheMaxSizeMB, cfg.CacheTTLSeconds, cfg.CacheEnabled)
-	if err := tools.RegisterAllTools(mcpServer, apiClient, cm, cfg.Profiles); err != nil {
+	cm.SetLogger(logger)
+	s.Metrics.RegisterCache(cm)
+	embedder, err := embed.New(cfg.EmbeddingsProvider, cfg.EmbeddingsURL, cfg.EmbeddingsModel, cfg.EmbeddingsAPIKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embeddings configuration: %w", err)
+	}
+	markdown, err := export.LoadMarkdown(cfg.ExportTemplateFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid export template: %w", err)
+	}
+	if err := tools.RegisterAllTools(mcpServer, apiClient, cm, cfg.Profiles, embedder, markdown); err != nil {
 		return nil, fmt.Errorf("failed to resolve profiles: %w", err)
 	}
+	resolved, _ := tools.ResolveProfiles(cfg.Profiles)
+	registered := tools.ToolsForProfiles(resolved)
+	mcpServer.AddReceivingMiddleware(telemetry.Middleware(registered), s.Metrics.Middleware(registered))
 
 	s.handler = mcp.NewStreamableHTTPHandler(
 		func(r *http.Request) *mcp.Server {
@@ -69,16 +118,21 @@ func New(cfg types.Config, logger *slog.Logger) (*Server, error) {
 		},
 	)
 
-	// Full mux serves all endpoints
+	// MCP mux serves the client-facing endpoints; /metrics stays off it so
+	// the public TLS listener never exposes it.
 	s.mux = http.NewServeMux()
 	s.mux.Handle("/mcp", s.handler)
 	s.mux.HandleFunc("/health", s.handleHealth)
 	s.mux.HandleFunc("/ready", s.handleReady)
+	if slices.Contains(resolved, "readwise") {
+		s.mux.HandleFunc("GET /export.ndjson", s.handleExport)
+	}
 
-	// Health-only mux for HTTP listener in TLS mode
+	// Health and metrics mux for HTTP listener in TLS mode
 	s.healthMux = http.NewServeMux()
 	s.healthMux.HandleFunc("/health", s.handleHealth)
 	s.healthMux.HandleFunc("/ready", s.handleReady)
+	s.healthMux.Handle("/metrics", s.Metrics.Handler())
 
 	return s, nil
 }
@@ -94,6 +148,27 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 	return s.listenHTTP(ctx)
 }
 
+// ServeStdio serves MCP over stdin/stdout for a single local client. Tool calls
+// are authenticated with apiKey instead of an Authorization header. It returns
+// when the client disconnects or the context is canceled.
+func (s *Server) ServeStdio(ctx context.Context, apiKey string) error {
+	return s.serveTransport(ctx, &mcp.StdioTransport{}, apiKey)
+}
+
+// serveTransport runs the MCP server on a single transport connection.
+func (s *Server) serveTransport(ctx context.Context, t mcp.Transport, apiKey string) error {
+	s.MCPServer.AddReceivingMiddleware(auth.StaticKeyMiddleware(apiKey))
+
+	s.Logger.Info("starting server on stdio", "profiles", s.Config.Profiles)
+
+	// A closed stdin means the client went away, which ends the session normally.
+	if err := s.MCPServer.Run(ctx, t); err != nil && ctx.Err() == nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	s.Logger.Info("shutting down server")
+	return nil
+}
+
 // listenHTTP starts a single HTTP listener serving all endpoints (non-TLS mode).
 func (s *Server) listenHTTP(ctx context.Context) error {
 	ln, err := net.Listen("tcp", s.Config.Addr())
@@ -102,7 +177,10 @@ func (s *Server) listenHTTP(ctx context.Context) error {
 	}
 	s.httpLn = ln
 
-	s.httpServer = &http.Server{Handler: s.mux}
+	all := http.NewServeMux()
+	all.Handle("/", s.mux)
+	all.Handle("/metrics", s.Metrics.Handler())
+	s.httpServer = &http.Server{Handler: all}
 
 	s.Logger.Info("starting server", "addr", ln.Addr().String(), "profiles", s.Config.Profiles)
 
@@ -257,12 +335,92 @@ func (s *Server) Handler() http.Handler {
 	return s.mux
 }
 
+// HealthHandler returns the probe and metrics handler (for testing).
+func (s *Server) HealthHandler() http.Handler {
+	return s.healthMux
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprint(w, `{"status":"ok"}`)
 }
 
+// handleExport streams the highlight export of the caller's API key as
+// NDJSON, writing and flushing each upstream page as it arrives so memory use
+// does not grow with the library. The unit query parameter selects one line
+// per source (default) or per highlight; updated_after limits the export to
+// recent changes. An error after the first page aborts the connection, so
+// clients see a truncated transfer instead of a complete export.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	apiKey := auth.ExtractAPIKeyFromHeader(r.Header)
+	if apiKey == "" {
+		writeError(w, http.StatusUnauthorized, api.NewAuthError("missing API key: provide your Readwise API key via the Authorization header"))
+		return
+	}
+	nw, err := export.NewNDJSONWriter(w, r.URL.Query().Get("unit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, api.NewValidationError("invalid_unit", err.Error()))
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	started := false
+	sources := 0
+	err = s.apiClient.StreamExport(r.Context(), apiKey, r.URL.Query().Get("updated_after"), func(page *types.CursorResponse[types.ExportSource]) error {
+		if !started {
+			w.Header().Set("Content-Type", export.NDJSONContentType)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if err := nw.Write(page.Results); err != nil {
+			return err
+		}
+		sources += len(page.Results)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		return
+	}
+	if started {
+		s.Logger.Warn("NDJSON export aborted", "error", err, "sources_written", sources)
+		panic(http.ErrAbortHandler)
+	}
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) {
+		apiErr = api.NewInternalError(err.Error())
+	}
+	writeError(w, exportErrorStatus(apiErr), apiErr)
+}
+
+// exportErrorStatus maps an export failure before the first page to an HTTP
+// status.
+func exportErrorStatus(e *api.ErrorResponse) int {
+	switch {
+	case e.Type == "auth_error":
+		return http.StatusUnauthorized
+	case e.Code == "rate_limited":
+		return http.StatusTooManyRequests
+	case e.Type == "api_error":
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError writes e as a JSON error body with the given status.
+func writeError(w http.ResponseWriter, status int, e *api.ErrorResponse) {
+	body, _ := e.JSON()
+	w.Header().Set("Content-Type", "application/json")
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", fmt.Sprint(e.RetryAfter))
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
 func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
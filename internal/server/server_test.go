@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -15,6 +16,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
 	"github.com/rhuss/readwise-mcp-server/internal/types"
 )
 
@@ -191,6 +194,16 @@ func TestTLSListenerStartup(t *testing.T) {
 		t.Error("expected /mcp endpoint on HTTPS listener")
 	}
 
+	// Metrics must not be exposed on the HTTPS listener
+	resp, err = tlsClient.Get(fmt.Sprintf("https://localhost:%d/metrics", s.tlsPort()))
+	if err != nil {
+		t.Fatalf("HTTPS metrics request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("HTTPS metrics status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+
 	// Test HTTP listener (health endpoint)
 	httpURL := fmt.Sprintf("http://localhost:%d/health", s.httpPort())
 	resp, err = http.Get(httpURL)
@@ -213,6 +226,17 @@ func TestTLSListenerStartup(t *testing.T) {
 		t.Errorf("ready status = %d, want %d", resp.StatusCode, http.StatusOK)
 	}
 
+	// Test HTTP listener (metrics endpoint)
+	httpURL = fmt.Sprintf("http://localhost:%d/metrics", s.httpPort())
+	resp, err = http.Get(httpURL)
+	if err != nil {
+		t.Fatalf("HTTP metrics request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("metrics status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
 	// Shutdown
 	cancel()
 	select {
@@ -225,6 +249,25 @@ func TestTLSListenerStartup(t *testing.T) {
 	}
 }
 
+func TestMetricsEndpoint(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+
+	s.HealthHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, name := range []string{"readwise_mcp_active_sessions", "readwise_mcp_cache_size_bytes", "go_goroutines"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("metrics output missing %s", name)
+		}
+	}
+}
+
 func TestCertExpiryWarning(t *testing.T) {
 	// Test with near-expiry cert
 	var buf bytes.Buffer
@@ -354,3 +397,180 @@ func TestNonTLSBackwardCompatibility(t *testing.T) {
 		t.Error("server did not shut down in time")
 	}
 }
+
+func TestServeTransportInjectsAPIKey(t *testing.T) {
+	s := newTestServer(t)
+
+	type echoInput struct{}
+	mcp.AddTool(s.MCPServer, &mcp.Tool{Name: "echo_key"}, func(ctx context.Context, req *mcp.CallToolRequest, _ echoInput) (*mcp.CallToolResult, any, error) {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: APIKeyFromRequest(req)}},
+		}, nil, nil
+	})
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.serveTransport(ctx, serverTransport, "stdio-key")
+	}()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := client.Connect(context.Background(), clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "echo_key"})
+	if err != nil {
+		t.Fatalf("CallTool() error: %v", err)
+	}
+	if got := result.Content[0].(*mcp.TextContent).Text; got != "stdio-key" {
+		t.Errorf("API key = %q, want %q", got, "stdio-key")
+	}
+
+	rec := httptest.NewRecorder()
+	s.HealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// echo_key is added after the profile tools, so metrics count it as unknown.
+	for _, line := range []string{
+		`readwise_mcp_tool_calls_total{outcome="success",tool="unknown"} 1`,
+		`readwise_mcp_active_sessions 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("metrics output missing %q", line)
+		}
+	}
+
+	session.Close()
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("serveTransport() error: %v", err)
+	}
+}
+
+func TestExportNDJSONEndpoint(t *testing.T) {
+	var auths []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		page := types.CursorResponse[types.ExportSource]{
+			Count:          1,
+			NextPageCursor: "next",
+			Results: []types.ExportSource{
+				{UserBookID: 1, Title: "Book", Highlights: []types.Highlight{{ID: 10, Text: "first"}, {ID: 11, Text: "second"}}},
+			},
+		}
+		if r.URL.Query().Get("pageCursor") != "" {
+			page = types.CursorResponse[types.ExportSource]{
+				Count:   1,
+				Results: []types.ExportSource{{UserBookID: 2, Title: "Article", Highlights: []types.Highlight{{ID: 20, Text: "third"}}}},
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	s.apiClient = api.NewClientWithBaseURLs(upstream.URL, upstream.URL)
+
+	req := httptest.NewRequest(http.MethodGet, "/export.ndjson?unit=highlights", nil)
+	req.Header.Set("Authorization", "Token test-key")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), rec.Body.String())
+	}
+	var last map[string]any
+	if err := json.Unmarshal([]byte(lines[2]), &last); err != nil {
+		t.Fatalf("last line is not JSON: %v", err)
+	}
+	if last["id"] != float64(20) || last["source_title"] != "Article" {
+		t.Errorf("unexpected last record: %v", last)
+	}
+	if len(auths) != 2 || auths[0] != "Token test-key" {
+		t.Errorf("upstream Authorization headers = %q", auths)
+	}
+}
+
+func TestExportNDJSONEndpointAbortsOnLaterPageError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pageCursor") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+			Count:          2,
+			NextPageCursor: "next",
+			Results:        []types.ExportSource{{UserBookID: 1, Title: "Book"}},
+		})
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	s.Logger = slog.New(slog.DiscardHandler)
+	s.apiClient = api.NewClientWithBaseURLs(upstream.URL, upstream.URL)
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/export.ndjson", nil)
+	req.Header.Set("Authorization", "Token test-key")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		t.Fatalf("reading body succeeded with %q, want a truncated transfer", body)
+	}
+}
+
+func TestExportNDJSONEndpointErrors(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	s.apiClient = api.NewClientWithBaseURLs(upstream.URL, upstream.URL)
+
+	tests := []struct {
+		name   string
+		url    string
+		auth   string
+		status int
+	}{
+		{"missing key", "/export.ndjson", "", http.StatusUnauthorized},
+		{"invalid unit", "/export.ndjson?unit=books", "Token key", http.StatusBadRequest},
+		{"upstream rejects key", "/export.ndjson", "Token bad-key", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package telemetry sets up OpenTelemetry tracing. Spans are started through
+// the global tracer provider, which stays a no-op until Setup installs an
+// OTLP exporter, so instrumented code costs next to nothing when tracing is
+// disabled.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/rhuss/readwise-mcp-server"
+
+// Setup installs a global tracer provider exporting spans over OTLP/HTTP to
+// endpoint, the collector's base URL (e.g. http://localhost:4318). Spans are
+// sent to its /v1/traces path. The returned function flushes pending spans and
+// must be called on shutdown.
+func Setup(ctx context.Context, endpoint, serviceVersion string, logger *slog.Logger) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName("readwise-mcp-server"),
+		semconv.ServiceVersion(serviceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("tracing error", "error", err)
+	}))
+
+	return tp.Shutdown, nil
+}
+
+// Start starts a span from the global tracer provider.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.GetTracerProvider().Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// unknownTool names calls to tools that are not registered, so clients cannot
+// create a span name per made-up tool name.
+const unknownTool = "unknown"
+
+// Middleware returns MCP middleware that wraps every tool call in a span.
+// Trace context sent by HTTP clients in traceparent headers is continued.
+// Calls to tools other than the given registered ones are named "unknown".
+func Middleware(tools []string) mcp.Middleware {
+	known := make(map[string]bool, len(tools))
+	for _, name := range tools {
+		known[name] = true
+	}
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			call, ok := req.(*mcp.CallToolRequest)
+			if !ok || call.Params == nil {
+				return next(ctx, method, req)
+			}
+
+			if call.Extra != nil && call.Extra.Header != nil {
+				ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(call.Extra.Header))
+			}
+
+			tool := call.Params.Name
+			if !known[tool] {
+				tool = unknownTool
+			}
+			ctx, span := Start(ctx, "tools/call "+tool,
+				attribute.String("mcp.method.name", method),
+				attribute.String("gen_ai.tool.name", tool),
+			)
+			result, err := next(ctx, method, req)
+
+			if r, ok := result.(*mcp.CallToolResult); err == nil && ok && r.IsError {
+				span.SetStatus(codes.Error, "tool returned an error result")
+			}
+			End(span, err)
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider recording finished spans for the
+// duration of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestSetupExportsToCollector(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var bodySize int
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		bodySize += len(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	prev := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(prev)
+
+	shutdown, err := Setup(context.Background(), collector.URL+"/", "test", slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("Setup() error: %v", err)
+	}
+
+	_, span := Start(context.Background(), "test-span")
+	span.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) == 0 {
+		t.Fatal("collector received no export requests")
+	}
+	if paths[0] != "/v1/traces" {
+		t.Errorf("export path = %q, want %q", paths[0], "/v1/traces")
+	}
+	if bodySize == 0 {
+		t.Error("export request had an empty body")
+	}
+}
+
+func TestEndRecordsError(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, span := Start(context.Background(), "failing")
+	End(span, errors.New("boom"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("status = %v, want Error", spans[0].Status().Code)
+	}
+	if len(spans[0].Events()) == 0 {
+		t.Error("expected an exception event")
+	}
+}
+
+func TestMiddlewareSpanPerToolCall(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var childTraceID string
+	handler := Middleware([]string{"list_sources"})(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		_, child := Start(ctx, "child")
+		childTraceID = child.SpanContext().TraceID().String()
+		child.End()
+		return &mcp.CallToolResult{IsError: true}, nil
+	})
+
+	req := &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "list_sources"}}
+	if _, err := handler(context.Background(), "tools/call", req); err != nil {
+		t.Fatalf("handler error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	tool := spans[1]
+	if tool.Name() != "tools/call list_sources" {
+		t.Errorf("span name = %q, want %q", tool.Name(), "tools/call list_sources")
+	}
+	if tool.Status().Code != codes.Error {
+		t.Errorf("status = %v, want Error for an error result", tool.Status().Code)
+	}
+	if spans[0].Parent().SpanID() != tool.SpanContext().SpanID() {
+		t.Error("child span is not parented to the tool span")
+	}
+	if childTraceID != tool.SpanContext().TraceID().String() {
+		t.Error("child span is not in the tool span's trace")
+	}
+}
+
+func TestMiddlewareUnknownToolName(t *testing.T) {
+	recorder := recordSpans(t)
+
+	handler := Middleware([]string{"list_sources"})(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.CallToolResult{}, nil
+	})
+	handler(context.Background(), "tools/call", &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: "made_up_tool"}})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	if got := spans[0].Name(); got != "tools/call unknown" {
+		t.Errorf("span name = %q, want %q", got, "tools/call unknown")
+	}
+}
+
+func TestMiddlewareContinuesRemoteTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(prev)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	h := http.Header{}
+	h.Set("Traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	handler := Middleware([]string{"get_source"})(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.CallToolResult{}, nil
+	})
+	handler(context.Background(), "tools/call", &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Name: "get_source"},
+		Extra:  &mcp.RequestExtra{Header: h},
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("trace ID = %s, want %s", got, traceID)
+	}
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/htmlconv"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// cachedClient serves the list and export endpoints used by read tools from
+// the cache, falling back to the API client on a miss.
+type cachedClient struct {
+	client *api.Client
+	cm     *cache.Manager
+}
+
+func newCachedClient(client *api.Client, cm *cache.Manager) *cachedClient {
+	return &cachedClient{client: client, cm: cm}
+}
+
+// ListBooks returns a page of sources, served from the cache when possible.
+func (c *cachedClient) ListBooks(ctx context.Context, apiKey string, page, pageSize int, category, updatedAfter string) (*types.PageResponse[types.Source], error) {
+	params := map[string]string{}
+	if page > 0 {
+		params["page"] = strconv.Itoa(page)
+	}
+	if pageSize > 0 {
+		params["page_size"] = strconv.Itoa(pageSize)
+	}
+	if category != "" {
+		params["category"] = category
+	}
+	if updatedAfter != "" {
+		params["updated_after"] = updatedAfter
+	}
+
+	return readThrough(ctx, c.cm, apiKey, cache.EndpointBooks, params, func(ctx context.Context) (*types.PageResponse[types.Source], error) {
+		return c.client.ListBooks(ctx, apiKey, page, pageSize, category, updatedAfter)
+	})
+}
+
+// ExportHighlights returns the highlight export. A full export is served from
+// the user's delta-synced snapshot; a filtered export is cached as-is.
+func (c *cachedClient) ExportHighlights(ctx context.Context, apiKey string, updatedAfter string) (*types.CursorResponse[types.ExportSource], error) {
+	if updatedAfter == "" {
+		sources, err := c.cm.SyncExport(ctx, apiKey, func(ctx context.Context, since string) ([]types.ExportSource, error) {
+			result, err := c.client.ExportHighlights(ctx, apiKey, since)
+			if err != nil {
+				return nil, err
+			}
+			return result.Results, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &types.CursorResponse[types.ExportSource]{
+			Count:   len(sources),
+			Results: sources,
+		}, nil
+	}
+
+	params := map[string]string{"updated_after": updatedAfter}
+	return readThrough(ctx, c.cm, apiKey, cache.EndpointExport, params, func(ctx context.Context) (*types.CursorResponse[types.ExportSource], error) {
+		return c.client.ExportHighlights(ctx, apiKey, updatedAfter)
+	})
+}
+
+// ListDocuments returns Reader documents, served from the cache when possible.
+func (c *cachedClient) ListDocuments(ctx context.Context, apiKey string, location, category, updatedAfter string, limit int) (*types.CursorResponse[types.Document], error) {
+	params := map[string]string{}
+	if location != "" {
+		params["location"] = location
+	}
+	if category != "" {
+		params["category"] = category
+	}
+	if updatedAfter != "" {
+		params["updated_after"] = updatedAfter
+	}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	return readThrough(ctx, c.cm, apiKey, cache.EndpointDocuments, params, func(ctx context.Context) (*types.CursorResponse[types.Document], error) {
+		return c.client.ListDocuments(ctx, apiKey, location, category, updatedAfter, limit)
+	})
+}
+
+// DocumentContent returns the plain text of the user's Reader documents keyed
+// by document ID, kept in a delta-synced snapshot. HTML content is converted
+// to text as it is fetched so only the text is cached.
+func (c *cachedClient) DocumentContent(ctx context.Context, apiKey string) (map[string]string, error) {
+	return c.cm.SyncDocumentContent(ctx, apiKey, func(ctx context.Context, since string) (map[string]string, error) {
+		result, err := c.client.ListDocumentContent(ctx, apiKey, since)
+		if err != nil {
+			return nil, err
+		}
+		content := make(map[string]string, len(result.Results))
+		for _, d := range result.Results {
+			content[d.ID] = htmlconv.ToText(d.Content)
+		}
+		return content, nil
+	})
+}
+
+// ListReaderTags returns all Reader tags, served from the cache when possible.
+func (c *cachedClient) ListReaderTags(ctx context.Context, apiKey string) ([]types.Tag, error) {
+	return readThrough(ctx, c.cm, apiKey, cache.EndpointReaderTags, nil, func(ctx context.Context) ([]types.Tag, error) {
+		return c.client.ListReaderTags(ctx, apiKey)
+	})
+}
+
+// readThrough fetches a typed value through the cache, storing it as JSON.
+func readThrough[T any](ctx context.Context, cm *cache.Manager, apiKey, endpoint string, params map[string]string, fetch func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	data, err := cm.Fetch(ctx, apiKey, endpoint, params, func(ctx context.Context) ([]byte, error) {
+		v, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, api.NewInternalError(fmt.Sprintf("failed to decode cached response: %v", err))
+	}
+	return result, nil
+}
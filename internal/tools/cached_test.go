@@ -0,0 +1,199 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func exportTestHandler(calls *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+			Count: 1,
+			Results: []types.ExportSource{
+				{UserBookID: 1, Title: "Book", Highlights: []types.Highlight{{ID: 10, Text: "cached highlight"}}},
+			},
+		})
+	}
+}
+
+func TestExportHighlightsHandlerUsesCache(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(exportTestHandler(&calls))
+	defer ts.Close()
+
+	handler := makeExportHighlightsHandler(newCachedClient(client, cm), nil)
+	for i := 0; i < 3; i++ {
+		result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), ExportHighlightsInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var export types.CursorResponse[types.ExportSource]
+		if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &export); err != nil {
+			t.Fatalf("failed to parse result: %v", err)
+		}
+		if len(export.Results) != 1 || export.Results[0].Highlights[0].ID != 10 {
+			t.Errorf("unexpected export result: %+v", export)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+}
+
+func TestSearchHighlightsSharesExportCache(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(exportTestHandler(&calls))
+	defer ts.Close()
+
+	cc := newCachedClient(client, cm)
+	exportHandler := makeExportHighlightsHandler(cc, nil)
+	searchHandler := makeSearchHighlightsHandler(cc, search.NewIndexCache(1))
+
+	if _, _, err := exportHandler(context.Background(), newReqWithAPIKey("test-key"), ExportHighlightsInput{}); err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := searchHandler(context.Background(), newReqWithAPIKey("test-key"), SearchHighlightsInput{Query: "cached"}); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+}
+
+func TestExportHighlightsHandlerMarkdown(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(exportTestHandler(&calls))
+	defer ts.Close()
+
+	markdown, err := export.NewMarkdown("# {{ .Title }}\n{{ range .Highlights }}> {{ .Text }}\n{{ end }}")
+	if err != nil {
+		t.Fatalf("NewMarkdown() error: %v", err)
+	}
+	handler := makeExportHighlightsHandler(newCachedClient(client, cm), markdown)
+
+	result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), ExportHighlightsInput{Format: "markdown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := result.Content[0].(*mcp.TextContent).Text, "# Book\n> cached highlight\n"; got != want {
+		t.Errorf("markdown = %q, want %q", got, want)
+	}
+
+	_, _, err = handler(context.Background(), newReqWithAPIKey("test-key"), ExportHighlightsInput{Format: "csv"})
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_format" {
+		t.Errorf("expected invalid_format error, got %v", err)
+	}
+}
+
+func TestCachedClientPerUserIsolation(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(exportTestHandler(&calls))
+	defer ts.Close()
+
+	handler := makeExportHighlightsHandler(newCachedClient(client, cm), nil)
+	handler(context.Background(), newReqWithAPIKey("user-1"), ExportHighlightsInput{})
+	handler(context.Background(), newReqWithAPIKey("user-2"), ExportHighlightsInput{})
+
+	if calls != 2 {
+		t.Errorf("upstream called %d times, want 2 (one per user)", calls)
+	}
+}
+
+func TestCachedClientRefetchesAfterInvalidation(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(exportTestHandler(&calls))
+	defer ts.Close()
+
+	handler := makeExportHighlightsHandler(newCachedClient(client, cm), nil)
+	handler(context.Background(), newReqWithAPIKey("test-key"), ExportHighlightsInput{})
+	cm.Invalidate("test-key", "create_highlight")
+	handler(context.Background(), newReqWithAPIKey("test-key"), ExportHighlightsInput{})
+
+	if calls != 2 {
+		t.Errorf("upstream called %d times, want 2 after invalidation", calls)
+	}
+}
+
+func TestListSourcesHandlerCachesPerParams(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(types.PageResponse[types.Source]{Count: 0})
+	})
+	defer ts.Close()
+
+	handler := makeListSourcesHandler(newCachedClient(client, cm))
+	handler(context.Background(), newReqWithAPIKey("test-key"), ListSourcesInput{Page: 1})
+	handler(context.Background(), newReqWithAPIKey("test-key"), ListSourcesInput{Page: 1})
+	handler(context.Background(), newReqWithAPIKey("test-key"), ListSourcesInput{Page: 2})
+
+	if calls != 2 {
+		t.Errorf("upstream called %d times, want 2 (one per distinct page)", calls)
+	}
+}
+
+func TestListReaderTagsHandlerUsesCache(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode([]types.Tag{{Name: "golang"}})
+	})
+	defer ts.Close()
+
+	handler := makeListReaderTagsHandler(newCachedClient(client, cm))
+	handler(context.Background(), newReqWithAPIKey("test-key"), ListReaderTagsInput{})
+	handler(context.Background(), newReqWithAPIKey("test-key"), ListReaderTagsInput{})
+
+	if calls != 1 {
+		t.Errorf("upstream called %d times, want 1", calls)
+	}
+}
+
+func TestExportHighlightsHandlerMergesDelta(t *testing.T) {
+	var queries []string
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("updatedAfter"))
+		results := []types.ExportSource{
+			{UserBookID: 1, Title: "Book", Highlights: []types.Highlight{{ID: 10, Text: "original"}}},
+		}
+		if r.URL.Query().Get("updatedAfter") != "" {
+			results = []types.ExportSource{
+				{UserBookID: 1, Title: "Book", Highlights: []types.Highlight{{ID: 11, Text: "habit formation"}}},
+			}
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{Count: len(results), Results: results})
+	})
+	defer ts.Close()
+
+	cc := newCachedClient(client, cm)
+	makeExportHighlightsHandler(cc, nil)(context.Background(), newReqWithAPIKey("test-key"), ExportHighlightsInput{})
+	cm.Invalidate("test-key", "create_highlight")
+
+	result, _, err := makeSearchHighlightsHandler(cc, search.NewIndexCache(1))(context.Background(), newReqWithAPIKey("test-key"), SearchHighlightsInput{Query: "habit"})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	if len(queries) != 2 || queries[0] != "" || queries[1] == "" {
+		t.Fatalf("upstream updatedAfter values = %q, want full export then delta", queries)
+	}
+
+	var results []SearchHighlightResult
+	json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &results)
+	if len(results) != 1 || results[0].Highlight.ID != 11 {
+		t.Errorf("search did not see merged highlight: %+v", results)
+	}
+}
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// ExportCitationsInput defines the parameters for the export_citations tool.
+type ExportCitationsInput struct {
+	SourceIDs []string `json:"source_ids,omitempty" jsonschema:"Only cite these Readwise source IDs or Reader document IDs"`
+	Tag       string   `json:"tag,omitempty" jsonschema:"Only cite sources and documents with this tag"`
+	Category  string   `json:"category,omitempty" jsonschema:"Only cite this category, e.g. books, articles, podcasts, tweets"`
+	Format    string   `json:"format,omitempty" jsonschema:"Citation format: bibtex (default) or csl-json"`
+}
+
+// RegisterExportCitationsTool registers the export_citations tool. With
+// includeReader set, Reader documents are cited as well; that requires the
+// reader profile.
+func RegisterExportCitationsTool(s *mcp.Server, client *api.Client, cm *cache.Manager, includeReader bool) {
+	description := "Export citations for Readwise sources as BibTeX (biblatex) entries or CSL-JSON items, selected by source IDs, tag or category. Entry types follow the category (books as @book, articles as @online) and citation keys such as newportdeep (author and first title word) do not depend on which sources are selected."
+	if includeReader {
+		description = "Export citations for Readwise sources and Reader documents as BibTeX (biblatex) entries or CSL-JSON items, selected by source or document IDs, tag or category. Entry types follow the category (books as @book, articles as @online) and citation keys such as newportdeep (author and first title word, plus the year for Reader documents, as in newport2016deep) do not depend on which sources or documents are selected. Documents already in Readwise as a source are cited once."
+	}
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "export_citations",
+		Description: description,
+	}, makeExportCitationsHandler(newCachedClient(client, cm), includeReader))
+}
+
+func makeExportCitationsHandler(client *cachedClient, includeReader bool) mcp.ToolHandlerFor[ExportCitationsInput, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ExportCitationsInput) (*mcp.CallToolResult, any, error) {
+		apiKey := auth.APIKeyFromRequest(req)
+		if apiKey == "" {
+			return nil, nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+
+		switch input.Format {
+		case "", export.FormatBibTeX, export.FormatCSLJSON:
+		default:
+			return nil, nil, api.NewValidationError("invalid_format", fmt.Sprintf("format must be bibtex or csl-json, got %q", input.Format))
+		}
+
+		exportData, err := client.ExportHighlights(ctx, apiKey, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		var docs []types.Document
+		if includeReader {
+			docData, err := client.ListDocuments(ctx, apiKey, "", "", "", 0)
+			if err != nil {
+				return nil, nil, err
+			}
+			docs = docData.Results
+		}
+
+		refs, err := selectReferences(libraryReferences(exportData.Results, docs), input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var b strings.Builder
+		if err := export.WriteCitations(&b, refs, input.Format); err != nil {
+			return nil, nil, api.NewInternalError(fmt.Sprintf("failed to write citations: %v", err))
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: b.String()}},
+		}, nil, nil
+	}
+}
+
+// libraryReferences returns the references of all sources and top-level
+// documents with citation keys assigned. Sources come first, then documents,
+// each ordered by ID, so keys only change when the library does. A document
+// with the source URL of a source is left out in favor of the source.
+func libraryReferences(sources []types.ExportSource, docs []types.Document) []export.Reference {
+	sources = append([]types.ExportSource(nil), sources...)
+	sort.Slice(sources, func(i, j int) bool { return sources[i].UserBookID < sources[j].UserBookID })
+	docs = append([]types.Document(nil), docs...)
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+
+	refs := make([]export.Reference, 0, len(sources)+len(docs))
+	covered := make(map[string]bool)
+	for i := range sources {
+		refs = append(refs, export.SourceReference(&sources[i]))
+		if url := normalizeURL(sources[i].SourceURL); url != "" {
+			covered[url] = true
+		}
+	}
+	for i := range docs {
+		d := &docs[i]
+		if d.ParentID != "" || covered[normalizeURL(d.SourceURL)] {
+			continue
+		}
+		refs = append(refs, export.DocumentReference(d))
+	}
+	export.AssignCitationKeys(refs)
+	return refs
+}
+
+// selectReferences filters refs by the selectors of input, which must all
+// match. Categories match ignoring case and a plural s, so "book" selects
+// Readwise "books". Requested IDs that are not in the library are an error.
+func selectReferences(refs []export.Reference, input ExportCitationsInput) ([]export.Reference, error) {
+	ids := make(map[string]bool, len(input.SourceIDs))
+	for _, id := range input.SourceIDs {
+		ids[strings.TrimSpace(id)] = true
+	}
+	category := singularCategory(input.Category)
+
+	var selected []export.Reference
+	found := make(map[string]bool)
+	for _, r := range refs {
+		if len(ids) > 0 && !ids[r.ID] {
+			continue
+		}
+		found[r.ID] = true
+		if category != "" && singularCategory(r.Category) != category {
+			continue
+		}
+		if input.Tag != "" && !containsFold(r.Tags, input.Tag) {
+			continue
+		}
+		selected = append(selected, r)
+	}
+
+	var missing []string
+	for _, id := range input.SourceIDs {
+		if id = strings.TrimSpace(id); !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, api.NewAPIError("not_found", fmt.Sprintf("no source or document with ID %s", strings.Join(missing, ", ")))
+	}
+	return selected, nil
+}
+
+func singularCategory(c string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(c)), "s")
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
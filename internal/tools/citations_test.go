@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func citationTestLibrary() ([]types.ExportSource, []types.Document) {
+	sources := []types.ExportSource{
+		{UserBookID: 2, Title: "Deep Work Again", Author: "Cal Newport", Category: "books"},
+		{UserBookID: 1, Title: "Deep Work", Author: "Cal Newport", Category: "books", BookTags: []types.Tag{{Name: "Focus"}}},
+		{UserBookID: 3, Title: "Attention", Author: "Jane Doe", Category: "articles", SourceURL: "https://example.com/attention"},
+	}
+	docs := []types.Document{
+		{ID: "doc-b", Title: "Attention", SourceURL: "https://example.com/attention/", Category: "article"},
+		{ID: "doc-a", Title: "Flow", Author: "Mihaly Csikszentmihalyi", Category: "article", Tags: map[string]types.Tag{"focus": {Name: "focus"}}},
+		{ID: "doc-c", Title: "A highlight", Category: "highlight", ParentID: "doc-a"},
+	}
+	return sources, docs
+}
+
+func TestLibraryReferences(t *testing.T) {
+	refs := libraryReferences(citationTestLibrary())
+
+	var got []string
+	for _, r := range refs {
+		got = append(got, r.ID+"="+r.Key)
+	}
+	want := []string{"1=newportdeep", "2=newportdeepelq", "3=doeattention", "doc-a=csikszentmihalyiflow"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("references = %q, want %q", got, want)
+	}
+}
+
+func TestSelectReferences(t *testing.T) {
+	refs := libraryReferences(citationTestLibrary())
+
+	tests := []struct {
+		name  string
+		input ExportCitationsInput
+		want  []string
+	}{
+		{"all", ExportCitationsInput{}, []string{"1", "2", "3", "doc-a"}},
+		{"ids", ExportCitationsInput{SourceIDs: []string{"2", " doc-a"}}, []string{"2", "doc-a"}},
+		{"tag", ExportCitationsInput{Tag: "focus"}, []string{"1", "doc-a"}},
+		{"category", ExportCitationsInput{Category: "Book"}, []string{"1", "2"}},
+		{"tag and category", ExportCitationsInput{Tag: "focus", Category: "articles"}, []string{"doc-a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := selectReferences(refs, tt.input)
+			if err != nil {
+				t.Fatalf("selectReferences() error: %v", err)
+			}
+			var ids []string
+			for _, r := range selected {
+				ids = append(ids, r.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("selected = %q, want %q", ids, tt.want)
+			}
+		})
+	}
+
+	_, err := selectReferences(refs, ExportCitationsInput{SourceIDs: []string{"1", "42"}})
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) || apiErr.Code != "not_found" || !strings.Contains(apiErr.Message, "42") {
+		t.Errorf("expected not_found error naming 42, got %v", err)
+	}
+}
+
+func TestExportCitationsHandler(t *testing.T) {
+	sources, docs := citationTestLibrary()
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/export/") {
+			json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{Count: len(sources), Results: sources})
+			return
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{Count: len(docs), Results: docs})
+	})
+	defer ts.Close()
+
+	handler := makeExportCitationsHandler(newCachedClient(client, cm), true)
+	result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), ExportCitationsInput{Tag: "focus"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if !strings.HasPrefix(text, "@book{newportdeep,\n") || !strings.Contains(text, "@online{csikszentmihalyiflow,\n") {
+		t.Errorf("unexpected BibTeX:\n%s", text)
+	}
+
+	result, _, err = handler(context.Background(), newReqWithAPIKey("test-key"), ExportCitationsInput{SourceIDs: []string{"3"}, Format: "csl-json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var items []map[string]any
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &items); err != nil {
+		t.Fatalf("failed to parse CSL-JSON: %v", err)
+	}
+	if len(items) != 1 || items[0]["id"] != "doeattention" || items[0]["type"] != "webpage" {
+		t.Errorf("unexpected CSL-JSON items: %v", items)
+	}
+
+	_, _, err = handler(context.Background(), newReqWithAPIKey("test-key"), ExportCitationsInput{Format: "ris"})
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_format" {
+		t.Errorf("expected invalid_format error, got %v", err)
+	}
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, ExportCitationsInput{}); err == nil {
+		t.Error("expected error for missing API key")
+	}
+}
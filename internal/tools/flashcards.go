@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+)
+
+// ExportFlashcardsInput defines the parameters for the export_flashcards tool.
+type ExportFlashcardsInput struct {
+	SourceID string `json:"source_id,omitempty" jsonschema:"Only export highlights of this source ID"`
+	Tag      string `json:"tag,omitempty" jsonschema:"Only export highlights with this tag or from a source with this tag"`
+	After    string `json:"after,omitempty" jsonschema:"Only export highlights made on or after this date (YYYY-MM-DD)"`
+	Before   string `json:"before,omitempty" jsonschema:"Only export highlights made before this date (YYYY-MM-DD)"`
+	Format   string `json:"format,omitempty" jsonschema:"File format: tsv (default) or csv"`
+}
+
+// RegisterExportFlashcardsTool registers the export_flashcards tool.
+func RegisterExportFlashcardsTool(s *mcp.Server, client *api.Client, cm *cache.Manager) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "export_flashcards",
+		Description: "Export highlights as Anki flashcards in an importable TSV or CSV file. All cards use the Basic (front/back) note type: highlights with cloze deletions ({{c1::answer}}) get a front with [...] in place of each deletion and the full text on the back; other highlights with a note use the note as the back; highlights with neither are skipped. Cards are tagged with source title and author and keep a stable GUID per highlight, so re-importing updates existing cards.",
+	}, makeExportFlashcardsHandler(newCachedClient(client, cm)))
+}
+
+func makeExportFlashcardsHandler(client *cachedClient) mcp.ToolHandlerFor[ExportFlashcardsInput, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ExportFlashcardsInput) (*mcp.CallToolResult, any, error) {
+		apiKey := auth.APIKeyFromRequest(req)
+		if apiKey == "" {
+			return nil, nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+
+		filter, err := flashcardFilter(input)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch input.Format {
+		case "", export.FormatTSV, export.FormatCSV:
+		default:
+			return nil, nil, api.NewValidationError("invalid_format", fmt.Sprintf("format must be tsv or csv, got %q", input.Format))
+		}
+
+		exportData, err := client.ExportHighlights(ctx, apiKey, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var b strings.Builder
+		if err := export.WriteFlashcards(&b, export.Flashcards(exportData.Results, filter), input.Format); err != nil {
+			return nil, nil, api.NewInternalError(fmt.Sprintf("failed to write flashcards: %v", err))
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: b.String()}},
+		}, nil, nil
+	}
+}
+
+// flashcardFilter validates the filter parameters of export_flashcards.
+func flashcardFilter(input ExportFlashcardsInput) (export.FlashcardFilter, error) {
+	filter := export.FlashcardFilter{Tag: input.Tag}
+	if input.SourceID != "" {
+		id, err := strconv.ParseInt(input.SourceID, 10, 64)
+		if err != nil {
+			return filter, api.NewValidationError("invalid_source_id", fmt.Sprintf("source_id must be numeric, got %q", input.SourceID))
+		}
+		filter.SourceID = id
+	}
+	var err error
+	if filter.After, err = export.ParseDate(input.After); err != nil {
+		return filter, api.NewValidationError("invalid_date", err.Error())
+	}
+	if filter.Before, err = export.ParseDate(input.Before); err != nil {
+		return filter, api.NewValidationError("invalid_date", err.Error())
+	}
+	return filter, nil
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func TestExportFlashcardsHandler(t *testing.T) {
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{Count: 2, Results: []types.ExportSource{
+			{UserBookID: 1, Title: "Peak", Highlights: []types.Highlight{{ID: 10, Text: "Practice {{c1::deliberately}}"}}},
+			{UserBookID: 2, Title: "Other", Highlights: []types.Highlight{{ID: 20, Text: "Question", Note: "Answer"}}},
+		}})
+	})
+	defer ts.Close()
+
+	handler := makeExportFlashcardsHandler(newCachedClient(client, cm))
+	result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), ExportFlashcardsInput{SourceID: "1", Format: "csv"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if !strings.Contains(text, "readwise-10,Practice [...],Practice deliberately,source::Peak\n") || strings.Contains(text, "readwise-20") {
+		t.Errorf("unexpected flashcards:\n%s", text)
+	}
+
+	tests := []struct {
+		input ExportFlashcardsInput
+		code  string
+	}{
+		{ExportFlashcardsInput{SourceID: "abc"}, "invalid_source_id"},
+		{ExportFlashcardsInput{After: "yesterday"}, "invalid_date"},
+		{ExportFlashcardsInput{Format: "apkg"}, "invalid_format"},
+	}
+	for _, tt := range tests {
+		_, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), tt.input)
+		var apiErr *api.ErrorResponse
+		if !errors.As(err, &apiErr) || apiErr.Code != tt.code {
+			t.Errorf("%+v: error = %v, want code %s", tt.input, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Result kinds of search_library.
+const (
+	kindHighlight = "highlight"
+	kindDocument  = "document"
+)
+
+// SearchLibraryInput defines the parameters for the search_library tool.
+type SearchLibraryInput struct {
+	Query        string `json:"query" jsonschema:"Search query; supports quoted phrases and AND/OR/NOT and field filters such as author:clear tag:favorite after:2024-01-01"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"Maximum number of results (1-200; default 50)"`
+	Fuzzy        bool   `json:"fuzzy,omitempty" jsonschema:"Also match words with typos; such matches rank below exact ones"`
+	SnippetsOnly bool   `json:"snippets_only,omitempty" jsonschema:"Return IDs and titles with match snippets instead of full highlights and documents, to keep responses small"`
+}
+
+// LibraryResult is a highlight or a Reader document matching a search_library
+// query. Kind tells which one it is. Scores are normalized so that the best
+// match of each kind scores 1. A highlight from an article that is also a
+// matching Reader document carries the document's ID in DocumentID.
+type LibraryResult struct {
+	Kind           string           `json:"kind"`
+	Title          string           `json:"title"`
+	SourceURL      string           `json:"source_url,omitempty"`
+	RelevanceScore float64          `json:"relevance_score"`
+	Highlight      *types.Highlight `json:"highlight,omitempty"`
+	HighlightID    int64            `json:"highlight_id,omitempty"`
+	SourceID       int64            `json:"source_id,omitempty"`
+	Document       *types.Document  `json:"document,omitempty"`
+	DocumentID     string           `json:"document_id,omitempty"`
+	Snippets       []search.Snippet `json:"snippets,omitempty"`
+}
+
+// RegisterSearchLibraryTool registers the search_library tool, which needs
+// both the readwise and the reader profile. It shares indexes with
+// search_highlights and search_documents.
+func RegisterSearchLibraryTool(s *mcp.Server, client *api.Client, cm *cache.Manager, indexes *search.IndexCache) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "search_library",
+		Description: "Search Readwise highlights and Reader documents at once and return a single list ranked by relevance. Each result has a kind of \"highlight\" or \"document\"; a document is left out when highlights of the same article (same source_url) match, and those highlights reference it via document_id. " + querySyntaxHelp + " Filters: author: title: tag: category: before: after:; color: and is:favorite only match highlights, location: only documents.",
+	}, makeSearchLibraryHandler(newCachedClient(client, cm), indexes))
+}
+
+func makeSearchLibraryHandler(client *cachedClient, indexes *search.IndexCache) mcp.ToolHandlerFor[SearchLibraryInput, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input SearchLibraryInput) (*mcp.CallToolResult, any, error) {
+		apiKey := auth.APIKeyFromRequest(req)
+		if apiKey == "" {
+			return nil, nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+		if input.Query == "" {
+			return nil, nil, fmt.Errorf("query is required")
+		}
+
+		if input.Limit <= 0 {
+			input.Limit = 50
+		}
+		if input.Limit > 200 {
+			input.Limit = 200
+		}
+
+		exportData, err := client.ExportHighlights(ctx, apiKey, "")
+		if err != nil {
+			return nil, nil, err
+		}
+		docData, err := client.ListDocuments(ctx, apiKey, "", "", "", 0)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		_, span := telemetry.Start(ctx, "search.score",
+			attribute.String("search.kind", "library"),
+			attribute.Int("search.sources", len(exportData.Results)),
+			attribute.Int("search.documents", len(docData.Results)),
+		)
+		user := auth.HashAPIKey(apiKey)
+		results, err := searchLibrary(indexes.Highlights(user, exportData.Results), indexes.Documents(user, docData.Results), input)
+		span.SetAttributes(attribute.Int("search.results", len(results)))
+		telemetry.End(span, err)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data, _ := json.Marshal(results)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// searchLibrary searches both indexes and merges the hits into one list. A
+// query whose filters only apply to one kind searches that kind alone; the
+// query fails only if neither index accepts it.
+func searchLibrary(hx *search.HighlightIndex, dx *search.DocumentIndex, input SearchLibraryInput) ([]LibraryResult, error) {
+	highlights, hErr := hx.Search(input.Query, search.HighlightOptions{Fuzzy: input.Fuzzy})
+	documents, dErr := dx.Search(input.Query, search.DocumentOptions{Fuzzy: input.Fuzzy})
+	if hErr != nil && dErr != nil {
+		return nil, queryError(hErr)
+	}
+
+	// Reader documents by article, to fold them into matching highlights.
+	docByURL := make(map[string]*types.Document)
+	for _, hit := range documents {
+		if url := normalizeURL(hit.Document.SourceURL); url != "" {
+			if _, ok := docByURL[url]; !ok {
+				docByURL[url] = hit.Document
+			}
+		}
+	}
+
+	var results []LibraryResult
+	covered := make(map[string]bool)
+	hMax := maxHighlightScore(highlights)
+	for _, hit := range highlights {
+		result := LibraryResult{
+			Kind:           kindHighlight,
+			Title:          hit.Source.Title,
+			SourceURL:      hit.Source.SourceURL,
+			RelevanceScore: normalizeScore(hit.Score, hMax),
+			Snippets:       hit.Snippets,
+		}
+		if input.SnippetsOnly {
+			result.HighlightID, result.SourceID = hit.Highlight.ID, hit.Source.UserBookID
+		} else {
+			result.Highlight = hit.Highlight
+		}
+		if url := normalizeURL(hit.Source.SourceURL); url != "" {
+			if doc := docByURL[url]; doc != nil {
+				result.DocumentID = doc.ID
+			}
+			covered[url] = true
+		}
+		results = append(results, result)
+	}
+
+	dMax := maxDocumentScore(documents)
+	for _, hit := range documents {
+		url := normalizeURL(hit.Document.SourceURL)
+		if url != "" {
+			if covered[url] {
+				continue
+			}
+			covered[url] = true
+		}
+		result := LibraryResult{
+			Kind:           kindDocument,
+			Title:          hit.Document.Title,
+			SourceURL:      hit.Document.SourceURL,
+			RelevanceScore: normalizeScore(hit.Score, dMax),
+			Snippets:       hit.Snippets,
+		}
+		if input.SnippetsOnly {
+			result.DocumentID = hit.Document.ID
+		} else {
+			result.Document = hit.Document
+		}
+		results = append(results, result)
+	}
+
+	// Stable, so highlights precede documents on equal scores and each kind
+	// keeps its own ranking.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	if input.Limit > 0 && len(results) > input.Limit {
+		results = results[:input.Limit]
+	}
+	return results, nil
+}
+
+func maxHighlightScore(hits []search.HighlightHit) float64 {
+	var m float64
+	for _, h := range hits {
+		m = max(m, h.Score)
+	}
+	return m
+}
+
+func maxDocumentScore(hits []search.DocumentHit) float64 {
+	var m float64
+	for _, h := range hits {
+		m = max(m, h.Score)
+	}
+	return m
+}
+
+// normalizeScore scales a BM25 score into [0, 1] relative to the best score
+// of the same index, since raw scores of different indexes do not compare.
+func normalizeScore(score, best float64) float64 {
+	if best <= 0 {
+		return 0
+	}
+	return math.Round(score/best*1000) / 1000
+}
+
+// normalizeURL reduces a source URL to a form in which the same article
+// saved twice compares equal: without scheme, "www.", fragment and trailing
+// slash, and with the host in lower case.
+func normalizeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if i := strings.Index(u, "#"); i >= 0 {
+		u = u[:i]
+	}
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	}
+	host, path, _ := strings.Cut(u, "/")
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	return strings.TrimSuffix(host+"/"+path, "/")
+}
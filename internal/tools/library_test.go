@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func testLibraryIndexes() (*search.HighlightIndex, *search.DocumentIndex) {
+	hx := search.NewHighlightIndex([]types.ExportSource{
+		{UserBookID: 1, Title: "Deep Work", SourceURL: "https://example.com/deep-work/", Highlights: []types.Highlight{
+			{ID: 10, Text: "Focus is the new IQ in the knowledge economy", Color: "yellow"},
+		}},
+		{UserBookID: 2, Title: "Shallows", Highlights: []types.Highlight{
+			{ID: 20, Text: "The net is eroding our capacity for focus and contemplation, focus"},
+		}},
+	})
+	dx := search.NewDocumentIndex([]types.Document{
+		{ID: "dup", Title: "Deep Work: focus", SourceURL: "http://www.Example.com/deep-work"},
+		{ID: "doc", Title: "Focus Mode", SourceURL: "https://other.org/focus", Location: "later"},
+		{ID: "copy", Title: "Focus Mode (saved again)", SourceURL: "https://other.org/focus#intro", Location: "later"},
+	})
+	return hx, dx
+}
+
+func TestSearchLibraryMergesKinds(t *testing.T) {
+	hx, dx := testLibraryIndexes()
+
+	results, err := searchLibrary(hx, dx, SearchLibraryInput{Query: "focus"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var kinds []string
+	for _, r := range results {
+		kinds = append(kinds, r.Kind)
+		if r.RelevanceScore <= 0 || r.RelevanceScore > 1 {
+			t.Errorf("score %v of %+v is not normalized", r.RelevanceScore, r)
+		}
+	}
+	if got := strings.Join(kinds, ","); got != "highlight,document,highlight" {
+		t.Fatalf("kinds = %s, want highlight,document,highlight", got)
+	}
+	if results[0].RelevanceScore != 1 || results[1].RelevanceScore != 1 {
+		t.Errorf("best match of each kind should score 1, got %v and %v", results[0].RelevanceScore, results[1].RelevanceScore)
+	}
+	if results[1].Document == nil || results[1].Document.ID != "doc" {
+		t.Errorf("document result = %+v, want doc", results[1])
+	}
+}
+
+func TestSearchLibraryDeduplicatesBySourceURL(t *testing.T) {
+	hx, dx := testLibraryIndexes()
+
+	results, err := searchLibrary(hx, dx, SearchLibraryInput{Query: "focus"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, r := range results {
+		if r.Document != nil && (r.Document.ID == "dup" || r.Document.ID == "copy") {
+			t.Errorf("duplicate document %q was not removed", r.Document.ID)
+		}
+		if r.Highlight != nil && r.Highlight.ID == 10 && r.DocumentID != "dup" {
+			t.Errorf("highlight 10 document_id = %q, want dup", r.DocumentID)
+		}
+	}
+}
+
+func TestSearchLibraryKindSpecificFilters(t *testing.T) {
+	hx, dx := testLibraryIndexes()
+
+	results, err := searchLibrary(hx, dx, SearchLibraryInput{Query: "focus color:yellow"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Kind != kindHighlight || results[0].Highlight.ID != 10 {
+		t.Errorf("color filter results = %+v, want highlight 10", results)
+	}
+
+	results, err = searchLibrary(hx, dx, SearchLibraryInput{Query: "focus location:later", SnippetsOnly: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Kind != kindDocument || results[0].DocumentID != "doc" || results[0].Document != nil {
+		t.Errorf("location filter results = %+v, want snippets-only document doc", results)
+	}
+
+	_, err = searchLibrary(hx, dx, SearchLibraryInput{Query: "focus AND ("})
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_query" {
+		t.Errorf("expected invalid_query error, got %v", err)
+	}
+}
+
+func TestSearchLibraryHandler(t *testing.T) {
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/export/") {
+			json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{Count: 1, Results: []types.ExportSource{
+				{UserBookID: 1, Title: "Book", Highlights: []types.Highlight{{ID: 10, Text: "a note on habits"}}},
+			}})
+			return
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{Count: 1, Results: []types.Document{
+			{ID: "a", Title: "Atomic Habits"},
+		}})
+	})
+	defer ts.Close()
+
+	handler := makeSearchLibraryHandler(newCachedClient(client, cm), search.NewIndexCache(1))
+	result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), SearchLibraryInput{Query: "habits", Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var results []LibraryResult
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &results); err != nil {
+		t.Fatalf("failed to parse result: %v", err)
+	}
+	if len(results) != 1 || results[0].Kind != kindHighlight {
+		t.Errorf("results = %+v, want one highlight", results)
+	}
+
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, SearchLibraryInput{Query: "habits"}); err == nil {
+		t.Error("expected error for missing API key")
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"https://Example.com/Post/", "http://www.example.com/Post"},
+		{"https://example.com/post#section", "https://example.com/post"},
+	}
+	for _, tt := range tests {
+		if normalizeURL(tt.a) != normalizeURL(tt.b) {
+			t.Errorf("normalizeURL(%q) = %q, normalizeURL(%q) = %q, want equal", tt.a, normalizeURL(tt.a), tt.b, normalizeURL(tt.b))
+		}
+	}
+	if normalizeURL("https://example.com/Post") == normalizeURL("https://example.com/post") {
+		t.Error("paths must stay case sensitive")
+	}
+}
@@ -31,8 +31,10 @@ var baseProfiles = map[string]Profile{
 			"list_sources", "get_source",
 			"list_highlights", "get_highlight",
 			"export_highlights", "get_daily_review",
-			"list_source_tags", "list_highlight_tags",
-			"search_highlights",
+			"export_flashcards", "export_citations",
+			"list_source_tags", "list_highlight_tags", "list_all_tags",
+			"search_highlights", "semantic_search",
+			"find_related_highlights",
 		},
 	},
 	"reader": {
@@ -75,6 +77,15 @@ var baseProfiles = map[string]Profile{
 	},
 }
 
+// combinedTools lists tools that are only registered when all of the given
+// profiles are active, because they draw on each of them.
+var combinedTools = []struct {
+	Profiles  []string
+	ToolNames []string
+}{
+	{Profiles: []string{"readwise", "reader"}, ToolNames: []string{"search_library"}},
+}
+
 // shortcuts maps shortcut names to profile lists.
 var shortcuts = map[string][]string{
 	"basic": {"reader", "write"},
@@ -152,7 +163,8 @@ func formatDependency(dep string) string {
 }
 
 // ToolsForProfiles returns the deduplicated list of tool names that should be
-// registered for the given active profiles.
+// registered for the given active profiles, including combined tools whose
+// profiles are all active.
 func ToolsForProfiles(profiles []string) []string {
 	seen := make(map[string]bool)
 	var tools []string
@@ -170,15 +182,32 @@ func ToolsForProfiles(profiles []string) []string {
 		}
 	}
 
+	for _, combined := range combinedTools {
+		if !slices.ContainsFunc(combined.Profiles, func(p string) bool { return !slices.Contains(profiles, p) }) {
+			for _, tool := range combined.ToolNames {
+				if !seen[tool] {
+					seen[tool] = true
+					tools = append(tools, tool)
+				}
+			}
+		}
+	}
+
 	return tools
 }
 
-// ProfileForTool returns the profile that owns a given tool name.
+// ProfileForTool returns the profile that owns a given tool name. Combined
+// tools are owned by their profiles joined with "+".
 func ProfileForTool(toolName string) (string, bool) {
 	for name, profile := range baseProfiles {
 		if slices.Contains(profile.ToolNames, toolName) {
 			return name, true
 		}
 	}
+	for _, combined := range combinedTools {
+		if slices.Contains(combined.ToolNames, toolName) {
+			return strings.Join(combined.Profiles, "+"), true
+		}
+	}
 	return "", false
 }
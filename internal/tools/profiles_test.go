@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -9,7 +10,7 @@ func TestBaseProfileDefinitions(t *testing.T) {
 		profile   string
 		toolCount int
 	}{
-		{"readwise", 9},
+		{"readwise", 14},
 		{"reader", 4},
 		{"write", 7},
 		{"video", 5},
@@ -28,13 +29,13 @@ func TestBaseProfileDefinitions(t *testing.T) {
 		})
 	}
 
-	// Total should be 29
+	// Total should be 34
 	total := 0
 	for _, p := range baseProfiles {
 		total += len(p.ToolNames)
 	}
-	if total != 29 {
-		t.Errorf("total tools = %d, want 29", total)
+	if total != 34 {
+		t.Errorf("total tools = %d, want 34", total)
 	}
 }
 
@@ -153,10 +154,11 @@ func TestToolFiltering(t *testing.T) {
 		profiles  []string
 		wantCount int
 	}{
-		{"readwise only", []string{"readwise"}, 9},
+		{"readwise only", []string{"readwise"}, 14},
 		{"reader only", []string{"reader"}, 4},
-		{"readwise+reader", []string{"readwise", "reader"}, 13},
-		{"all profiles", []string{"readwise", "reader", "write", "video", "destructive"}, 29},
+		{"readwise+reader", []string{"readwise", "reader"}, 19},
+		{"reader+write", []string{"reader", "write"}, 11},
+		{"all profiles", []string{"readwise", "reader", "write", "video", "destructive"}, 35},
 	}
 
 	for _, tt := range tests {
@@ -181,8 +183,26 @@ func TestToolFiltering(t *testing.T) {
 func TestToolFilteringDeduplication(t *testing.T) {
 	// If profiles somehow share tools, they should be deduplicated
 	tools := ToolsForProfiles([]string{"readwise", "readwise"})
-	if len(tools) != 9 {
-		t.Errorf("expected 9 tools, got %d (duplicate profile should not duplicate tools)", len(tools))
+	if len(tools) != 14 {
+		t.Errorf("expected 14 tools, got %d (duplicate profile should not duplicate tools)", len(tools))
+	}
+}
+
+func TestCombinedTools(t *testing.T) {
+	tests := []struct {
+		profiles []string
+		want     bool
+	}{
+		{[]string{"readwise"}, false},
+		{[]string{"reader"}, false},
+		{[]string{"reader", "readwise"}, true},
+		{[]string{"readwise", "reader", "write"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := slices.Contains(ToolsForProfiles(tt.profiles), "search_library"); got != tt.want {
+			t.Errorf("search_library active for %v = %v, want %v", tt.profiles, got, tt.want)
+		}
 	}
 }
 
@@ -197,6 +217,7 @@ func TestProfileForTool(t *testing.T) {
 		{"save_document", "write", true},
 		{"list_videos", "video", true},
 		{"delete_highlight", "destructive", true},
+		{"search_library", "readwise+reader", true},
 		{"nonexistent", "", false},
 	}
 
@@ -0,0 +1,302 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// RegisterPrompts registers the workflow prompts whose underlying tools are
+// active. Each prompt fetches the data its tools would return and embeds it
+// in the prompt message.
+func RegisterPrompts(s *mcp.Server, client *api.Client, cm *cache.Manager, indexes *search.IndexCache, activeTools map[string]bool) {
+	cc := newCachedClient(client, cm)
+
+	if activeTools["get_daily_review"] {
+		s.AddPrompt(&mcp.Prompt{
+			Name:        "daily_review",
+			Title:       "Review today's highlights",
+			Description: "Walk through today's Readwise daily review highlights.",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "focus", Description: "Optional theme or question to relate the highlights to"},
+			},
+		}, makeDailyReviewPrompt(client))
+	}
+
+	if activeTools["get_source"] && activeTools["list_highlights"] {
+		s.AddPrompt(&mcp.Prompt{
+			Name:        "summarize_source",
+			Title:       "Summarize a source",
+			Description: "Summarize the highlights and notes of a Readwise source.",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "source_id", Description: "The ID of the source to summarize", Required: true},
+			},
+		}, makeSummarizeSourcePrompt(client))
+	}
+
+	if activeTools["list_documents"] {
+		s.AddPrompt(&mcp.Prompt{
+			Name:        "triage_inbox",
+			Title:       "Triage my Reader inbox",
+			Description: "Go through new documents in the Reader inbox and decide what to read, defer or archive.",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "category", Description: "Only triage documents of this category: article email rss pdf epub tweet video"},
+				{Name: "limit", Description: "Maximum number of documents to triage (1-100; default 20)"},
+			},
+		}, makeTriageInboxPrompt(cc, activeTools["update_document"]))
+	}
+
+	if activeTools["search_highlights"] || activeTools["search_documents"] {
+		s.AddPrompt(&mcp.Prompt{
+			Name:        "research_topic",
+			Title:       "Find what I've read about a topic",
+			Description: "Collect highlights and documents matching a topic and synthesize what they say.",
+			Arguments: []*mcp.PromptArgument{
+				{Name: "topic", Description: "The topic to research", Required: true},
+				{Name: "limit", Description: "Maximum number of highlights and of documents to include (1-50; default 20)"},
+			},
+		}, makeResearchTopicPrompt(cc, indexes, activeTools["search_highlights"], activeTools["search_documents"]))
+	}
+}
+
+func makeDailyReviewPrompt(client *api.Client) mcp.PromptHandler {
+	return func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		apiKey := auth.APIKeyFromExtra(req.Extra)
+		if apiKey == "" {
+			return nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+
+		review, err := client.GetDailyReview(ctx, apiKey)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		b.WriteString("Help me review today's Readwise highlights. For each highlight, explain the key idea in one or two sentences and point out connections between highlights.")
+		if focus := req.Params.Arguments["focus"]; focus != "" {
+			fmt.Fprintf(&b, " Relate them to this theme: %s.", focus)
+		}
+		b.WriteString(" Finish with one question I could reflect on.\n\n")
+		if len(review.Highlights) == 0 {
+			b.WriteString("(The daily review has no highlights today.)\n")
+		}
+		for i, h := range review.Highlights {
+			fmt.Fprintf(&b, "%d. %q", i+1, h.Text)
+			writeAttribution(&b, h.Title, h.Author)
+			if h.Note != "" {
+				fmt.Fprintf(&b, "\n   Note: %s", h.Note)
+			}
+			b.WriteString("\n")
+		}
+
+		return promptResult("Today's daily review", b.String()), nil
+	}
+}
+
+func makeSummarizeSourcePrompt(client *api.Client) mcp.PromptHandler {
+	return func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		apiKey := auth.APIKeyFromExtra(req.Extra)
+		if apiKey == "" {
+			return nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+		sourceID := req.Params.Arguments["source_id"]
+		if sourceID == "" {
+			return nil, fmt.Errorf("source_id is required")
+		}
+
+		source, err := client.GetBook(ctx, apiKey, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		highlights, err := sourceHighlights(ctx, client, apiKey, sourceID)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Summarize my highlights from %q", source.Title)
+		writeAttribution(&b, "", source.Author)
+		b.WriteString(". Identify the main arguments and recurring themes, and include my own notes where they add a perspective.\n\n")
+		if source.Summary != "" {
+			fmt.Fprintf(&b, "Source summary: %s\n\n", source.Summary)
+		}
+		if len(highlights) == 0 {
+			b.WriteString("(This source has no highlights.)\n")
+		}
+		for i, h := range highlights {
+			fmt.Fprintf(&b, "%d. %q\n", i+1, h.Text)
+			if h.Note != "" {
+				fmt.Fprintf(&b, "   Note: %s\n", h.Note)
+			}
+		}
+
+		return promptResult("Summary of "+source.Title, b.String()), nil
+	}
+}
+
+func makeTriageInboxPrompt(client *cachedClient, canUpdate bool) mcp.PromptHandler {
+	return func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		apiKey := auth.APIKeyFromExtra(req.Extra)
+		if apiKey == "" {
+			return nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+		limit, err := promptLimit(req.Params.Arguments["limit"], 20, 100)
+		if err != nil {
+			return nil, err
+		}
+
+		// Fetch one document more than shown to tell whether the inbox holds
+		// more, instead of paging through all of it.
+		result, err := client.ListDocuments(ctx, apiKey, "new", req.Params.Arguments["category"], "", limit+1)
+		if err != nil {
+			return nil, err
+		}
+		truncated := len(result.Results) > limit
+		var docs []types.Document
+		for _, d := range result.Results {
+			if d.ParentID == "" {
+				docs = append(docs, d)
+			}
+		}
+		docs = docs[:min(len(docs), limit)]
+
+		var b strings.Builder
+		b.WriteString("Help me triage my Reader inbox. For each document, suggest whether to read it now (shortlist), read it later (later) or archive it, with a one-sentence reason based on its title and summary.")
+		if canUpdate {
+			b.WriteString(" After I confirm, move the documents with the update_document tool.")
+		}
+		b.WriteString("\n\n")
+		if len(docs) == 0 {
+			b.WriteString("(The inbox is empty.)\n")
+		} else if truncated {
+			fmt.Fprintf(&b, "(The inbox holds more documents than the %d listed here.)\n", len(docs))
+		}
+		for i, d := range docs {
+			fmt.Fprintf(&b, "%d. %s (id: %s, %s", i+1, d.Title, d.ID, d.Category)
+			if d.WordCount > 0 {
+				fmt.Fprintf(&b, ", %d words", d.WordCount)
+			}
+			b.WriteString(")")
+			writeAttribution(&b, "", d.Author)
+			if d.Summary != "" {
+				fmt.Fprintf(&b, "\n   Summary: %s", d.Summary)
+			}
+			b.WriteString("\n")
+		}
+
+		return promptResult("Reader inbox triage", b.String()), nil
+	}
+}
+
+func makeResearchTopicPrompt(client *cachedClient, indexes *search.IndexCache, highlights, documents bool) mcp.PromptHandler {
+	return func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		apiKey := auth.APIKeyFromExtra(req.Extra)
+		if apiKey == "" {
+			return nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+		topic := req.Params.Arguments["topic"]
+		if topic == "" {
+			return nil, fmt.Errorf("topic is required")
+		}
+		limit, err := promptLimit(req.Params.Arguments["limit"], 20, 50)
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Tell me what I've read about %q. Synthesize the main ideas across the material below, note where sources agree or disagree, and cite the titles you draw from.\n", topic)
+
+		if highlights {
+			exportData, err := client.ExportHighlights(ctx, apiKey, "")
+			if err != nil {
+				return nil, err
+			}
+			ix := indexes.Highlights(auth.HashAPIKey(apiKey), exportData.Results)
+			results, err := searchHighlightIndex(ix, SearchHighlightsInput{Query: topic, Limit: limit})
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString("\nMatching highlights:\n")
+			if len(results) == 0 {
+				b.WriteString("(none)\n")
+			}
+			for i, r := range results {
+				fmt.Fprintf(&b, "%d. %q", i+1, r.Highlight.Text)
+				writeAttribution(&b, r.SourceTitle, "")
+				b.WriteString("\n")
+				if r.Highlight.Note != "" {
+					fmt.Fprintf(&b, "   Note: %s\n", r.Highlight.Note)
+				}
+			}
+		}
+
+		if documents {
+			docData, err := client.ListDocuments(ctx, apiKey, "", "", "", 0)
+			if err != nil {
+				return nil, err
+			}
+			ix := indexes.Documents(auth.HashAPIKey(apiKey), docData.Results)
+			results, err := searchDocumentIndex(ix, SearchDocumentsInput{Query: topic, Limit: limit})
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString("\nMatching Reader documents:\n")
+			if len(results) == 0 {
+				b.WriteString("(none)\n")
+			}
+			for i, r := range results {
+				fmt.Fprintf(&b, "%d. %s", i+1, r.Document.Title)
+				writeAttribution(&b, "", r.Document.Author)
+				b.WriteString("\n")
+				if r.Document.Summary != "" {
+					fmt.Fprintf(&b, "   Summary: %s\n", r.Document.Summary)
+				}
+			}
+		}
+
+		return promptResult("Reading on "+topic, b.String()), nil
+	}
+}
+
+// promptLimit parses an optional numeric prompt argument. Prompt arguments
+// are always strings, so limits arrive as decimal text.
+func promptLimit(arg string, def, maxLimit int) (int, error) {
+	if arg == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("limit must be a positive number")
+	}
+	return min(n, maxLimit), nil
+}
+
+// writeAttribution appends " (title, by author)" with whichever parts are set.
+func writeAttribution(b *strings.Builder, title, author string) {
+	switch {
+	case title != "" && author != "":
+		fmt.Fprintf(b, " (%s, by %s)", title, author)
+	case title != "":
+		fmt.Fprintf(b, " (%s)", title)
+	case author != "":
+		fmt.Fprintf(b, " (by %s)", author)
+	}
+}
+
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return &mcp.GetPromptResult{
+		Description: description,
+		Messages: []*mcp.PromptMessage{{
+			Role:    "user",
+			Content: &mcp.TextContent{Text: text},
+		}},
+	}
+}
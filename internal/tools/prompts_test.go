@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func promptsTestHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/review/":
+		json.NewEncoder(w).Encode(types.DailyReview{
+			Highlights: []types.ReviewHighlight{
+				{ID: 1, Text: "Habits compound", Title: "Atomic Habits", Author: "James Clear", Note: "like interest"},
+			},
+		})
+	case "/export/":
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+			Results: []types.ExportSource{{
+				UserBookID: 1,
+				Title:      "Clear Thinking",
+				Highlights: []types.Highlight{
+					{ID: 1, Text: "Habits compound over time", BookID: 1},
+					{ID: 2, Text: "Environment beats motivation", BookID: 1},
+				},
+			}},
+		})
+	case "/list/":
+		if r.URL.Query().Get("location") == "new" {
+			json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{
+				Results: []types.Document{
+					{ID: "doc-1", Title: "Inbox Article", Category: "article", Summary: "About habits"},
+					{ID: "doc-2", Title: "Second Article", Category: "article"},
+					{ID: "hl-1", Title: "A highlight", ParentID: "doc-1"},
+				},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{
+			Results: []types.Document{{ID: "doc-3", Title: "On habits", Summary: "Habits and routines"}},
+		})
+	default:
+		resourcesTestHandler(w, r)
+	}
+}
+
+func connectPromptsTest(t *testing.T, profiles ...string) *mcp.ClientSession {
+	t.Helper()
+	return connectTestSession(t, promptsTestHandler, func(s *mcp.Server, client *api.Client, cm *cache.Manager) {
+		activeTools := make(map[string]bool)
+		for _, tool := range ToolsForProfiles(profiles) {
+			activeTools[tool] = true
+		}
+		RegisterPrompts(s, client, cm, search.NewIndexCache(1), activeTools)
+	})
+}
+
+func promptText(t *testing.T, result *mcp.GetPromptResult) string {
+	t.Helper()
+	if len(result.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(result.Messages))
+	}
+	text, ok := result.Messages[0].Content.(*mcp.TextContent)
+	if !ok {
+		t.Fatalf("expected text content, got %T", result.Messages[0].Content)
+	}
+	return text.Text
+}
+
+func TestPromptsGatedByProfile(t *testing.T) {
+	tests := []struct {
+		profiles []string
+		want     []string
+	}{
+		{[]string{"readwise"}, []string{"daily_review", "research_topic", "summarize_source"}},
+		{[]string{"reader"}, []string{"research_topic", "triage_inbox"}},
+		{[]string{"readwise", "reader"}, []string{"daily_review", "research_topic", "summarize_source", "triage_inbox"}},
+	}
+
+	for _, tt := range tests {
+		session := connectPromptsTest(t, tt.profiles...)
+		result, err := session.ListPrompts(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("ListPrompts() error: %v", err)
+		}
+		var names []string
+		for _, p := range result.Prompts {
+			names = append(names, p.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("profiles %v: prompts = %v, want %v", tt.profiles, names, tt.want)
+		}
+	}
+}
+
+func TestDailyReviewPrompt(t *testing.T) {
+	session := connectPromptsTest(t, "readwise")
+
+	result, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "daily_review",
+		Arguments: map[string]string{"focus": "productivity"},
+	})
+	if err != nil {
+		t.Fatalf("GetPrompt() error: %v", err)
+	}
+	text := promptText(t, result)
+	for _, want := range []string{"Habits compound", "Atomic Habits, by James Clear", "like interest", "productivity"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestSummarizeSourcePrompt(t *testing.T) {
+	session := connectPromptsTest(t, "readwise")
+
+	result, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "summarize_source",
+		Arguments: map[string]string{"source_id": "1"},
+	})
+	if err != nil {
+		t.Fatalf("GetPrompt() error: %v", err)
+	}
+	text := promptText(t, result)
+	for _, want := range []string{`"Older"`, "first", "second"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt missing %q:\n%s", want, text)
+		}
+	}
+
+	if _, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{Name: "summarize_source"}); err == nil {
+		t.Error("expected error for missing source_id")
+	}
+}
+
+func TestTriageInboxPrompt(t *testing.T) {
+	var listLimits []string
+	session := connectTestSession(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/list/" {
+			listLimits = append(listLimits, r.URL.Query().Get("limit"))
+		}
+		promptsTestHandler(w, r)
+	}, func(s *mcp.Server, client *api.Client, cm *cache.Manager) {
+		RegisterPrompts(s, client, cm, search.NewIndexCache(1), map[string]bool{"list_documents": true})
+	})
+
+	result, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "triage_inbox",
+		Arguments: map[string]string{"limit": "1"},
+	})
+	if err != nil {
+		t.Fatalf("GetPrompt() error: %v", err)
+	}
+	text := promptText(t, result)
+	if !strings.Contains(text, "Inbox Article (id: doc-1") || !strings.Contains(text, "About habits") {
+		t.Errorf("prompt missing inbox document:\n%s", text)
+	}
+	if strings.Contains(text, "Second Article") || strings.Contains(text, "A highlight") {
+		t.Errorf("prompt should be limited to one top-level document:\n%s", text)
+	}
+	if strings.Contains(text, "update_document") {
+		t.Errorf("prompt should not mention update_document without the write profile:\n%s", text)
+	}
+	if !strings.Contains(text, "more documents than the 1 listed") {
+		t.Errorf("prompt should say that the list is truncated:\n%s", text)
+	}
+	if len(listLimits) != 1 || listLimits[0] != "2" {
+		t.Errorf("list requests with limits %q, want a single request with limit 2", listLimits)
+	}
+
+	if _, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "triage_inbox",
+		Arguments: map[string]string{"limit": "many"},
+	}); err == nil {
+		t.Error("expected error for non-numeric limit")
+	}
+}
+
+func TestResearchTopicPrompt(t *testing.T) {
+	session := connectPromptsTest(t, "readwise", "reader")
+
+	result, err := session.GetPrompt(context.Background(), &mcp.GetPromptParams{
+		Name:      "research_topic",
+		Arguments: map[string]string{"topic": "habits"},
+	})
+	if err != nil {
+		t.Fatalf("GetPrompt() error: %v", err)
+	}
+	text := promptText(t, result)
+	for _, want := range []string{"Habits compound over time", "On habits"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("prompt missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Environment beats motivation") {
+		t.Errorf("prompt contains non-matching highlight:\n%s", text)
+	}
+}
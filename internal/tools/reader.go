@@ -8,6 +8,9 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/rhuss/readwise-mcp-server/internal/api"
 	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/htmlconv"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
 )
 
 // ListDocumentsInput defines the parameters for the list_documents tool.
@@ -22,30 +25,49 @@ type ListDocumentsInput struct {
 type GetDocumentInput struct {
 	ID             string `json:"id" jsonschema:"Document ID"`
 	IncludeContent bool   `json:"include_content,omitempty" jsonschema:"Include full document content (default false)"`
+	ContentFormat  string `json:"content_format,omitempty" jsonschema:"Format of the content: html (default), markdown or text. Setting it includes the content."`
+}
+
+// Content formats of get_document and get_video.
+const (
+	contentFormatHTML     = "html"
+	contentFormatMarkdown = "markdown"
+	contentFormatText     = "text"
+)
+
+// convertedDocument is a document whose HTML content was converted. The
+// converted content replaces the html field.
+type convertedDocument struct {
+	*types.Document
+	Content       string `json:"content"`
+	ContentFormat string `json:"content_format"`
 }
 
 // ListReaderTagsInput is empty since no parameters are needed.
 type ListReaderTagsInput struct{}
 
 // RegisterReaderTools registers the 4 reader profile tools with the MCP server.
-func RegisterReaderTools(s *mcp.Server, client *api.Client) {
+// List tools are served through the cache.
+func RegisterReaderTools(s *mcp.Server, client *api.Client, cm *cache.Manager) {
+	cc := newCachedClient(client, cm)
+
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "list_documents",
 		Description: "List Reader documents with optional filtering by location (new, later, archive) or category (article, pdf, email, video, etc.).",
-	}, makeListDocumentsHandler(client))
+	}, makeListDocumentsHandler(cc))
 
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "get_document",
-		Description: "Get a single Reader document by ID, optionally including its full content.",
+		Description: "Get a single Reader document by ID, optionally including its full content as HTML, as Markdown (headings, lists, links, code and images as links kept) or as plain text. Markdown and text use far fewer tokens than HTML.",
 	}, makeGetDocumentHandler(client))
 
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "list_reader_tags",
 		Description: "List all tags in Reader.",
-	}, makeListReaderTagsHandler(client))
+	}, makeListReaderTagsHandler(cc))
 }
 
-func makeListDocumentsHandler(client *api.Client) mcp.ToolHandlerFor[ListDocumentsInput, any] {
+func makeListDocumentsHandler(client *cachedClient) mcp.ToolHandlerFor[ListDocumentsInput, any] {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input ListDocumentsInput) (*mcp.CallToolResult, any, error) {
 		apiKey := auth.APIKeyFromRequest(req)
 		if apiKey == "" {
@@ -77,20 +99,23 @@ func makeGetDocumentHandler(client *api.Client) mcp.ToolHandlerFor[GetDocumentIn
 		if input.ID == "" {
 			return nil, nil, fmt.Errorf("id is required")
 		}
+		if err := validateContentFormat(input.ContentFormat); err != nil {
+			return nil, nil, err
+		}
 
-		result, err := client.GetDocument(ctx, apiKey, input.ID, input.IncludeContent)
+		result, err := client.GetDocument(ctx, apiKey, input.ID, input.IncludeContent || input.ContentFormat != "")
 		if err != nil {
 			return nil, nil, err
 		}
 
-		data, _ := json.Marshal(result)
+		data, _ := json.Marshal(formatContent(result, input.ContentFormat))
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
 		}, nil, nil
 	}
 }
 
-func makeListReaderTagsHandler(client *api.Client) mcp.ToolHandlerFor[ListReaderTagsInput, any] {
+func makeListReaderTagsHandler(client *cachedClient) mcp.ToolHandlerFor[ListReaderTagsInput, any] {
 	return func(ctx context.Context, req *mcp.CallToolRequest, _ ListReaderTagsInput) (*mcp.CallToolResult, any, error) {
 		apiKey := auth.APIKeyFromRequest(req)
 		if apiKey == "" {
@@ -108,3 +133,27 @@ func makeListReaderTagsHandler(client *api.Client) mcp.ToolHandlerFor[ListReader
 		}, nil, nil
 	}
 }
+
+func validateContentFormat(format string) error {
+	switch format {
+	case "", contentFormatHTML, contentFormatMarkdown, contentFormatText:
+		return nil
+	}
+	return api.NewValidationError("invalid_content_format", fmt.Sprintf("content_format must be html, markdown or text, got %q", format))
+}
+
+// formatContent returns doc with its HTML content converted to format. HTML
+// and documents without content are returned as they are.
+func formatContent(doc *types.Document, format string) any {
+	if doc.Content == "" || format == "" || format == contentFormatHTML {
+		return doc
+	}
+	converted := &convertedDocument{Document: doc, ContentFormat: format}
+	if format == contentFormatMarkdown {
+		converted.Content = htmlconv.ToMarkdown(doc.Content)
+	} else {
+		converted.Content = htmlconv.ToText(doc.Content)
+	}
+	doc.Content = ""
+	return converted
+}
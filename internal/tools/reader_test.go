@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func TestGetDocumentHandlerContentFormat(t *testing.T) {
+	var withContent []string
+	client, _, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		withContent = append(withContent, r.URL.Query().Get("withHtmlContent"))
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{
+			Count: 1,
+			Results: []types.Document{
+				{ID: "d1", Title: "Post", Content: `<h2>Intro</h2><p>See <a href="https://example.com?utm_source=rss">this</a>.</p><script>track()</script>`},
+			},
+		})
+	})
+	defer ts.Close()
+
+	handler := makeGetDocumentHandler(client)
+	tests := []struct {
+		format      string
+		wantContent string
+		wantHTML    bool
+	}{
+		{"", "", true},
+		{"html", "", true},
+		{"markdown", "## Intro\n\nSee [this](https://example.com).", false},
+		{"text", "Intro\n\nSee this.", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), GetDocumentInput{ID: "d1", ContentFormat: tt.format, IncludeContent: tt.format == ""})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+				t.Fatalf("failed to parse result: %v", err)
+			}
+			if _, ok := got["html"]; ok != tt.wantHTML {
+				t.Errorf("html field present = %v, want %v", ok, tt.wantHTML)
+			}
+			if tt.wantContent != "" && (got["content"] != tt.wantContent || got["content_format"] != tt.format) {
+				t.Errorf("content = %q (%v), want %q", got["content"], got["content_format"], tt.wantContent)
+			}
+			if got["title"] != "Post" {
+				t.Errorf("title = %v, want document metadata kept", got["title"])
+			}
+		})
+	}
+	for i, v := range withContent {
+		if v != "true" {
+			t.Errorf("request %d: withHtmlContent = %q, want true", i, v)
+		}
+	}
+
+	_, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), GetDocumentInput{ID: "d1", ContentFormat: "pdf"})
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_content_format" {
+		t.Errorf("expected invalid_content_format error, got %v", err)
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/rhuss/readwise-mcp-server/internal/api"
 	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
 )
 
 // ListSourcesInput defines the parameters for the list_sources tool.
@@ -39,6 +42,7 @@ type GetHighlightInput struct {
 // ExportHighlightsInput defines the parameters for the export_highlights tool.
 type ExportHighlightsInput struct {
 	UpdatedAfter string `json:"updated_after,omitempty" jsonschema:"ISO 8601 datetime to filter exports updated after"`
+	Format       string `json:"format,omitempty" jsonschema:"Output format: json (default) or markdown, one section per source with frontmatter and highlights as blockquotes"`
 }
 
 // ListSourceTagsInput defines the parameters for the list_source_tags tool.
@@ -52,11 +56,14 @@ type ListHighlightTagsInput struct {
 }
 
 // RegisterReadwiseTools registers the 9 readwise profile tools with the MCP server.
-func RegisterReadwiseTools(s *mcp.Server, client *api.Client) {
+// List and export tools are served through the cache.
+func RegisterReadwiseTools(s *mcp.Server, client *api.Client, cm *cache.Manager, markdown *export.Markdown) {
+	cc := newCachedClient(client, cm)
+
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "list_sources",
 		Description: "List highlight sources (books, articles, etc.) with pagination and optional filtering by category or update time.",
-	}, makeListSourcesHandler(client))
+	}, makeListSourcesHandler(cc))
 
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "get_source",
@@ -75,8 +82,8 @@ func RegisterReadwiseTools(s *mcp.Server, client *api.Client) {
 
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "export_highlights",
-		Description: "Bulk export all highlights grouped by source. Paginates through all pages automatically. Primary data source for search.",
-	}, makeExportHighlightsHandler(client))
+		Description: "Bulk export all highlights grouped by source. Paginates through all pages automatically. Primary data source for search. Set format to markdown for a readable rendering instead of JSON.",
+	}, makeExportHighlightsHandler(cc, markdown))
 
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "get_daily_review",
@@ -94,7 +101,7 @@ func RegisterReadwiseTools(s *mcp.Server, client *api.Client) {
 	}, makeListHighlightTagsHandler(client))
 }
 
-func makeListSourcesHandler(client *api.Client) mcp.ToolHandlerFor[ListSourcesInput, any] {
+func makeListSourcesHandler(client *cachedClient) mcp.ToolHandlerFor[ListSourcesInput, any] {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input ListSourcesInput) (*mcp.CallToolResult, any, error) {
 		apiKey := auth.APIKeyFromRequest(req)
 		if apiKey == "" {
@@ -190,18 +197,33 @@ func makeGetHighlightHandler(client *api.Client) mcp.ToolHandlerFor[GetHighlight
 	}
 }
 
-func makeExportHighlightsHandler(client *api.Client) mcp.ToolHandlerFor[ExportHighlightsInput, any] {
+func makeExportHighlightsHandler(client *cachedClient, markdown *export.Markdown) mcp.ToolHandlerFor[ExportHighlightsInput, any] {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input ExportHighlightsInput) (*mcp.CallToolResult, any, error) {
 		apiKey := auth.APIKeyFromRequest(req)
 		if apiKey == "" {
 			return nil, nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
 		}
+		switch input.Format {
+		case "", "json", "markdown":
+		default:
+			return nil, nil, api.NewValidationError("invalid_format", fmt.Sprintf("format must be json or markdown, got %q", input.Format))
+		}
 
 		result, err := client.ExportHighlights(ctx, apiKey, input.UpdatedAfter)
 		if err != nil {
 			return nil, nil, err
 		}
 
+		if input.Format == "markdown" {
+			var b strings.Builder
+			if err := markdown.Render(&b, result.Results); err != nil {
+				return nil, nil, api.NewInternalError(fmt.Sprintf("failed to render markdown export: %v", err))
+			}
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{&mcp.TextContent{Text: b.String()}},
+			}, nil, nil
+		}
+
 		data, _ := json.Marshal(result)
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
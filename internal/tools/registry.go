@@ -4,11 +4,24 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/rhuss/readwise-mcp-server/internal/api"
 	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/embed"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
 )
 
+// searchIndexUsers is the number of users whose search indexes are kept in
+// memory at the same time.
+const searchIndexUsers = 32
+
+// vectorCacheUsers is the number of users whose highlight embeddings are kept
+// in memory at the same time. Vectors take more memory than indexes.
+const vectorCacheUsers = 8
+
 // RegisterAllTools resolves the given profiles and registers the corresponding
-// tools with the MCP server. Returns an error if profile resolution fails.
-func RegisterAllTools(s *mcp.Server, client *api.Client, cm *cache.Manager, profiles []string) error {
+// tools, resources and prompts with the MCP server. embedder computes the
+// vectors of semantic_search and markdown renders export_highlights in
+// Markdown format. Returns an error if profile resolution fails.
+func RegisterAllTools(s *mcp.Server, client *api.Client, cm *cache.Manager, profiles []string, embedder embed.Embedder, markdown *export.Markdown) error {
 	resolved, err := ResolveProfiles(profiles)
 	if err != nil {
 		return err
@@ -24,19 +37,39 @@ func RegisterAllTools(s *mcp.Server, client *api.Client, cm *cache.Manager, prof
 		profileSet[p] = true
 	}
 
+	indexes := search.NewIndexCache(searchIndexUsers)
+
 	// Register tools based on active profiles
 	if profileSet["readwise"] {
-		RegisterReadwiseTools(s, client)
+		RegisterReadwiseTools(s, client, cm, markdown)
 		if activeTools["search_highlights"] {
-			RegisterSearchHighlightsTool(s, client)
+			RegisterSearchHighlightsTool(s, client, cm, indexes)
+		}
+		if activeTools["export_flashcards"] {
+			RegisterExportFlashcardsTool(s, client, cm)
+		}
+		if activeTools["export_citations"] {
+			RegisterExportCitationsTool(s, client, cm, profileSet["reader"])
+		}
+		if activeTools["list_all_tags"] {
+			RegisterListAllTagsTool(s, client, cm, profileSet["reader"])
+		}
+		if activeTools["find_related_highlights"] {
+			RegisterFindRelatedHighlightsTool(s, client, cm, indexes)
+		}
+		if activeTools["semantic_search"] {
+			RegisterSemanticSearchTool(s, client, cm, embed.NewVectorCache(embedder, vectorCacheUsers))
 		}
 	}
 	if profileSet["reader"] {
-		RegisterReaderTools(s, client)
+		RegisterReaderTools(s, client, cm)
 		if activeTools["search_documents"] {
-			RegisterSearchDocumentsTool(s, client)
+			RegisterSearchDocumentsTool(s, client, cm, indexes)
 		}
 	}
+	if activeTools["search_library"] {
+		RegisterSearchLibraryTool(s, client, cm, indexes)
+	}
 	if profileSet["write"] {
 		RegisterWriteTools(s, client, cm)
 	}
@@ -47,5 +80,8 @@ func RegisterAllTools(s *mcp.Server, client *api.Client, cm *cache.Manager, prof
 		RegisterDestructiveTools(s, client, cm)
 	}
 
+	RegisterResources(s, client, cm, profileSet["readwise"], profileSet["reader"])
+	RegisterPrompts(s, client, cm, indexes, activeTools)
+
 	return nil
 }
@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FindRelatedHighlightsInput defines the parameters for the
+// find_related_highlights tool.
+type FindRelatedHighlightsInput struct {
+	HighlightID       string `json:"highlight_id" jsonschema:"ID of the highlight to find related highlights for"`
+	IncludeSameSource bool   `json:"include_same_source,omitempty" jsonschema:"Also return highlights from the same book or article (default false)"`
+	Limit             int    `json:"limit,omitempty" jsonschema:"Maximum number of results (1-100; default 20)"`
+}
+
+// RegisterFindRelatedHighlightsTool registers the find_related_highlights
+// tool. It uses the highlight indexes shared with search_highlights.
+func RegisterFindRelatedHighlightsTool(s *mcp.Server, client *api.Client, cm *cache.Manager, indexes *search.IndexCache) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "find_related_highlights",
+		Description: "Find highlights similar to a given highlight (\"more like this\"). Compares the words of highlights, notes, source titles and authors across the library, ranked by similarity from 0 to 1. Highlights from the same source are excluded unless include_same_source is set.",
+	}, makeFindRelatedHighlightsHandler(newCachedClient(client, cm), indexes))
+}
+
+func makeFindRelatedHighlightsHandler(client *cachedClient, indexes *search.IndexCache) mcp.ToolHandlerFor[FindRelatedHighlightsInput, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input FindRelatedHighlightsInput) (*mcp.CallToolResult, any, error) {
+		apiKey := auth.APIKeyFromRequest(req)
+		if apiKey == "" {
+			return nil, nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+		if input.HighlightID == "" {
+			return nil, nil, fmt.Errorf("highlight_id is required")
+		}
+		id, err := strconv.ParseInt(input.HighlightID, 10, 64)
+		if err != nil {
+			return nil, nil, api.NewValidationError("invalid_highlight_id", fmt.Sprintf("highlight_id must be numeric, got %q", input.HighlightID))
+		}
+
+		limit := input.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		if limit > 100 {
+			limit = 100
+		}
+
+		exportData, err := client.ExportHighlights(ctx, apiKey, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		_, span := telemetry.Start(ctx, "search.score",
+			attribute.String("search.kind", "related"),
+			attribute.Int("search.sources", len(exportData.Results)),
+		)
+		ix := indexes.Highlights(auth.HashAPIKey(apiKey), exportData.Results)
+		hits, found := ix.Related(id, search.RelatedOptions{IncludeSameSource: input.IncludeSameSource, Limit: limit})
+		span.SetAttributes(attribute.Int("search.results", len(hits)))
+		telemetry.End(span, nil)
+		if !found {
+			return nil, nil, api.NewAPIError("not_found", fmt.Sprintf("highlight %d not found", id))
+		}
+
+		results := make([]SearchHighlightResult, len(hits))
+		for i, hit := range hits {
+			results[i] = SearchHighlightResult{
+				Highlight:      hit.Highlight,
+				SourceTitle:    hit.Source.Title,
+				RelevanceScore: hit.Score,
+			}
+		}
+
+		data, _ := json.Marshal(results)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func relatedTestHandler(calls *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+			Count: 2,
+			Results: []types.ExportSource{
+				{UserBookID: 1, Title: "Peak", Highlights: []types.Highlight{
+					{ID: 1, Text: "Deliberate practice requires focused attention"},
+					{ID: 2, Text: "Deliberate practice needs feedback"},
+				}},
+				{UserBookID: 2, Title: "Deep Work", Highlights: []types.Highlight{
+					{ID: 3, Text: "Focused attention on deliberate practice"},
+				}},
+			},
+		})
+	}
+}
+
+func TestFindRelatedHighlightsHandler(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(relatedTestHandler(&calls))
+	defer ts.Close()
+
+	indexes := search.NewIndexCache(1)
+	handler := makeFindRelatedHighlightsHandler(newCachedClient(client, cm), indexes)
+	run := func(input FindRelatedHighlightsInput) []SearchHighlightResult {
+		t.Helper()
+		result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var results []SearchHighlightResult
+		if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &results); err != nil {
+			t.Fatalf("failed to parse result: %v", err)
+		}
+		return results
+	}
+
+	results := run(FindRelatedHighlightsInput{HighlightID: "1"})
+	if len(results) != 1 || results[0].Highlight.ID != 3 || results[0].SourceTitle != "Deep Work" {
+		t.Errorf("results = %+v, want only highlight 3 from another source", results)
+	}
+
+	results = run(FindRelatedHighlightsInput{HighlightID: "1", IncludeSameSource: true, Limit: 1})
+	if len(results) != 1 {
+		t.Errorf("limit 1 returned %d results", len(results))
+	}
+
+	if calls != 1 {
+		t.Errorf("export fetched %d times, want 1", calls)
+	}
+}
+
+func TestFindRelatedHighlightsHandlerErrors(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(relatedTestHandler(&calls))
+	defer ts.Close()
+
+	handler := makeFindRelatedHighlightsHandler(newCachedClient(client, cm), search.NewIndexCache(1))
+	tests := []struct {
+		id   string
+		code string
+	}{
+		{"abc", "invalid_highlight_id"},
+		{"99", "not_found"},
+	}
+	for _, tt := range tests {
+		_, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), FindRelatedHighlightsInput{HighlightID: tt.id})
+		var apiErr *api.ErrorResponse
+		if !errors.As(err, &apiErr) || apiErr.Code != tt.code {
+			t.Errorf("highlight_id %q: error = %v, want code %s", tt.id, err, tt.code)
+		}
+	}
+
+	if _, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), FindRelatedHighlightsInput{}); err == nil {
+		t.Error("expected error for missing highlight_id")
+	}
+}
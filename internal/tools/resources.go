@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// Resource URI prefixes. Each is followed by the item's ID.
+const (
+	sourceURIPrefix    = "readwise://source/"
+	highlightURIPrefix = "readwise://highlight/"
+	documentURIPrefix  = "reader://document/"
+)
+
+// recentResourceLimit is how many sources and documents resources/list returns.
+const recentResourceLimit = 20
+
+// highlightsPageSize is the page size used to collect all highlights of a source.
+const highlightsPageSize = 1000
+
+// SourceResource is the content of a readwise://source/{id} resource.
+type SourceResource struct {
+	Source     *types.Source     `json:"source"`
+	Highlights []types.Highlight `json:"highlights"`
+}
+
+// RegisterResources registers resource templates for Readwise sources and
+// highlights and/or Reader documents, and extends resources/list with the
+// user's recent sources and documents.
+func RegisterResources(s *mcp.Server, client *api.Client, cm *cache.Manager, sources, documents bool) {
+	if sources {
+		s.AddResourceTemplate(&mcp.ResourceTemplate{
+			Name:        "readwise-source",
+			Title:       "Readwise source",
+			Description: "A Readwise source (book, article, etc.) with all of its highlights.",
+			URITemplate: sourceURIPrefix + "{id}",
+			MIMEType:    "application/json",
+		}, makeSourceResourceHandler(client))
+
+		s.AddResourceTemplate(&mcp.ResourceTemplate{
+			Name:        "readwise-highlight",
+			Title:       "Readwise highlight",
+			Description: "A single Readwise highlight.",
+			URITemplate: highlightURIPrefix + "{id}",
+			MIMEType:    "application/json",
+		}, makeHighlightResourceHandler(client))
+	}
+
+	if documents {
+		s.AddResourceTemplate(&mcp.ResourceTemplate{
+			Name:        "reader-document",
+			Title:       "Reader document",
+			Description: "A Reader document including its full HTML content.",
+			URITemplate: documentURIPrefix + "{id}",
+			MIMEType:    "application/json",
+		}, makeDocumentResourceHandler(client))
+	}
+
+	if sources || documents {
+		s.AddReceivingMiddleware(recentResourcesMiddleware(newCachedClient(client, cm), sources, documents))
+	}
+}
+
+func makeSourceResourceHandler(client *api.Client) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		uri := req.Params.URI
+		apiKey, id, err := resourceRequest(req, sourceURIPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		source, err := client.GetBook(ctx, apiKey, id)
+		if err != nil {
+			return nil, resourceError(uri, err)
+		}
+
+		highlights, err := sourceHighlights(ctx, client, apiKey, id)
+		if err != nil {
+			return nil, err
+		}
+
+		return jsonResource(uri, SourceResource{Source: source, Highlights: highlights})
+	}
+}
+
+func makeHighlightResourceHandler(client *api.Client) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		uri := req.Params.URI
+		apiKey, id, err := resourceRequest(req, highlightURIPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		highlight, err := client.GetHighlight(ctx, apiKey, id)
+		if err != nil {
+			return nil, resourceError(uri, err)
+		}
+		return jsonResource(uri, highlight)
+	}
+}
+
+func makeDocumentResourceHandler(client *api.Client) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		uri := req.Params.URI
+		apiKey, id, err := resourceRequest(req, documentURIPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := client.GetDocument(ctx, apiKey, id, true)
+		if err != nil {
+			return nil, resourceError(uri, err)
+		}
+		return jsonResource(uri, doc)
+	}
+}
+
+// sourceHighlights returns all highlights of a source, following pagination.
+func sourceHighlights(ctx context.Context, client *api.Client, apiKey, sourceID string) ([]types.Highlight, error) {
+	var highlights []types.Highlight
+	for page := 1; ; page++ {
+		result, err := client.ListHighlights(ctx, apiKey, page, highlightsPageSize, sourceID, "")
+		if err != nil {
+			return nil, err
+		}
+		highlights = append(highlights, result.Results...)
+		if result.Next == "" {
+			return highlights, nil
+		}
+	}
+}
+
+// resourceRequest returns the API key and the item ID of a resource read.
+func resourceRequest(req *mcp.ReadResourceRequest, prefix string) (apiKey, id string, err error) {
+	apiKey = auth.APIKeyFromExtra(req.Extra)
+	if apiKey == "" {
+		return "", "", fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+	}
+
+	id = strings.TrimPrefix(req.Params.URI, prefix)
+	if id == "" || strings.ContainsAny(id, "/?#") {
+		return "", "", mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	return apiKey, id, nil
+}
+
+// resourceError reports upstream "not found" responses as a missing resource.
+func resourceError(uri string, err error) error {
+	if apiErr, ok := err.(*api.ErrorResponse); ok && (apiErr.Code == "not_found" || apiErr.Code == "http_404") {
+		return mcp.ResourceNotFoundError(uri)
+	}
+	return err
+}
+
+func jsonResource(uri string, v any) (*mcp.ReadResourceResult, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, api.NewInternalError(fmt.Sprintf("failed to encode resource: %v", err))
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		}},
+	}, nil
+}
+
+// recentResourcesMiddleware appends the caller's recently highlighted sources
+// and recently updated documents to the first page of resources/list. The
+// listings are read through the cache. A listing that fails is logged and
+// left out, so the static resources are still returned.
+func recentResourcesMiddleware(client *cachedClient, sources, documents bool) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			result, err := next(ctx, method, req)
+
+			listReq, ok := req.(*mcp.ListResourcesRequest)
+			if !ok || err != nil {
+				return result, err
+			}
+			if listReq.Params != nil && listReq.Params.Cursor != "" {
+				return result, nil
+			}
+			apiKey := auth.APIKeyFromExtra(listReq.Extra)
+			if apiKey == "" {
+				return result, nil
+			}
+
+			list := result.(*mcp.ListResourcesResult)
+			if sources {
+				recent, err := recentSources(ctx, client, apiKey)
+				if err != nil {
+					client.client.Logger().Warn("listing recent sources failed", "error", err)
+				}
+				list.Resources = append(list.Resources, recent...)
+			}
+			if documents {
+				recent, err := recentDocuments(ctx, client, apiKey)
+				if err != nil {
+					client.client.Logger().Warn("listing recent documents failed", "error", err)
+				}
+				list.Resources = append(list.Resources, recent...)
+			}
+			return list, nil
+		}
+	}
+}
+
+// recentSources returns resources for the most recently highlighted sources.
+func recentSources(ctx context.Context, client *cachedClient, apiKey string) ([]*mcp.Resource, error) {
+	result, err := client.ListBooks(ctx, apiKey, 1, 100, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	books := slices.Clone(result.Results)
+	slices.SortStableFunc(books, func(a, b types.Source) int {
+		return b.LastHighlightAt.Compare(a.LastHighlightAt)
+	})
+
+	resources := make([]*mcp.Resource, 0, min(len(books), recentResourceLimit))
+	for _, b := range books[:min(len(books), recentResourceLimit)] {
+		resources = append(resources, &mcp.Resource{
+			URI:         fmt.Sprintf("%s%d", sourceURIPrefix, b.ID),
+			Name:        b.Title,
+			Description: resourceDescription(b.Category, b.Author),
+			MIMEType:    "application/json",
+		})
+	}
+	return resources, nil
+}
+
+// recentDocuments returns resources for the most recently updated top-level
+// Reader documents. Highlights and notes, which Reader lists as child
+// documents, are skipped.
+func recentDocuments(ctx context.Context, client *cachedClient, apiKey string) ([]*mcp.Resource, error) {
+	result, err := client.ListDocuments(ctx, apiKey, "", "", "", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []types.Document
+	for _, d := range result.Results {
+		if d.ParentID == "" {
+			docs = append(docs, d)
+		}
+	}
+	slices.SortStableFunc(docs, func(a, b types.Document) int {
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+
+	resources := make([]*mcp.Resource, 0, min(len(docs), recentResourceLimit))
+	for _, d := range docs[:min(len(docs), recentResourceLimit)] {
+		resources = append(resources, &mcp.Resource{
+			URI:         documentURIPrefix + d.ID,
+			Name:        d.Title,
+			Description: resourceDescription(d.Category, d.Author),
+			MIMEType:    "application/json",
+		})
+	}
+	return resources, nil
+}
+
+func resourceDescription(category, author string) string {
+	if author == "" {
+		return category
+	}
+	return category + " by " + author
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func resourcesTestHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	switch r.URL.Path {
+	case "/books/":
+		json.NewEncoder(w).Encode(types.PageResponse[types.Source]{
+			Count: 2,
+			Results: []types.Source{
+				{ID: 1, Title: "Older", Category: "books", LastHighlightAt: now.Add(-time.Hour)},
+				{ID: 2, Title: "Newer", Category: "articles", Author: "Ann", LastHighlightAt: now},
+			},
+		})
+	case "/books/1/":
+		json.NewEncoder(w).Encode(types.Source{ID: 1, Title: "Older"})
+	case "/books/404/":
+		w.WriteHeader(http.StatusNotFound)
+	case "/highlights/":
+		if r.URL.Query().Get("page") == "2" {
+			json.NewEncoder(w).Encode(types.PageResponse[types.Highlight]{
+				Results: []types.Highlight{{ID: 11, Text: "second", BookID: 1}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(types.PageResponse[types.Highlight]{
+			Next:    "page2",
+			Results: []types.Highlight{{ID: 10, Text: "first", BookID: 1}},
+		})
+	case "/highlights/10/":
+		json.NewEncoder(w).Encode(types.Highlight{ID: 10, Text: "first", BookID: 1})
+	case "/list/":
+		if id := r.URL.Query().Get("id"); id != "" {
+			var docs []types.Document
+			if id == "doc-1" {
+				docs = append(docs, types.Document{ID: "doc-1", Title: "Doc", Content: "<p>hi</p>"})
+			}
+			json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{Results: docs})
+			return
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{
+			Results: []types.Document{
+				{ID: "doc-1", Title: "Doc", Category: "article", UpdatedAt: now},
+				{ID: "hl-1", Title: "Highlight", ParentID: "doc-1", UpdatedAt: now.Add(time.Hour)},
+			},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// connectTestSession serves the tools, resources or prompts added by register
+// over in-memory transports, with the API key injected as in stdio mode.
+func connectTestSession(t *testing.T, handler http.HandlerFunc, register func(*mcp.Server, *api.Client, *cache.Manager)) *mcp.ClientSession {
+	t.Helper()
+	client, cm, ts := newWriteTestDeps(handler)
+	t.Cleanup(ts.Close)
+
+	s := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "1.0.0"}, nil)
+	register(s, client, cm)
+	s.AddReceivingMiddleware(auth.StaticKeyMiddleware("test-key"))
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go s.Run(ctx, serverTransport)
+
+	c := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "1.0.0"}, nil)
+	session, err := c.Connect(context.Background(), clientTransport, nil)
+	if err != nil {
+		t.Fatalf("Connect() error: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func connectResourcesTest(t *testing.T, sources, documents bool) *mcp.ClientSession {
+	t.Helper()
+	return connectTestSession(t, resourcesTestHandler, func(s *mcp.Server, client *api.Client, cm *cache.Manager) {
+		RegisterResources(s, client, cm, sources, documents)
+	})
+}
+
+func TestReadSourceResource(t *testing.T) {
+	session := connectResourcesTest(t, true, false)
+
+	result, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "readwise://source/1"})
+	if err != nil {
+		t.Fatalf("ReadResource() error: %v", err)
+	}
+	if len(result.Contents) != 1 {
+		t.Fatalf("expected 1 content, got %d", len(result.Contents))
+	}
+	if result.Contents[0].MIMEType != "application/json" {
+		t.Errorf("MIMEType = %q, want application/json", result.Contents[0].MIMEType)
+	}
+
+	var res SourceResource
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &res); err != nil {
+		t.Fatalf("failed to parse resource: %v", err)
+	}
+	if res.Source == nil || res.Source.Title != "Older" {
+		t.Errorf("source = %+v, want title Older", res.Source)
+	}
+	if len(res.Highlights) != 2 {
+		t.Errorf("expected highlights from both pages, got %d", len(res.Highlights))
+	}
+}
+
+func TestReadHighlightAndDocumentResources(t *testing.T) {
+	session := connectResourcesTest(t, true, true)
+
+	result, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "readwise://highlight/10"})
+	if err != nil {
+		t.Fatalf("ReadResource(highlight) error: %v", err)
+	}
+	var h types.Highlight
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &h); err != nil {
+		t.Fatalf("failed to parse highlight: %v", err)
+	}
+	if h.ID != 10 {
+		t.Errorf("highlight ID = %d, want 10", h.ID)
+	}
+
+	result, err = session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "reader://document/doc-1"})
+	if err != nil {
+		t.Fatalf("ReadResource(document) error: %v", err)
+	}
+	var doc types.Document
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &doc); err != nil {
+		t.Fatalf("failed to parse document: %v", err)
+	}
+	if doc.Content != "<p>hi</p>" {
+		t.Errorf("Content = %q, want document content", doc.Content)
+	}
+}
+
+func TestReadResourceNotFound(t *testing.T) {
+	session := connectResourcesTest(t, true, true)
+
+	for _, uri := range []string{"readwise://source/404", "reader://document/missing"} {
+		_, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri})
+		var rpcErr *jsonrpc.Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code != mcp.CodeResourceNotFound {
+			t.Errorf("ReadResource(%s) error = %v, want resource not found", uri, err)
+		}
+	}
+}
+
+func TestReadResourceProfileGating(t *testing.T) {
+	session := connectResourcesTest(t, false, true)
+
+	if _, err := session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: "readwise://source/1"}); err == nil {
+		t.Error("expected error for source resource without the readwise profile")
+	}
+}
+
+func TestListRecentResources(t *testing.T) {
+	session := connectResourcesTest(t, true, true)
+
+	result, err := session.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListResources() error: %v", err)
+	}
+
+	var uris []string
+	for _, r := range result.Resources {
+		uris = append(uris, r.URI)
+	}
+	want := []string{"readwise://source/2", "readwise://source/1", "reader://document/doc-1"}
+	if len(uris) != len(want) {
+		t.Fatalf("resources = %v, want %v", uris, want)
+	}
+	for i := range want {
+		if uris[i] != want[i] {
+			t.Errorf("resources[%d] = %q, want %q", i, uris[i], want[i])
+		}
+	}
+	if result.Resources[0].Description != "articles by Ann" {
+		t.Errorf("Description = %q, want %q", result.Resources[0].Description, "articles by Ann")
+	}
+}
+
+func TestListRecentResourcesSkipsFailedListing(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/list/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resourcesTestHandler(w, r)
+	}
+	session := connectTestSession(t, handler, func(s *mcp.Server, client *api.Client, cm *cache.Manager) {
+		RegisterResources(s, client, cm, true, true)
+	})
+
+	result, err := session.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListResources() error: %v", err)
+	}
+	var uris []string
+	for _, r := range result.Resources {
+		uris = append(uris, r.URI)
+	}
+	want := []string{"readwise://source/2", "readwise://source/1"}
+	if !slices.Equal(uris, want) {
+		t.Errorf("resources = %v, want %v", uris, want)
+	}
+}
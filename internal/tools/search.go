@@ -3,61 +3,84 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"sort"
-	"strings"
+	"strconv"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/rhuss/readwise-mcp-server/internal/api"
 	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
 	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// querySyntaxHelp describes the query language shared by the search tools.
+const querySyntaxHelp = `Query syntax: words must all match unless joined with OR and also match other forms of the same word (negotiate, negotiation); "quoted phrase" matches adjacent words; NOT or a leading - excludes; parentheses group; field filters take the form key:value or key:"two words". Set fuzzy to tolerate typos.`
+
 // SearchHighlightsInput defines the parameters for the search_highlights tool.
 type SearchHighlightsInput struct {
-	Query    string `json:"query" jsonschema:"Search query to match against highlight text and notes and source titles"`
-	SourceID string `json:"source_id,omitempty" jsonschema:"Filter results to a specific source ID"`
-	Limit    int    `json:"limit,omitempty" jsonschema:"Maximum number of results (1-200; default 50)"`
+	Query        string `json:"query" jsonschema:"Search query; supports quoted phrases and AND/OR/NOT and field filters such as author:clear tag:favorite is:favorite after:2024-01-01"`
+	SourceID     string `json:"source_id,omitempty" jsonschema:"Filter results to a specific source ID"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"Maximum number of results (1-200; default 50)"`
+	Fuzzy        bool   `json:"fuzzy,omitempty" jsonschema:"Also match words with typos; such matches rank below exact ones"`
+	SnippetsOnly bool   `json:"snippets_only,omitempty" jsonschema:"Return highlight and source IDs with match snippets instead of full highlights, to keep responses small"`
 }
 
-// SearchHighlightResult represents a single search result.
+// SearchHighlightResult represents a single search result. With snippets_only
+// set, Highlight is omitted and HighlightID and SourceID identify the match.
 type SearchHighlightResult struct {
-	Highlight      types.Highlight `json:"highlight"`
-	SourceTitle    string          `json:"source_title"`
-	RelevanceScore float64         `json:"relevance_score"`
+	Highlight      *types.Highlight `json:"highlight,omitempty"`
+	HighlightID    int64            `json:"highlight_id,omitempty"`
+	SourceID       int64            `json:"source_id,omitempty"`
+	SourceTitle    string           `json:"source_title"`
+	RelevanceScore float64          `json:"relevance_score"`
+	Snippets       []search.Snippet `json:"snippets,omitempty"`
 }
 
 // SearchDocumentsInput defines the parameters for the search_documents tool.
 type SearchDocumentsInput struct {
-	Query    string `json:"query" jsonschema:"Search query to match against document title and author and summary and notes"`
-	Location string `json:"location,omitempty" jsonschema:"Filter by location: new later shortlist archive feed"`
-	Category string `json:"category,omitempty" jsonschema:"Filter by category: article email rss highlight note pdf epub tweet video"`
-	Limit    int    `json:"limit,omitempty" jsonschema:"Maximum number of results (1-200; default 50)"`
+	Query         string `json:"query" jsonschema:"Search query; supports quoted phrases and AND/OR/NOT and field filters such as author:graham location:later before:2024-06-01"`
+	Location      string `json:"location,omitempty" jsonschema:"Filter by location: new later shortlist archive feed"`
+	Category      string `json:"category,omitempty" jsonschema:"Filter by category: article email rss highlight note pdf epub tweet video"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"Maximum number of results (1-200; default 50)"`
+	Fuzzy         bool   `json:"fuzzy,omitempty" jsonschema:"Also match words with typos; such matches rank below exact ones"`
+	SnippetsOnly  bool   `json:"snippets_only,omitempty" jsonschema:"Return document IDs and titles with match snippets instead of full documents, to keep responses small"`
+	SearchContent bool   `json:"search_content,omitempty" jsonschema:"Also match the full text of documents; the first such search fetches the content of all documents and can take a while"`
 }
 
-// SearchDocumentResult represents a single document search result.
+// SearchDocumentResult represents a single document search result. With
+// snippets_only set, Document is omitted and DocumentID and Title identify
+// the match.
 type SearchDocumentResult struct {
-	Document       types.Document `json:"document"`
-	RelevanceScore float64        `json:"relevance_score"`
+	Document       *types.Document  `json:"document,omitempty"`
+	DocumentID     string           `json:"document_id,omitempty"`
+	Title          string           `json:"title,omitempty"`
+	RelevanceScore float64          `json:"relevance_score"`
+	Snippets       []search.Snippet `json:"snippets,omitempty"`
 }
 
-// RegisterSearchHighlightsTool registers the search_highlights tool.
-func RegisterSearchHighlightsTool(s *mcp.Server, client *api.Client) {
+// RegisterSearchHighlightsTool registers the search_highlights tool. Each
+// user's highlight index is kept in indexes.
+func RegisterSearchHighlightsTool(s *mcp.Server, client *api.Client, cm *cache.Manager, indexes *search.IndexCache) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "search_highlights",
-		Description: "Search highlights by query. Searches across highlight text, notes, and source titles. Returns results ranked by relevance.",
-	}, makeSearchHighlightsHandler(client))
+		Description: "Search highlights by query. Matches highlights containing all query words across highlight text, notes, source titles and authors, ranked by relevance (BM25). " + querySyntaxHelp + " Filters: author: title: tag: color: category: before: after: (highlighted date, YYYY-MM-DD) is:favorite.",
+	}, makeSearchHighlightsHandler(newCachedClient(client, cm), indexes))
 }
 
-// RegisterSearchDocumentsTool registers the search_documents tool.
-func RegisterSearchDocumentsTool(s *mcp.Server, client *api.Client) {
+// RegisterSearchDocumentsTool registers the search_documents tool. Each
+// user's document index is kept in indexes.
+func RegisterSearchDocumentsTool(s *mcp.Server, client *api.Client, cm *cache.Manager, indexes *search.IndexCache) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "search_documents",
-		Description: "Search Reader documents by query. Searches across title, author, summary, and notes. Supports location and category filtering.",
-	}, makeSearchDocumentsHandler(client))
+		Description: "Search Reader documents by query. Matches documents containing all query words across title, author, summary and notes, ranked by relevance (BM25). Set search_content to also match the document body text. " + querySyntaxHelp + " Filters: author: title: tag: category: location: before: after: (saved date, YYYY-MM-DD).",
+	}, makeSearchDocumentsHandler(newCachedClient(client, cm), indexes))
 }
 
-func makeSearchHighlightsHandler(client *api.Client) mcp.ToolHandlerFor[SearchHighlightsInput, any] {
+func makeSearchHighlightsHandler(client *cachedClient, indexes *search.IndexCache) mcp.ToolHandlerFor[SearchHighlightsInput, any] {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input SearchHighlightsInput) (*mcp.CallToolResult, any, error) {
 		apiKey := auth.APIKeyFromRequest(req)
 		if apiKey == "" {
@@ -67,21 +90,29 @@ func makeSearchHighlightsHandler(client *api.Client) mcp.ToolHandlerFor[SearchHi
 			return nil, nil, fmt.Errorf("query is required")
 		}
 
-		limit := input.Limit
-		if limit <= 0 {
-			limit = 50
+		if input.Limit <= 0 {
+			input.Limit = 50
 		}
-		if limit > 200 {
-			limit = 200
+		if input.Limit > 200 {
+			input.Limit = 200
 		}
 
-		// Fetch export data (in future phases, this will use the cache)
 		exportData, err := client.ExportHighlights(ctx, apiKey, "")
 		if err != nil {
 			return nil, nil, err
 		}
 
-		results := searchHighlights(exportData.Results, input.Query, input.SourceID, limit)
+		_, span := telemetry.Start(ctx, "search.score",
+			attribute.String("search.kind", "highlights"),
+			attribute.Int("search.sources", len(exportData.Results)),
+		)
+		ix := indexes.Highlights(auth.HashAPIKey(apiKey), exportData.Results)
+		results, err := searchHighlightIndex(ix, input)
+		span.SetAttributes(attribute.Int("search.results", len(results)))
+		telemetry.End(span, err)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		data, _ := json.Marshal(results)
 		return &mcp.CallToolResult{
@@ -90,7 +121,7 @@ func makeSearchHighlightsHandler(client *api.Client) mcp.ToolHandlerFor[SearchHi
 	}
 }
 
-func makeSearchDocumentsHandler(client *api.Client) mcp.ToolHandlerFor[SearchDocumentsInput, any] {
+func makeSearchDocumentsHandler(client *cachedClient, indexes *search.IndexCache) mcp.ToolHandlerFor[SearchDocumentsInput, any] {
 	return func(ctx context.Context, req *mcp.CallToolRequest, input SearchDocumentsInput) (*mcp.CallToolResult, any, error) {
 		apiKey := auth.APIKeyFromRequest(req)
 		if apiKey == "" {
@@ -100,21 +131,43 @@ func makeSearchDocumentsHandler(client *api.Client) mcp.ToolHandlerFor[SearchDoc
 			return nil, nil, fmt.Errorf("query is required")
 		}
 
-		limit := input.Limit
-		if limit <= 0 {
-			limit = 50
+		if input.Limit <= 0 {
+			input.Limit = 50
 		}
-		if limit > 200 {
-			limit = 200
+		if input.Limit > 200 {
+			input.Limit = 200
 		}
 
-		// Fetch document list (in future phases, this will use the cache)
 		docData, err := client.ListDocuments(ctx, apiKey, "", "", "", 0)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		results := searchDocuments(docData.Results, input.Query, input.Location, input.Category, limit)
+		var content map[string]string
+		if input.SearchContent {
+			content, err = client.DocumentContent(ctx, apiKey)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		_, span := telemetry.Start(ctx, "search.score",
+			attribute.String("search.kind", "documents"),
+			attribute.Int("search.documents", len(docData.Results)),
+			attribute.Bool("search.content", input.SearchContent),
+		)
+		var ix *search.DocumentIndex
+		if input.SearchContent {
+			ix = indexes.DocumentContent(auth.HashAPIKey(apiKey), docData.Results, content)
+		} else {
+			ix = indexes.Documents(auth.HashAPIKey(apiKey), docData.Results)
+		}
+		results, err := searchDocumentIndex(ix, input)
+		span.SetAttributes(attribute.Int("search.results", len(results)))
+		telemetry.End(span, err)
+		if err != nil {
+			return nil, nil, err
+		}
 
 		data, _ := json.Marshal(results)
 		return &mcp.CallToolResult{
@@ -123,129 +176,72 @@ func makeSearchDocumentsHandler(client *api.Client) mcp.ToolHandlerFor[SearchDoc
 	}
 }
 
-// searchHighlights performs case-insensitive search across export data.
-func searchHighlights(sources []types.ExportSource, query, sourceID string, limit int) []SearchHighlightResult {
-	queryLower := strings.ToLower(query)
-	var results []SearchHighlightResult
-
-	for _, source := range sources {
-		if sourceID != "" && fmt.Sprintf("%d", source.UserBookID) != sourceID {
-			continue
+// searchHighlightIndex runs a highlight search on a prebuilt index.
+func searchHighlightIndex(ix *search.HighlightIndex, input SearchHighlightsInput) ([]SearchHighlightResult, error) {
+	opts := search.HighlightOptions{Limit: input.Limit, Fuzzy: input.Fuzzy}
+	if input.SourceID != "" {
+		id, err := strconv.ParseInt(input.SourceID, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, api.NewValidationError("invalid_source_id", fmt.Sprintf("source_id must be a positive number, got %q", input.SourceID))
 		}
-
-		titleLower := strings.ToLower(source.Title)
-
-		for _, h := range source.Highlights {
-			score := scoreHighlight(h, titleLower, queryLower)
-			if score > 0 {
-				results = append(results, SearchHighlightResult{
-					Highlight:      h,
-					SourceTitle:    source.Title,
-					RelevanceScore: score,
-				})
-			}
-		}
-	}
-
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].RelevanceScore > results[j].RelevanceScore
-	})
-
-	if len(results) > limit {
-		results = results[:limit]
+		opts.SourceID = id
 	}
 
-	return results
-}
-
-// scoreHighlight calculates a relevance score for a highlight against a query.
-func scoreHighlight(h types.Highlight, titleLower, queryLower string) float64 {
-	var score float64
-
-	textLower := strings.ToLower(h.Text)
-	noteLower := strings.ToLower(h.Note)
-
-	// Exact matches score higher
-	if strings.EqualFold(h.Text, queryLower) {
-		score += 10.0
-	} else if strings.Contains(textLower, queryLower) {
-		score += 5.0
+	hits, err := ix.Search(input.Query, opts)
+	if err != nil {
+		return nil, queryError(err)
 	}
 
-	if strings.EqualFold(h.Note, queryLower) {
-		score += 8.0
-	} else if noteLower != "" && strings.Contains(noteLower, queryLower) {
-		score += 4.0
+	var results []SearchHighlightResult
+	for _, hit := range hits {
+		result := SearchHighlightResult{
+			SourceTitle:    hit.Source.Title,
+			RelevanceScore: hit.Score,
+			Snippets:       hit.Snippets,
+		}
+		if input.SnippetsOnly {
+			result.HighlightID, result.SourceID = hit.Highlight.ID, hit.Source.UserBookID
+		} else {
+			result.Highlight = hit.Highlight
+		}
+		results = append(results, result)
 	}
+	return results, nil
+}
 
-	if strings.Contains(titleLower, queryLower) {
-		score += 3.0
+// searchDocumentIndex runs a document search on a prebuilt index.
+func searchDocumentIndex(ix *search.DocumentIndex, input SearchDocumentsInput) ([]SearchDocumentResult, error) {
+	hits, err := ix.Search(input.Query, search.DocumentOptions{
+		Location: input.Location,
+		Category: input.Category,
+		Limit:    input.Limit,
+		Fuzzy:    input.Fuzzy,
+	})
+	if err != nil {
+		return nil, queryError(err)
 	}
 
-	return score
-}
-
-// searchDocuments performs case-insensitive search across document data.
-func searchDocuments(docs []types.Document, query, location, category string, limit int) []SearchDocumentResult {
-	queryLower := strings.ToLower(query)
 	var results []SearchDocumentResult
-
-	for _, doc := range docs {
-		if location != "" && doc.Location != location {
-			continue
-		}
-		if category != "" && doc.Category != category {
-			continue
+	for _, hit := range hits {
+		result := SearchDocumentResult{
+			RelevanceScore: hit.Score,
+			Snippets:       hit.Snippets,
 		}
-
-		score := scoreDocument(doc, queryLower)
-		if score > 0 {
-			results = append(results, SearchDocumentResult{
-				Document:       doc,
-				RelevanceScore: score,
-			})
+		if input.SnippetsOnly {
+			result.DocumentID, result.Title = hit.Document.ID, hit.Document.Title
+		} else {
+			result.Document = hit.Document
 		}
+		results = append(results, result)
 	}
-
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].RelevanceScore > results[j].RelevanceScore
-	})
-
-	if len(results) > limit {
-		results = results[:limit]
-	}
-
-	return results
+	return results, nil
 }
 
-// scoreDocument calculates a relevance score for a document against a query.
-func scoreDocument(doc types.Document, queryLower string) float64 {
-	var score float64
-
-	titleLower := strings.ToLower(doc.Title)
-	authorLower := strings.ToLower(doc.Author)
-	summaryLower := strings.ToLower(doc.Summary)
-	notesLower := strings.ToLower(doc.Notes)
-
-	if strings.EqualFold(doc.Title, queryLower) {
-		score += 10.0
-	} else if strings.Contains(titleLower, queryLower) {
-		score += 5.0
-	}
-
-	if strings.EqualFold(doc.Author, queryLower) {
-		score += 8.0
-	} else if authorLower != "" && strings.Contains(authorLower, queryLower) {
-		score += 4.0
-	}
-
-	if summaryLower != "" && strings.Contains(summaryLower, queryLower) {
-		score += 3.0
+// queryError reports an invalid search query as a validation error.
+func queryError(err error) error {
+	var parseErr *search.ParseError
+	if errors.As(err, &parseErr) {
+		return api.NewValidationError("invalid_query", parseErr.Error())
 	}
-
-	if notesLower != "" && strings.Contains(notesLower, queryLower) {
-		score += 3.0
-	}
-
-	return score
+	return err
 }
@@ -1,8 +1,17 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/search"
 	"github.com/rhuss/readwise-mcp-server/internal/types"
 )
 
@@ -19,7 +28,10 @@ func TestSearchHighlightsExactMatch(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "Hello world", "", 50)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "Hello world", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) == 0 {
 		t.Fatal("expected results")
 	}
@@ -40,7 +52,10 @@ func TestSearchHighlightsCaseInsensitive(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "important note", "", 50)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "important note", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -57,7 +72,10 @@ func TestSearchHighlightsInNotes(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "key insight", "", 50)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "key insight", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -74,7 +92,10 @@ func TestSearchHighlightsInSourceTitle(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "Atomic Habits", "", 50)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "Atomic Habits", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -98,7 +119,10 @@ func TestSearchHighlightsSourceIDFilter(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "matching", "1", 50)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "matching", SourceID: "1", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -107,6 +131,20 @@ func TestSearchHighlightsSourceIDFilter(t *testing.T) {
 	}
 }
 
+func TestSearchHighlightsInvalidSourceID(t *testing.T) {
+	sources := []types.ExportSource{
+		{UserBookID: 1, Title: "Book", Highlights: []types.Highlight{{ID: 1, Text: "matching text"}}},
+	}
+
+	for _, id := range []string{"abc", "0"} {
+		_, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "matching", SourceID: id, Limit: 50})
+		apiErr, ok := err.(*api.ErrorResponse)
+		if !ok || apiErr.Code != "invalid_source_id" {
+			t.Errorf("source_id %q: error = %v, want invalid_source_id", id, err)
+		}
+	}
+}
+
 func TestSearchHighlightsLimit(t *testing.T) {
 	sources := []types.ExportSource{
 		{
@@ -120,7 +158,10 @@ func TestSearchHighlightsLimit(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "matching", "", 2)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "matching", Limit: 2})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) != 2 {
 		t.Fatalf("expected 2 results, got %d", len(results))
 	}
@@ -137,7 +178,10 @@ func TestSearchHighlightsNoMatch(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "nonexistent query", "", 50)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "nonexistent query", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) != 0 {
 		t.Fatalf("expected 0 results, got %d", len(results))
 	}
@@ -155,7 +199,10 @@ func TestSearchHighlightsRelevanceScoring(t *testing.T) {
 		},
 	}
 
-	results := searchHighlights(sources, "query", "", 50)
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "query", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
 	if len(results) != 2 {
 		t.Fatalf("expected 2 results, got %d", len(results))
 	}
@@ -168,6 +215,182 @@ func TestSearchHighlightsRelevanceScoring(t *testing.T) {
 	}
 }
 
+func TestSearchHighlightsWordsApart(t *testing.T) {
+	sources := []types.ExportSource{
+		{
+			UserBookID: 1,
+			Title:      "Book",
+			Highlights: []types.Highlight{
+				{ID: 1, Text: "Formation of a habit starts small"},
+				{ID: 2, Text: "A habit is a routine"},
+			},
+		},
+	}
+
+	results, err := searchHighlightIndex(search.NewHighlightIndex(sources), SearchHighlightsInput{Query: "habit formation", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchHighlightIndex() error: %v", err)
+	}
+	if len(results) != 1 || results[0].Highlight.ID != 1 {
+		t.Fatalf("expected highlight 1 only, got %+v", results)
+	}
+	if results[0].RelevanceScore <= 0 {
+		t.Errorf("expected a positive relevance score, got %f", results[0].RelevanceScore)
+	}
+}
+
+func TestSearchHighlightsHandlerInvalidQuery(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(exportTestHandler(&calls))
+	defer ts.Close()
+
+	handler := makeSearchHighlightsHandler(newCachedClient(client, cm), search.NewIndexCache(1))
+	_, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), SearchHighlightsInput{Query: `cached AND (`})
+
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *api.ErrorResponse, got %v", err)
+	}
+	if apiErr.Type != "validation_error" || apiErr.Code != "invalid_query" {
+		t.Errorf("error = %s/%s, want validation_error/invalid_query", apiErr.Type, apiErr.Code)
+	}
+	if !strings.Contains(apiErr.Message, "position 13") {
+		t.Errorf("message %q should report position 13", apiErr.Message)
+	}
+}
+
+func TestSearchHighlightsHandlerFuzzy(t *testing.T) {
+	calls := 0
+	client, cm, ts := newWriteTestDeps(exportTestHandler(&calls))
+	defer ts.Close()
+
+	handler := makeSearchHighlightsHandler(newCachedClient(client, cm), search.NewIndexCache(1))
+	run := func(input SearchHighlightsInput) []SearchHighlightResult {
+		t.Helper()
+		result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var results []SearchHighlightResult
+		if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &results); err != nil {
+			t.Fatalf("failed to parse result: %v", err)
+		}
+		return results
+	}
+
+	if results := run(SearchHighlightsInput{Query: "higlight"}); len(results) != 0 {
+		t.Errorf("typo should not match without fuzzy, got %+v", results)
+	}
+	if results := run(SearchHighlightsInput{Query: "higlight", Fuzzy: true}); len(results) != 1 || results[0].Highlight.ID != 10 {
+		t.Errorf("fuzzy search should find highlight 10, got %+v", results)
+	}
+	if results := run(SearchHighlightsInput{Query: "highlighting"}); len(results) != 1 {
+		t.Errorf("other word forms should match without fuzzy, got %+v", results)
+	}
+}
+
+func TestSearchHighlightsSnippets(t *testing.T) {
+	ix := search.NewHighlightIndex([]types.ExportSource{
+		{UserBookID: 7, Title: "Deep Work", Highlights: []types.Highlight{
+			{ID: 1, Text: "Clarity about what matters provides clarity about what does not", Note: "work on what matters"},
+		}},
+	})
+
+	results, err := searchHighlightIndex(ix, SearchHighlightsInput{Query: "matters"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Highlight == nil {
+		t.Fatalf("expected one full result, got %+v", results)
+	}
+	want := []search.Snippet{
+		{Field: "text", Text: "Clarity about what **matters** provides clarity about what does not"},
+		{Field: "note", Text: "work on what **matters**"},
+	}
+	if !reflect.DeepEqual(results[0].Snippets, want) {
+		t.Errorf("snippets = %q, want %q", results[0].Snippets, want)
+	}
+
+	results, _ = searchHighlightIndex(ix, SearchHighlightsInput{Query: "matters", SnippetsOnly: true})
+	if len(results) != 1 {
+		t.Fatalf("expected one result, got %+v", results)
+	}
+	r := results[0]
+	if r.Highlight != nil || r.HighlightID != 1 || r.SourceID != 7 || len(r.Snippets) != 2 {
+		t.Errorf("snippets_only result = %+v", r)
+	}
+}
+
+func TestSearchDocumentsSnippetsOnly(t *testing.T) {
+	ix := search.NewDocumentIndex([]types.Document{
+		{ID: "a", Title: "Go Programming", Summary: "A tour of concurrency in Go"},
+	})
+
+	results, err := searchDocumentIndex(ix, SearchDocumentsInput{Query: "concurrency", SnippetsOnly: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected one result, got %+v", results)
+	}
+	r := results[0]
+	if r.Document != nil || r.DocumentID != "a" || r.Title != "Go Programming" {
+		t.Errorf("snippets_only result = %+v", r)
+	}
+	want := []search.Snippet{{Field: "summary", Text: "A tour of **concurrency** in Go"}}
+	if !reflect.DeepEqual(r.Snippets, want) {
+		t.Errorf("snippets = %q, want %q", r.Snippets, want)
+	}
+}
+
+func TestSearchDocumentsHandlerSearchContent(t *testing.T) {
+	contentCalls := 0
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		doc := types.Document{ID: "a", Title: "Weekly Notes"}
+		if r.URL.Query().Get("withHtmlContent") == "true" {
+			contentCalls++
+			doc.Content = "<article><p>Notes on <b>stoicism</b> and habits.</p></article>"
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{Count: 1, Results: []types.Document{doc}})
+	})
+	defer ts.Close()
+
+	handler := makeSearchDocumentsHandler(newCachedClient(client, cm), search.NewIndexCache(1))
+	run := func(input SearchDocumentsInput) []SearchDocumentResult {
+		t.Helper()
+		result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var results []SearchDocumentResult
+		if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &results); err != nil {
+			t.Fatalf("failed to parse result: %v", err)
+		}
+		return results
+	}
+
+	if results := run(SearchDocumentsInput{Query: "stoicism"}); len(results) != 0 {
+		t.Errorf("body text should not match without search_content, got %+v", results)
+	}
+	if contentCalls != 0 {
+		t.Errorf("content fetched %d times without search_content", contentCalls)
+	}
+
+	for i := 0; i < 2; i++ {
+		results := run(SearchDocumentsInput{Query: "stoicism", SearchContent: true})
+		if len(results) != 1 || results[0].Document.ID != "a" {
+			t.Fatalf("search_content should find document a, got %+v", results)
+		}
+		want := []search.Snippet{{Field: "content", Text: "Notes on **stoicism** and habits."}}
+		if !reflect.DeepEqual(results[0].Snippets, want) {
+			t.Errorf("snippets = %q, want %q", results[0].Snippets, want)
+		}
+	}
+	if contentCalls != 1 {
+		t.Errorf("content fetched %d times, want 1 (cached)", contentCalls)
+	}
+}
+
 func TestSearchDocumentsBasic(t *testing.T) {
 	docs := []types.Document{
 		{ID: "1", Title: "Go Programming", Author: "Rob Pike"},
@@ -175,7 +398,10 @@ func TestSearchDocumentsBasic(t *testing.T) {
 		{ID: "3", Title: "Python Basics", Author: "Guido"},
 	}
 
-	results := searchDocuments(docs, "Programming", "", "", 50)
+	results, err := searchDocumentIndex(search.NewDocumentIndex(docs), SearchDocumentsInput{Query: "Programming", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchDocumentIndex() error: %v", err)
+	}
 	if len(results) != 2 {
 		t.Fatalf("expected 2 results, got %d", len(results))
 	}
@@ -187,7 +413,10 @@ func TestSearchDocumentsLocationFilter(t *testing.T) {
 		{ID: "2", Title: "Rust Programming", Location: "archive"},
 	}
 
-	results := searchDocuments(docs, "Programming", "later", "", 50)
+	results, err := searchDocumentIndex(search.NewDocumentIndex(docs), SearchDocumentsInput{Query: "Programming", Location: "later", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchDocumentIndex() error: %v", err)
+	}
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -202,7 +431,10 @@ func TestSearchDocumentsCategoryFilter(t *testing.T) {
 		{ID: "2", Title: "Go Video", Category: "video"},
 	}
 
-	results := searchDocuments(docs, "Go", "", "article", 50)
+	results, err := searchDocumentIndex(search.NewDocumentIndex(docs), SearchDocumentsInput{Query: "Go", Category: "article", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchDocumentIndex() error: %v", err)
+	}
 	if len(results) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(results))
 	}
@@ -214,7 +446,10 @@ func TestSearchDocumentsInSummaryAndNotes(t *testing.T) {
 		{ID: "2", Title: "also unrelated", Notes: "has the search term here"},
 	}
 
-	results := searchDocuments(docs, "search term", "", "", 50)
+	results, err := searchDocumentIndex(search.NewDocumentIndex(docs), SearchDocumentsInput{Query: "search term", Limit: 50})
+	if err != nil {
+		t.Fatalf("searchDocumentIndex() error: %v", err)
+	}
 	if len(results) != 2 {
 		t.Fatalf("expected 2 results, got %d", len(results))
 	}
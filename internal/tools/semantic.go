@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/embed"
+	"github.com/rhuss/readwise-mcp-server/internal/telemetry"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SemanticSearchInput defines the parameters for the semantic_search tool.
+type SemanticSearchInput struct {
+	Query    string `json:"query" jsonschema:"Natural language description of what to find, e.g. dealing with uncertainty"`
+	SourceID string `json:"source_id,omitempty" jsonschema:"Filter results to a specific source ID"`
+	Limit    int    `json:"limit,omitempty" jsonschema:"Maximum number of results (1-100; default 20)"`
+}
+
+// RegisterSemanticSearchTool registers the semantic_search tool. Highlight
+// vectors are kept per user in vectors.
+func RegisterSemanticSearchTool(s *mcp.Server, client *api.Client, cm *cache.Manager, vectors *embed.VectorCache) {
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "semantic_search",
+		Description: "Search highlights by meaning rather than exact words. Ranks highlights (text and note) by embedding similarity to the query, so a query like \"dealing with uncertainty\" also finds highlights that never use those words. Use search_highlights for exact words, phrases and filters.",
+	}, makeSemanticSearchHandler(newCachedClient(client, cm), vectors))
+}
+
+func makeSemanticSearchHandler(client *cachedClient, vectors *embed.VectorCache) mcp.ToolHandlerFor[SemanticSearchInput, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input SemanticSearchInput) (*mcp.CallToolResult, any, error) {
+		apiKey := auth.APIKeyFromRequest(req)
+		if apiKey == "" {
+			return nil, nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+		if input.Query == "" {
+			return nil, nil, fmt.Errorf("query is required")
+		}
+
+		limit := input.Limit
+		if limit <= 0 {
+			limit = 20
+		}
+		if limit > 100 {
+			limit = 100
+		}
+
+		var sourceID int64
+		if input.SourceID != "" {
+			id, err := strconv.ParseInt(input.SourceID, 10, 64)
+			if err != nil {
+				return nil, nil, api.NewValidationError("invalid_source_id", fmt.Sprintf("source_id must be numeric, got %q", input.SourceID))
+			}
+			sourceID = id
+		}
+
+		exportData, err := client.ExportHighlights(ctx, apiKey, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		vecs, err := vectors.Vectors(ctx, auth.HashAPIKey(apiKey), highlightItems(exportData.Results))
+		if err != nil {
+			return nil, nil, api.NewAPIError("embedding_failed", err.Error())
+		}
+		query, err := vectors.Embed(ctx, input.Query)
+		if err != nil {
+			return nil, nil, api.NewAPIError("embedding_failed", err.Error())
+		}
+
+		_, span := telemetry.Start(ctx, "search.score",
+			attribute.String("search.kind", "semantic"),
+			attribute.Int("search.vectors", len(vecs)),
+		)
+		results := rankBySimilarity(exportData.Results, vecs, query, sourceID, limit)
+		span.SetAttributes(attribute.Int("search.results", len(results)))
+		telemetry.End(span, nil)
+
+		data, _ := json.Marshal(results)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// highlightItems returns the text to embed for every highlight: its text
+// followed by its note.
+func highlightItems(sources []types.ExportSource) []embed.Item {
+	var items []embed.Item
+	for _, s := range sources {
+		for _, h := range s.Highlights {
+			text := h.Text
+			if h.Note != "" {
+				text += "\n" + h.Note
+			}
+			items = append(items, embed.Item{ID: h.ID, Text: text})
+		}
+	}
+	return items
+}
+
+// rankBySimilarity returns the limit highlights most similar to query,
+// optionally restricted to one source. Highlights without a vector or with
+// no positive similarity are left out.
+func rankBySimilarity(sources []types.ExportSource, vecs map[int64][]float32, query []float32, sourceID int64, limit int) []SearchHighlightResult {
+	var results []SearchHighlightResult
+	for si := range sources {
+		s := &sources[si]
+		if sourceID != 0 && s.UserBookID != sourceID {
+			continue
+		}
+		for hi := range s.Highlights {
+			h := &s.Highlights[hi]
+			v, ok := vecs[h.ID]
+			if !ok {
+				continue
+			}
+			if score := embed.Cosine(query, v); score > 0 {
+				results = append(results, SearchHighlightResult{
+					Highlight:      h,
+					SourceTitle:    s.Title,
+					RelevanceScore: math.Round(score*1e4) / 1e4,
+				})
+			}
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
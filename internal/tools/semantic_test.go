@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/embed"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func semanticTestHandler(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{
+		Count: 2,
+		Results: []types.ExportSource{
+			{UserBookID: 1, Title: "Thinking in Bets", Highlights: []types.Highlight{
+				{ID: 10, Text: "Living with uncertainty is a skill"},
+				{ID: 11, Text: "Outcomes are not decisions", Note: "uncertain outcomes"},
+			}},
+			{UserBookID: 2, Title: "Bread", Highlights: []types.Highlight{
+				{ID: 20, Text: "Sourdough needs a lively starter"},
+			}},
+		},
+	})
+}
+
+func callSemanticSearch(t *testing.T, input SemanticSearchInput) []SearchHighlightResult {
+	t.Helper()
+	client, cm, ts := newWriteTestDeps(semanticTestHandler)
+	t.Cleanup(ts.Close)
+
+	handler := makeSemanticSearchHandler(newCachedClient(client, cm), embed.NewVectorCache(embed.NewLocal(0), 1))
+	result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), input)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var results []SearchHighlightResult
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &results); err != nil {
+		t.Fatalf("failed to parse result: %v", err)
+	}
+	return results
+}
+
+func TestSemanticSearchRanksBySimilarity(t *testing.T) {
+	results := callSemanticSearch(t, SemanticSearchInput{Query: "uncertain"})
+	if len(results) < 2 {
+		t.Fatalf("expected at least 2 results, got %+v", results)
+	}
+	for _, r := range results[:2] {
+		if r.Highlight.BookID == 2 || r.SourceTitle != "Thinking in Bets" {
+			t.Errorf("unrelated highlight ranked in the top 2: %+v", results)
+		}
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i-1].RelevanceScore < results[i].RelevanceScore {
+			t.Errorf("results not sorted by score: %+v", results)
+		}
+	}
+}
+
+func TestSemanticSearchSourceFilterAndLimit(t *testing.T) {
+	results := callSemanticSearch(t, SemanticSearchInput{Query: "uncertainty outcomes", SourceID: "1", Limit: 1})
+	if len(results) != 1 || results[0].SourceTitle != "Thinking in Bets" {
+		t.Errorf("expected one result from source 1, got %+v", results)
+	}
+}
+
+func TestSemanticSearchValidation(t *testing.T) {
+	handler := makeSemanticSearchHandler(nil, embed.NewVectorCache(embed.NewLocal(0), 1))
+
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, SemanticSearchInput{Query: "x"}); err == nil {
+		t.Error("expected an error without API key")
+	}
+	if _, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), SemanticSearchInput{}); err == nil {
+		t.Error("expected an error without query")
+	}
+
+	_, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), SemanticSearchInput{Query: "x", SourceID: "abc"})
+	var apiErr *api.ErrorResponse
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_source_id" {
+		t.Errorf("expected invalid_source_id error, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/auth"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// Tag kinds counted by list_all_tags.
+const (
+	tagKindSource    = "source"
+	tagKindHighlight = "highlight"
+	tagKindDocument  = "document"
+)
+
+// ListAllTagsInput defines the parameters for the list_all_tags tool.
+type ListAllTagsInput struct {
+	Prefix string `json:"prefix,omitempty" jsonschema:"Only return tags starting with this prefix (case-insensitive)"`
+}
+
+// TagUsage is a tag name with the number of items carrying it.
+type TagUsage struct {
+	Name string `json:"name"`
+	// Total is the sum of Counts.
+	Total int `json:"total"`
+	// Counts holds the number of sources, highlights and Reader documents
+	// tagged with the tag, keyed by kind. Kinds without use are omitted.
+	Counts map[string]int `json:"counts"`
+}
+
+// ListAllTagsResult is the response of the list_all_tags tool.
+type ListAllTagsResult struct {
+	Count int        `json:"count"`
+	Tags  []TagUsage `json:"tags"`
+}
+
+// RegisterListAllTagsTool registers the list_all_tags tool. With
+// includeReader set, Reader document tags are counted as well; that requires
+// the reader profile.
+func RegisterListAllTagsTool(s *mcp.Server, client *api.Client, cm *cache.Manager, includeReader bool) {
+	description := "List every tag used in the Readwise library with usage counts per kind (source, highlight), most used first. Use it to pick existing tags instead of inventing near-duplicates."
+	if includeReader {
+		description = "List every tag used in the Readwise library and in Reader with usage counts per kind (source, highlight, document), most used first. Use it to pick existing tags instead of inventing near-duplicates."
+	}
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "list_all_tags",
+		Description: description,
+	}, makeListAllTagsHandler(newCachedClient(client, cm), includeReader))
+}
+
+func makeListAllTagsHandler(client *cachedClient, includeReader bool) mcp.ToolHandlerFor[ListAllTagsInput, any] {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input ListAllTagsInput) (*mcp.CallToolResult, any, error) {
+		apiKey := auth.APIKeyFromRequest(req)
+		if apiKey == "" {
+			return nil, nil, fmt.Errorf("missing API key: provide your Readwise API key via the Authorization header")
+		}
+
+		exportData, err := client.ExportHighlights(ctx, apiKey, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var readerTags []types.Tag
+		var docs []types.Document
+		if includeReader {
+			readerTags, err = client.ListReaderTags(ctx, apiKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			docData, err := client.ListDocuments(ctx, apiKey, "", "", "", 0)
+			if err != nil {
+				return nil, nil, err
+			}
+			docs = docData.Results
+		}
+
+		tags := countTags(exportData.Results, readerTags, docs, input.Prefix)
+		data, _ := json.Marshal(ListAllTagsResult{Count: len(tags), Tags: tags})
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		}, nil, nil
+	}
+}
+
+// countTags counts the tags of sources and highlights in the export and of
+// top-level Reader documents. Reader tags without documents are listed with no counts
+// so that every existing tag shows up. Tags are matched by exact name and
+// sorted by total use, then by name.
+func countTags(sources []types.ExportSource, readerTags []types.Tag, docs []types.Document, prefix string) []TagUsage {
+	prefix = strings.ToLower(prefix)
+	byName := make(map[string]*TagUsage)
+	add := func(name, kind string, n int) {
+		if name == "" || !strings.HasPrefix(strings.ToLower(name), prefix) {
+			return
+		}
+		u := byName[name]
+		if u == nil {
+			u = &TagUsage{Name: name, Counts: make(map[string]int)}
+			byName[name] = u
+		}
+		if n > 0 {
+			u.Counts[kind] += n
+			u.Total += n
+		}
+	}
+
+	for _, s := range sources {
+		for _, t := range s.BookTags {
+			add(t.Name, tagKindSource, 1)
+		}
+		for _, h := range s.Highlights {
+			for _, t := range h.Tags {
+				add(t.Name, tagKindHighlight, 1)
+			}
+		}
+	}
+	for _, t := range readerTags {
+		add(t.Name, tagKindDocument, 0)
+	}
+	for _, d := range docs {
+		// Reader highlights and notes are child documents; their tags are
+		// counted with the highlights they sync to in the export.
+		if d.ParentID != "" {
+			continue
+		}
+		for key, t := range d.Tags {
+			name := t.Name
+			if name == "" {
+				name = key
+			}
+			add(name, tagKindDocument, 1)
+		}
+	}
+
+	tags := make([]TagUsage, 0, len(byName))
+	for _, u := range byName {
+		tags = append(tags, *u)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Total != tags[j].Total {
+			return tags[i].Total > tags[j].Total
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+func TestCountTags(t *testing.T) {
+	sources := []types.ExportSource{
+		{UserBookID: 1, BookTags: []types.Tag{{Name: "productivity"}}, Highlights: []types.Highlight{
+			{ID: 1, Tags: []types.Tag{{Name: "focus"}, {Name: "productivity"}}},
+			{ID: 2, Tags: []types.Tag{{Name: "focus"}}},
+		}},
+		{UserBookID: 2, BookTags: []types.Tag{{Name: "Productivity"}}},
+	}
+	readerTags := []types.Tag{{Name: "focus"}, {Name: "unused"}}
+	docs := []types.Document{
+		{ID: "a", Tags: map[string]types.Tag{"focus": {Name: "focus"}}},
+		{ID: "b", Tags: map[string]types.Tag{"productivity": {}}},
+		{ID: "c", ParentID: "a", Tags: map[string]types.Tag{"focus": {Name: "focus"}}},
+	}
+
+	got := countTags(sources, readerTags, docs, "")
+	want := []TagUsage{
+		{Name: "focus", Total: 3, Counts: map[string]int{"highlight": 2, "document": 1}},
+		{Name: "productivity", Total: 3, Counts: map[string]int{"source": 1, "highlight": 1, "document": 1}},
+		{Name: "Productivity", Total: 1, Counts: map[string]int{"source": 1}},
+		{Name: "unused", Total: 0, Counts: map[string]int{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("countTags() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	got = countTags(sources, readerTags, docs, "PRO")
+	if len(got) != 2 || got[0].Name != "productivity" || got[1].Name != "Productivity" {
+		t.Errorf("prefix PRO = %+v, want both productivity tags", got)
+	}
+}
+
+func TestListAllTagsHandler(t *testing.T) {
+	client, cm, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/export/"):
+			json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{Count: 1, Results: []types.ExportSource{
+				{UserBookID: 1, BookTags: []types.Tag{{Name: "books"}}},
+			}})
+		case strings.Contains(r.URL.Path, "/tags/"):
+			json.NewEncoder(w).Encode([]types.Tag{{Name: "reader-tag"}})
+		default:
+			json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{})
+		}
+	})
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		includeReader bool
+		want          []string
+	}{
+		{false, []string{"books"}},
+		{true, []string{"books", "reader-tag"}},
+	} {
+		handler := makeListAllTagsHandler(newCachedClient(client, cm), tt.includeReader)
+		result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), ListAllTagsInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var got ListAllTagsResult
+		if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got); err != nil {
+			t.Fatalf("failed to parse result: %v", err)
+		}
+		var names []string
+		for _, tag := range got.Tags {
+			names = append(names, tag.Name)
+		}
+		if got.Count != len(tt.want) || !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("includeReader=%v: tags = %v (count %d), want %v", tt.includeReader, names, got.Count, tt.want)
+		}
+	}
+
+	handler := makeListAllTagsHandler(newCachedClient(client, cm), false)
+	if _, _, err := handler(context.Background(), &mcp.CallToolRequest{}, ListAllTagsInput{}); err == nil {
+		t.Error("expected error for missing API key")
+	}
+}
@@ -20,7 +20,8 @@ type ListVideosInput struct {
 
 // GetVideoInput defines the parameters for the get_video tool.
 type GetVideoInput struct {
-	ID string `json:"id" jsonschema:"Video document ID"`
+	ID            string `json:"id" jsonschema:"Video document ID"`
+	ContentFormat string `json:"content_format,omitempty" jsonschema:"Format of the transcript: html (default), markdown or text"`
 }
 
 // GetVideoPositionInput defines the parameters for the get_video_position tool.
@@ -52,7 +53,7 @@ func RegisterVideoTools(s *mcp.Server, client *api.Client, cm *cache.Manager) {
 
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "get_video",
-		Description: "Get a video document with transcript content.",
+		Description: "Get a video document with transcript content, as HTML, Markdown or plain text.",
 	}, makeGetVideoHandler(client))
 
 	mcp.AddTool(s, &mcp.Tool{
@@ -107,13 +108,16 @@ func makeGetVideoHandler(client *api.Client) mcp.ToolHandlerFor[GetVideoInput, a
 		if input.ID == "" {
 			return nil, nil, fmt.Errorf("id is required")
 		}
+		if err := validateContentFormat(input.ContentFormat); err != nil {
+			return nil, nil, err
+		}
 
 		result, err := client.GetDocument(ctx, apiKey, input.ID, true)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		data, _ := json.Marshal(result)
+		data, _ := json.Marshal(formatContent(result, input.ContentFormat))
 		return &mcp.CallToolResult{
 			Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
 		}, nil, nil
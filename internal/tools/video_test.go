@@ -102,6 +102,29 @@ func TestGetVideoHandler(t *testing.T) {
 	}
 }
 
+func TestGetVideoHandlerTranscriptText(t *testing.T) {
+	client, _, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.CursorResponse[types.Document]{
+			Count: 1,
+			Results: []types.Document{
+				{ID: "v1", Title: "My Video", Category: "video", Content: "<p>First line</p><p>Second <b>line</b></p>"},
+			},
+		})
+	})
+	defer ts.Close()
+
+	handler := makeGetVideoHandler(client)
+	result, _, err := handler(context.Background(), newReqWithAPIKey("test-key"), GetVideoInput{ID: "v1", ContentFormat: "markdown"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]any
+	json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &got)
+	if got["content"] != "First line\n\nSecond **line**" || got["html"] != nil {
+		t.Errorf("unexpected transcript: %v", got)
+	}
+}
+
 func TestGetVideoHandlerMissingID(t *testing.T) {
 	client, _, ts := newWriteTestDeps(func(w http.ResponseWriter, r *http.Request) {})
 	defer ts.Close()
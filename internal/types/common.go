@@ -12,29 +12,60 @@ var (
 	ErrTLSIncomplete = fmt.Errorf("TLS configuration incomplete: both TLS_CERT_FILE and TLS_KEY_FILE must be set")
 )
 
+// Transport modes.
+const (
+	TransportHTTP  = "http"
+	TransportStdio = "stdio"
+)
+
 // Config holds server configuration loaded from environment variables.
 type Config struct {
-	Profiles        []string
-	Port            int
-	LogLevel        string
-	CacheMaxSizeMB  int
-	CacheTTLSeconds int
-	CacheEnabled    bool
-	TLSCertFile     string
-	TLSKeyFile      string
-	TLSPort         int
+	Profiles                 []string
+	Transport                string
+	APIKey                   string
+	APIKeyFile               string
+	Port                     int
+	LogLevel                 string
+	CacheMaxSizeMB           int
+	CacheTTLSeconds          int
+	CacheEnabled             bool
+	TLSCertFile              string
+	TLSKeyFile               string
+	TLSPort                  int
+	RetryMaxAttempts         int
+	RetryBaseDelayMS         int
+	RetryMaxDelayMS          int
+	RetryAfterMaxSeconds     int
+	RateLimitV2PerMinute     int
+	RateLimitV2ListPerMinute int
+	RateLimitV3PerMinute     int
+	OTLPEndpoint             string
+	EmbeddingsProvider       string
+	EmbeddingsURL            string
+	EmbeddingsModel          string
+	EmbeddingsAPIKey         string
+	ExportTemplateFile       string
 }
 
 // LoadConfig reads configuration from environment variables with defaults.
 func LoadConfig() Config {
 	c := Config{
-		Profiles:        []string{"readwise"},
-		Port:            8080,
-		LogLevel:        "info",
-		CacheMaxSizeMB:  128,
-		CacheTTLSeconds: 300,
-		CacheEnabled:    true,
-		TLSPort:         8443,
+		Profiles:                 []string{"readwise"},
+		Transport:                TransportHTTP,
+		Port:                     8080,
+		LogLevel:                 "info",
+		CacheMaxSizeMB:           128,
+		CacheTTLSeconds:          300,
+		CacheEnabled:             true,
+		TLSPort:                  8443,
+		RetryMaxAttempts:         3,
+		RetryBaseDelayMS:         500,
+		RetryMaxDelayMS:          10000,
+		RetryAfterMaxSeconds:     30,
+		RateLimitV2PerMinute:     240,
+		RateLimitV2ListPerMinute: 20,
+		RateLimitV3PerMinute:     20,
+		EmbeddingsProvider:       "local",
 	}
 
 	if v := os.Getenv("READWISE_PROFILES"); v != "" {
@@ -48,6 +79,18 @@ func LoadConfig() Config {
 		}
 	}
 
+	if v := os.Getenv("TRANSPORT"); v != "" {
+		c.Transport = strings.ToLower(strings.TrimSpace(v))
+	}
+
+	if v := os.Getenv("READWISE_API_KEY"); v != "" {
+		c.APIKey = v
+	}
+
+	if v := os.Getenv("READWISE_API_KEY_FILE"); v != "" {
+		c.APIKeyFile = v
+	}
+
 	if v := os.Getenv("PORT"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n > 0 {
 			c.Port = n
@@ -88,9 +131,112 @@ func LoadConfig() Config {
 		}
 	}
 
+	if v := os.Getenv("RETRY_MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			c.RetryMaxAttempts = n
+		}
+	}
+
+	if v := os.Getenv("RETRY_BASE_DELAY_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.RetryBaseDelayMS = n
+		}
+	}
+
+	if v := os.Getenv("RETRY_MAX_DELAY_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.RetryMaxDelayMS = n
+		}
+	}
+
+	if v := os.Getenv("RETRY_AFTER_MAX_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.RetryAfterMaxSeconds = n
+		}
+	}
+
+	if v := os.Getenv("RATE_LIMIT_V2_PER_MINUTE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.RateLimitV2PerMinute = n
+		}
+	}
+
+	if v := os.Getenv("RATE_LIMIT_V2_LIST_PER_MINUTE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.RateLimitV2ListPerMinute = n
+		}
+	}
+
+	if v := os.Getenv("RATE_LIMIT_V3_PER_MINUTE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			c.RateLimitV3PerMinute = n
+		}
+	}
+
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+		c.OTLPEndpoint = v
+	}
+
+	if v := os.Getenv("EMBEDDINGS_PROVIDER"); v != "" {
+		c.EmbeddingsProvider = v
+	}
+
+	if v := os.Getenv("EMBEDDINGS_URL"); v != "" {
+		c.EmbeddingsURL = v
+	}
+
+	if v := os.Getenv("EMBEDDINGS_MODEL"); v != "" {
+		c.EmbeddingsModel = v
+	}
+
+	if v := os.Getenv("EMBEDDINGS_API_KEY"); v != "" {
+		c.EmbeddingsAPIKey = v
+	}
+
+	if v := os.Getenv("EXPORT_TEMPLATE_FILE"); v != "" {
+		c.ExportTemplateFile = v
+	}
+
 	return c
 }
 
+// TracingEnabled returns true when an OTLP endpoint is configured for trace export.
+func (c Config) TracingEnabled() bool {
+	return c.OTLPEndpoint != ""
+}
+
+// ValidateTransport checks that the transport mode is known.
+func (c Config) ValidateTransport() error {
+	switch c.Transport {
+	case TransportHTTP, TransportStdio:
+		return nil
+	}
+	return fmt.Errorf("unknown transport %q: must be %q or %q", c.Transport, TransportHTTP, TransportStdio)
+}
+
+// ResolveAPIKey returns the server-wide Readwise API key used in stdio mode.
+// READWISE_API_KEY takes precedence over the contents of READWISE_API_KEY_FILE.
+func (c Config) ResolveAPIKey() (string, error) {
+	if key := strings.TrimSpace(c.APIKey); key != "" {
+		return key, nil
+	}
+
+	if c.APIKeyFile == "" {
+		return "", fmt.Errorf("no API key configured: set READWISE_API_KEY or READWISE_API_KEY_FILE")
+	}
+
+	data, err := os.ReadFile(c.APIKeyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file is empty: %s", c.APIKeyFile)
+	}
+	return key, nil
+}
+
 // TLSEnabled returns true when both TLS certificate and key files are configured.
 func (c Config) TLSEnabled() bool {
 	return c.TLSCertFile != "" && c.TLSKeyFile != ""
@@ -2,12 +2,13 @@ package types
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestLoadConfigDefaults(t *testing.T) {
 	// Clear any env vars that might interfere
-	for _, key := range []string{"READWISE_PROFILES", "PORT", "LOG_LEVEL", "CACHE_MAX_SIZE_MB", "CACHE_TTL_SECONDS", "CACHE_ENABLED", "TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_PORT"} {
+	for _, key := range []string{"READWISE_PROFILES", "PORT", "LOG_LEVEL", "CACHE_MAX_SIZE_MB", "CACHE_TTL_SECONDS", "CACHE_ENABLED", "TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_PORT", "RETRY_MAX_ATTEMPTS", "RETRY_AFTER_MAX_SECONDS", "RATE_LIMIT_V2_PER_MINUTE", "RATE_LIMIT_V2_LIST_PER_MINUTE", "RATE_LIMIT_V3_PER_MINUTE", "TRANSPORT", "OTEL_EXPORTER_OTLP_ENDPOINT", "EMBEDDINGS_PROVIDER"} {
 		t.Setenv(key, "")
 		os.Unsetenv(key)
 	}
@@ -32,6 +33,30 @@ func TestLoadConfigDefaults(t *testing.T) {
 	if !cfg.CacheEnabled {
 		t.Error("CacheEnabled = false, want true")
 	}
+	if cfg.Transport != TransportHTTP {
+		t.Errorf("Transport = %q, want %q", cfg.Transport, TransportHTTP)
+	}
+	if cfg.TracingEnabled() {
+		t.Error("TracingEnabled() = true, want false without an OTLP endpoint")
+	}
+	if cfg.RetryMaxAttempts != 3 {
+		t.Errorf("RetryMaxAttempts = %d, want 3", cfg.RetryMaxAttempts)
+	}
+	if cfg.RetryAfterMaxSeconds != 30 {
+		t.Errorf("RetryAfterMaxSeconds = %d, want 30", cfg.RetryAfterMaxSeconds)
+	}
+	if cfg.RateLimitV2PerMinute != 240 {
+		t.Errorf("RateLimitV2PerMinute = %d, want 240", cfg.RateLimitV2PerMinute)
+	}
+	if cfg.RateLimitV2ListPerMinute != 20 {
+		t.Errorf("RateLimitV2ListPerMinute = %d, want 20", cfg.RateLimitV2ListPerMinute)
+	}
+	if cfg.RateLimitV3PerMinute != 20 {
+		t.Errorf("RateLimitV3PerMinute = %d, want 20", cfg.RateLimitV3PerMinute)
+	}
+	if cfg.EmbeddingsProvider != "local" {
+		t.Errorf("EmbeddingsProvider = %q, want %q", cfg.EmbeddingsProvider, "local")
+	}
 }
 
 func TestLoadConfigEnvOverrides(t *testing.T) {
@@ -64,6 +89,157 @@ func TestLoadConfigEnvOverrides(t *testing.T) {
 	}
 }
 
+func TestLoadConfigRetryOverrides(t *testing.T) {
+	t.Setenv("RETRY_MAX_ATTEMPTS", "5")
+	t.Setenv("RETRY_BASE_DELAY_MS", "100")
+	t.Setenv("RETRY_MAX_DELAY_MS", "2000")
+	t.Setenv("RETRY_AFTER_MAX_SECONDS", "0")
+
+	cfg := LoadConfig()
+
+	if cfg.RetryMaxAttempts != 5 {
+		t.Errorf("RetryMaxAttempts = %d, want 5", cfg.RetryMaxAttempts)
+	}
+	if cfg.RetryBaseDelayMS != 100 {
+		t.Errorf("RetryBaseDelayMS = %d, want 100", cfg.RetryBaseDelayMS)
+	}
+	if cfg.RetryMaxDelayMS != 2000 {
+		t.Errorf("RetryMaxDelayMS = %d, want 2000", cfg.RetryMaxDelayMS)
+	}
+	if cfg.RetryAfterMaxSeconds != 0 {
+		t.Errorf("RetryAfterMaxSeconds = %d, want 0", cfg.RetryAfterMaxSeconds)
+	}
+}
+
+func TestLoadConfigRateLimitOverrides(t *testing.T) {
+	t.Setenv("RATE_LIMIT_V2_PER_MINUTE", "60")
+	t.Setenv("RATE_LIMIT_V2_LIST_PER_MINUTE", "10")
+	t.Setenv("RATE_LIMIT_V3_PER_MINUTE", "0")
+
+	cfg := LoadConfig()
+
+	if cfg.RateLimitV2PerMinute != 60 {
+		t.Errorf("RateLimitV2PerMinute = %d, want 60", cfg.RateLimitV2PerMinute)
+	}
+	if cfg.RateLimitV2ListPerMinute != 10 {
+		t.Errorf("RateLimitV2ListPerMinute = %d, want 10", cfg.RateLimitV2ListPerMinute)
+	}
+	if cfg.RateLimitV3PerMinute != 0 {
+		t.Errorf("RateLimitV3PerMinute = %d, want 0", cfg.RateLimitV3PerMinute)
+	}
+}
+
+func TestLoadConfigTransport(t *testing.T) {
+	t.Setenv("TRANSPORT", "STDIO")
+	t.Setenv("READWISE_API_KEY", "env-key")
+	t.Setenv("READWISE_API_KEY_FILE", "/run/secrets/readwise")
+
+	cfg := LoadConfig()
+
+	if cfg.Transport != TransportStdio {
+		t.Errorf("Transport = %q, want %q", cfg.Transport, TransportStdio)
+	}
+	if cfg.APIKey != "env-key" {
+		t.Errorf("APIKey = %q, want %q", cfg.APIKey, "env-key")
+	}
+	if cfg.APIKeyFile != "/run/secrets/readwise" {
+		t.Errorf("APIKeyFile = %q, want %q", cfg.APIKeyFile, "/run/secrets/readwise")
+	}
+}
+
+func TestLoadConfigTracing(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+
+	cfg := LoadConfig()
+
+	if cfg.OTLPEndpoint != "http://collector:4318" {
+		t.Errorf("OTLPEndpoint = %q, want %q", cfg.OTLPEndpoint, "http://collector:4318")
+	}
+	if !cfg.TracingEnabled() {
+		t.Error("TracingEnabled() = false, want true")
+	}
+}
+
+func TestLoadConfigEmbeddings(t *testing.T) {
+	t.Setenv("EMBEDDINGS_PROVIDER", "openai")
+	t.Setenv("EMBEDDINGS_URL", "http://localhost:11434/v1")
+	t.Setenv("EMBEDDINGS_MODEL", "nomic-embed-text")
+	t.Setenv("EMBEDDINGS_API_KEY", "secret")
+
+	cfg := LoadConfig()
+
+	if cfg.EmbeddingsProvider != "openai" {
+		t.Errorf("EmbeddingsProvider = %q, want %q", cfg.EmbeddingsProvider, "openai")
+	}
+	if cfg.EmbeddingsURL != "http://localhost:11434/v1" {
+		t.Errorf("EmbeddingsURL = %q, want %q", cfg.EmbeddingsURL, "http://localhost:11434/v1")
+	}
+	if cfg.EmbeddingsModel != "nomic-embed-text" {
+		t.Errorf("EmbeddingsModel = %q, want %q", cfg.EmbeddingsModel, "nomic-embed-text")
+	}
+	if cfg.EmbeddingsAPIKey != "secret" {
+		t.Errorf("EmbeddingsAPIKey = %q, want %q", cfg.EmbeddingsAPIKey, "secret")
+	}
+}
+
+func TestLoadConfigExportTemplate(t *testing.T) {
+	t.Setenv("EXPORT_TEMPLATE_FILE", "/etc/readwise/export.tmpl")
+
+	cfg := LoadConfig()
+
+	if cfg.ExportTemplateFile != "/etc/readwise/export.tmpl" {
+		t.Errorf("ExportTemplateFile = %q, want %q", cfg.ExportTemplateFile, "/etc/readwise/export.tmpl")
+	}
+}
+
+func TestValidateTransport(t *testing.T) {
+	for _, transport := range []string{TransportHTTP, TransportStdio} {
+		if err := (Config{Transport: transport}).ValidateTransport(); err != nil {
+			t.Errorf("ValidateTransport(%q) error: %v", transport, err)
+		}
+	}
+	if err := (Config{Transport: "sse"}).ValidateTransport(); err == nil {
+		t.Error("ValidateTransport(\"sse\") expected error")
+	}
+}
+
+func TestResolveAPIKey(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("file-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(emptyFile, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    string
+		wantErr bool
+	}{
+		{"env key", Config{APIKey: "env-key"}, "env-key", false},
+		{"env key wins over file", Config{APIKey: "env-key", APIKeyFile: keyFile}, "env-key", false},
+		{"file key trimmed", Config{APIKeyFile: keyFile}, "file-key", false},
+		{"missing file", Config{APIKeyFile: filepath.Join(t.TempDir(), "missing")}, "", true},
+		{"empty file", Config{APIKeyFile: emptyFile}, "", true},
+		{"not configured", Config{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.ResolveAPIKey()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveAPIKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveAPIKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoadConfigProfileParsing(t *testing.T) {
 	tests := []struct {
 		name     string
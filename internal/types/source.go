@@ -37,5 +37,6 @@ type ExportSource struct {
 	ReadwiseURL    string           `json:"readwise_url"`
 	UniqueURL      string           `json:"unique_url"`
 	HighlightCount int              `json:"num_highlights"`
+	BookTags       []Tag            `json:"book_tags"`
 	Highlights     []Highlight      `json:"highlights"`
 }
@@ -0,0 +1,292 @@
+// Package vault mirrors the Readwise highlight export into a directory of
+// Markdown files, such as an Obsidian vault, keeping the notes users add to
+// those files.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/cache"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// StateFile is the name of the file in the vault directory that records the
+// last sync.
+const StateFile = ".readwise-sync.json"
+
+// Markers delimit the block of a file that sync owns. Everything outside the
+// block belongs to the user and is never rewritten.
+const (
+	BeginMarker = "<!-- readwise:begin (generated, edits here are overwritten) -->"
+	EndMarker   = "<!-- readwise:end -->"
+)
+
+// maxNameLen bounds the length of file names derived from titles, in runes.
+const maxNameLen = 100
+
+// State is the content of StateFile. Sources holds the export as of SyncedAt
+// so that changes fetched with updatedAfter can be merged into full sources.
+type State struct {
+	SyncedAt time.Time            `json:"synced_at"`
+	Files    map[int64]string     `json:"files"`
+	Sources  []types.ExportSource `json:"sources"`
+}
+
+// Result reports what a sync did. File names are relative to the vault
+// directory.
+type Result struct {
+	// Full is true when the whole export was fetched rather than the
+	// changes since the last sync.
+	Full      bool     `json:"full"`
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	// Skipped lists files whose markers were removed. They are left alone
+	// because it is no longer clear which part sync may replace.
+	Skipped []string `json:"skipped"`
+}
+
+// Options configure a sync.
+type Options struct {
+	// Full ignores the last sync time and fetches the whole export, which
+	// also picks up deleted highlights.
+	Full bool
+}
+
+// Sync writes one Markdown file per source into dir, rendered with
+// markdown. The first sync, or one with opts.Full, fetches the whole export;
+// later syncs fetch only sources changed since the last one and rewrite just
+// their files. Files are placed in a directory per category and named after
+// the source title; once created, a file keeps its name. Only the block
+// between BeginMarker and EndMarker is replaced on updates; frontmatter
+// rendered by the template is written when a file is created and left to the
+// user afterwards. The state is saved after all files are written, so the
+// next run after a failed sync fetches the same changes again.
+func Sync(ctx context.Context, client *api.Client, apiKey, dir string, markdown *export.Markdown, opts Options) (*Result, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	state, err := loadState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Full: opts.Full || state.SyncedAt.IsZero()}
+	updatedAfter := ""
+	if !result.Full {
+		updatedAfter = state.SyncedAt.Format(time.RFC3339)
+	}
+
+	startedAt := time.Now().UTC()
+	exportData, err := client.ExportHighlights(ctx, apiKey, updatedAfter)
+	if err != nil {
+		return nil, err
+	}
+	changed := exportData.Results
+	if result.Full {
+		state.Sources = changed
+	} else {
+		state.Sources = cache.MergeExport(state.Sources, changed)
+	}
+
+	// Render the merged sources, which carry all highlights, not just the
+	// changed ones from the delta.
+	byID := make(map[int64]*types.ExportSource, len(state.Sources))
+	for i := range state.Sources {
+		byID[state.Sources[i].UserBookID] = &state.Sources[i]
+	}
+	ids := make([]int64, 0, len(changed))
+	for _, s := range changed {
+		ids = append(ids, s.UserBookID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	taken := make(map[string]bool, len(state.Files))
+	for _, name := range state.Files {
+		taken[strings.ToLower(name)] = true
+	}
+	for _, id := range ids {
+		source := byID[id]
+		name, ok := state.Files[id]
+		if !ok {
+			name = fileName(dir, source, taken)
+			taken[strings.ToLower(name)] = true
+			state.Files[id] = name
+		}
+		if err := writeSource(dir, name, source, markdown, result); err != nil {
+			return nil, err
+		}
+	}
+
+	state.SyncedAt = startedAt
+	if err := saveState(dir, state); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// writeSource renders source into the file name, creating it or replacing
+// its marked block, and records the outcome in result.
+func writeSource(dir, name string, source *types.ExportSource, markdown *export.Markdown, result *Result) error {
+	var buf bytes.Buffer
+	if err := markdown.RenderSource(&buf, source); err != nil {
+		return err
+	}
+	frontmatter, body := splitFrontmatter(buf.String())
+	block := BeginMarker + "\n" + strings.TrimSpace(body) + "\n" + EndMarker + "\n"
+
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		result.Created = append(result.Created, name)
+		return writeFile(path, []byte(frontmatter+block))
+	case err != nil:
+		return err
+	}
+
+	content, ok := replaceBlock(string(existing), block)
+	if !ok {
+		result.Skipped = append(result.Skipped, name)
+		return nil
+	}
+	if content == string(existing) {
+		result.Unchanged++
+		return nil
+	}
+	result.Updated = append(result.Updated, name)
+	return writeFile(path, []byte(content))
+}
+
+// replaceBlock replaces the marked block in content. It reports false if
+// the markers are missing or out of order.
+func replaceBlock(content, block string) (string, bool) {
+	begin := strings.Index(content, BeginMarker)
+	if begin < 0 {
+		return "", false
+	}
+	end := strings.Index(content[begin:], EndMarker)
+	if end < 0 {
+		return "", false
+	}
+	end += begin + len(EndMarker)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:begin] + block + content[end:], true
+}
+
+// splitFrontmatter splits rendered Markdown into its leading YAML
+// frontmatter, if any, and the rest.
+func splitFrontmatter(s string) (frontmatter, body string) {
+	if !strings.HasPrefix(s, "---\n") {
+		return "", s
+	}
+	end := strings.Index(s[4:], "\n---\n")
+	if end < 0 {
+		return "", s
+	}
+	end += 4 + len("\n---\n")
+	return s[:end] + "\n", s[end:]
+}
+
+// fileName derives the path of a new source file from its category and
+// title. Names already taken by another source, compared ignoring case for
+// case-insensitive file systems, or by a file of the user in dir get the
+// source ID appended.
+func fileName(dir string, source *types.ExportSource, taken map[string]bool) string {
+	category := sanitize(source.Category)
+	if category == "" {
+		category = "other"
+	}
+	title := sanitize(source.Title)
+	if title == "" {
+		title = "Untitled"
+	}
+	name := filepath.Join(category, title+".md")
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil || taken[strings.ToLower(name)] {
+		name = filepath.Join(category, title+" ("+strconv.FormatInt(source.UserBookID, 10)+").md")
+	}
+	return name
+}
+
+// sanitize makes s usable as a file name on common file systems and in
+// Obsidian links, which reserve #, ^, [, ] and |.
+func sanitize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r < ' ', strings.ContainsRune(`/\:*?"<>|#^[]`, r):
+			return ' '
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > maxNameLen {
+		s = strings.TrimSpace(string(r[:maxNameLen]))
+	}
+	return strings.Trim(s, ". ")
+}
+
+func loadState(dir string) (*State, error) {
+	state := &State{}
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("invalid sync state %s: %w", StateFile, err)
+		}
+	}
+	if state.Files == nil {
+		state.Files = make(map[int64]string)
+	}
+	return state, nil
+}
+
+func saveState(dir string, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, StateFile), data)
+}
+
+// writeFile replaces path through a temporary file, so an interrupted sync
+// never leaves a truncated note behind.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".readwise-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,190 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhuss/readwise-mcp-server/internal/api"
+	"github.com/rhuss/readwise-mcp-server/internal/export"
+	"github.com/rhuss/readwise-mcp-server/internal/types"
+)
+
+// fakeUpstream serves the full export for requests without updatedAfter and
+// delta otherwise, recording the updatedAfter values it saw.
+type fakeUpstream struct {
+	full, delta  []types.ExportSource
+	updatedAfter []string
+}
+
+func (f *fakeUpstream) start(t *testing.T) *api.Client {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		since := r.URL.Query().Get("updatedAfter")
+		f.updatedAfter = append(f.updatedAfter, since)
+		results := f.full
+		if since != "" {
+			results = f.delta
+		}
+		json.NewEncoder(w).Encode(types.CursorResponse[types.ExportSource]{Count: len(results), Results: results})
+	}))
+	t.Cleanup(ts.Close)
+	return api.NewClientWithBaseURLs(ts.URL, ts.URL)
+}
+
+func testMarkdown(t *testing.T) *export.Markdown {
+	t.Helper()
+	markdown, err := export.NewMarkdown("---\ntitle: {{ yaml .Title }}\n---\n\n# {{ .Title }}\n{{ range .Highlights }}\n> {{ .Text }}\n{{ end }}")
+	if err != nil {
+		t.Fatalf("NewMarkdown() error: %v", err)
+	}
+	return markdown
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	return string(data)
+}
+
+func TestSync(t *testing.T) {
+	upstream := &fakeUpstream{full: []types.ExportSource{
+		{UserBookID: 1, Title: "Deep Work", Category: "books", Highlights: []types.Highlight{{ID: 10, Text: "first"}}},
+		{UserBookID: 2, Title: "Deep Work", Category: "books", Highlights: []types.Highlight{{ID: 20, Text: "other"}}},
+		{UserBookID: 3, Title: "What: Why?", Category: "articles", Highlights: []types.Highlight{{ID: 30, Text: "third"}}},
+	}}
+	client := upstream.start(t)
+	markdown := testMarkdown(t)
+	dir := t.TempDir()
+
+	result, err := Sync(context.Background(), client, "key", dir, markdown, Options{})
+	if err != nil {
+		t.Fatalf("first Sync() error: %v", err)
+	}
+	wantCreated := []string{filepath.Join("books", "Deep Work.md"), filepath.Join("books", "Deep Work (2).md"), filepath.Join("articles", "What Why.md")}
+	if !result.Full || !reflect.DeepEqual(result.Created, wantCreated) {
+		t.Fatalf("first sync = %+v, want full sync creating %q", result, wantCreated)
+	}
+
+	path := filepath.Join(dir, "books", "Deep Work.md")
+	want := "---\ntitle: \"Deep Work\"\n---\n\n" + BeginMarker + "\n# Deep Work\n\n> first\n" + EndMarker + "\n"
+	if got := readFile(t, path); got != want {
+		t.Fatalf("created file =\n%s\nwant\n%s", got, want)
+	}
+
+	// The user adds notes around the block and edits the frontmatter.
+	edited := strings.Replace(want, "title:", "rating: 5\ntitle:", 1) + "\n## My notes\n\nKeep this.\n"
+	edited = strings.Replace(edited, BeginMarker, "Intro by me.\n\n"+BeginMarker, 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A file without markers is no longer managed.
+	unmanaged := filepath.Join(dir, "articles", "What Why.md")
+	if err := os.WriteFile(unmanaged, []byte("my own file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	upstream.delta = []types.ExportSource{
+		{UserBookID: 1, Title: "Deep Work", Category: "books", Highlights: []types.Highlight{{ID: 11, Text: "second"}}},
+		{UserBookID: 3, Title: "What: Why?", Category: "articles", Highlights: []types.Highlight{{ID: 31, Text: "new"}}},
+		{UserBookID: 4, Title: "New Book", Category: "", Highlights: []types.Highlight{{ID: 40, Text: "fourth"}}},
+	}
+	result, err = Sync(context.Background(), client, "key", dir, markdown, Options{})
+	if err != nil {
+		t.Fatalf("second Sync() error: %v", err)
+	}
+	if len(upstream.updatedAfter) != 2 || upstream.updatedAfter[0] != "" || upstream.updatedAfter[1] == "" {
+		t.Errorf("updatedAfter values = %q, want a full export then a delta", upstream.updatedAfter)
+	}
+	want2 := &Result{
+		Created: []string{filepath.Join("other", "New Book.md")},
+		Updated: []string{filepath.Join("books", "Deep Work.md")},
+		Skipped: []string{filepath.Join("articles", "What Why.md")},
+	}
+	if !reflect.DeepEqual(result, want2) {
+		t.Errorf("second sync = %+v, want %+v", result, want2)
+	}
+
+	wantEdited := strings.Replace(edited, "> first\n", "> first\n\n> second\n", 1)
+	if got := readFile(t, path); got != wantEdited {
+		t.Errorf("updated file =\n%s\nwant\n%s", got, wantEdited)
+	}
+	if got := readFile(t, unmanaged); got != "my own file\n" {
+		t.Errorf("unmanaged file was changed: %q", got)
+	}
+
+	// A forced full sync rewrites nothing that is already current.
+	upstream.full = []types.ExportSource{
+		{UserBookID: 1, Title: "Deep Work", Category: "books", Highlights: []types.Highlight{{ID: 10, Text: "first"}, {ID: 11, Text: "second"}}},
+		upstream.full[1],
+	}
+	result, err = Sync(context.Background(), client, "key", dir, markdown, Options{Full: true})
+	if err != nil {
+		t.Fatalf("full Sync() error: %v", err)
+	}
+	if !result.Full || len(result.Created) != 0 || len(result.Updated) != 0 || result.Unchanged != 2 {
+		t.Errorf("full sync = %+v, want both files unchanged", result)
+	}
+}
+
+func TestSyncKeepsExistingUserFiles(t *testing.T) {
+	upstream := &fakeUpstream{full: []types.ExportSource{
+		{UserBookID: 7, Title: "Deep Work", Category: "books", Highlights: []types.Highlight{{ID: 70, Text: "first"}}},
+	}}
+	client := upstream.start(t)
+	dir := t.TempDir()
+	own := filepath.Join(dir, "books", "Deep Work.md")
+	if err := os.MkdirAll(filepath.Dir(own), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(own, []byte("my own note\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Sync(context.Background(), client, "key", dir, testMarkdown(t), Options{})
+	if err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+	want := []string{filepath.Join("books", "Deep Work (7).md")}
+	if !reflect.DeepEqual(result.Created, want) || len(result.Skipped) != 0 {
+		t.Errorf("sync = %+v, want %q created", result, want)
+	}
+	if got := readFile(t, own); got != "my own note\n" {
+		t.Errorf("user file was changed: %q", got)
+	}
+}
+
+func TestSyncRejectsCorruptState(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, StateFile), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client := (&fakeUpstream{}).start(t)
+	if _, err := Sync(context.Background(), client, "key", dir, testMarkdown(t), Options{}); err == nil {
+		t.Error("expected an error for a corrupt state file")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"Deep Work":               "Deep Work",
+		"  What: Why?  ":          "What Why",
+		"a/b\\c [[link]] #tag ^x": "a b c link tag x",
+		"...":                     "",
+		strings.Repeat("x", 120):  strings.Repeat("x", maxNameLen),
+	}
+	for in, want := range tests {
+		if got := sanitize(in); got != want {
+			t.Errorf("sanitize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}